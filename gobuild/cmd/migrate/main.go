@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/database"
+)
+
+// Comando de migrações do schema:
+//
+//	go run ./cmd/migrate up           -> aplica todas as migrações pendentes
+//	go run ./cmd/migrate down [n]     -> desfaz as últimas n migrações (padrão 1)
+//	go run ./cmd/migrate status       -> mostra versão atual e migrações aplicadas/pendentes
+//
+// Use -db para apontar outro arquivo (padrão data.db).
+func main() {
+	dbPath := flag.String("db", database.DefaultPath, "caminho do arquivo SQLite")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "uso: migrate [-db data.db] up | down [n] | status")
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := database.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Erro ao abrir banco: %v", err)
+	}
+	defer db.Close()
+
+	switch flag.Arg(0) {
+	case "up":
+		n, err := database.MigrateUp(db)
+		if err != nil {
+			log.Fatalf("Erro ao migrar: %v", err)
+		}
+		fmt.Printf("%d migração(ões) aplicada(s)\n", n)
+
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps <= 0 {
+				log.Fatalf("número de passos inválido: %s", flag.Arg(1))
+			}
+		}
+		n, err := database.MigrateDown(db, steps)
+		if err != nil {
+			log.Fatalf("Erro ao desfazer migração: %v", err)
+		}
+		fmt.Printf("%d migração(ões) desfeita(s)\n", n)
+
+	case "status":
+		current, err := database.CurrentVersion(db)
+		if err != nil {
+			log.Fatalf("Erro ao ler versão: %v", err)
+		}
+		latest, err := database.LatestVersion()
+		if err != nil {
+			log.Fatalf("Erro ao ler migrações: %v", err)
+		}
+		status, err := database.Status(db)
+		if err != nil {
+			log.Fatalf("Erro ao ler status: %v", err)
+		}
+
+		fmt.Printf("versão do banco: %d | versão do binário: %d\n", current, latest)
+		for _, st := range status {
+			situacao := "pendente"
+			if st.Applied {
+				situacao = "aplicada em " + st.AppliedAt
+			}
+			fmt.Printf("  %04d_%-30s %s\n", st.Version, st.Name, situacao)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
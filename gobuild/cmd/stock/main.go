@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/database"
+	"github.com/EtraudBits/golangProject/gobuild/internal/product"
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// Comandos administrativos de estoque:
+//
+//	go run ./cmd/stock reconcile        -> compara o estoque gravado com o histórico de movimentos
+//	go run ./cmd/stock reconcile -fix   -> idem, postando os ajustes corretivos
+//
+// Use -db para apontar outro arquivo (padrão data.db).
+func main() {
+	dbPath := flag.String("db", database.DefaultPath, "caminho do arquivo SQLite")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "uso: stock [-db data.db] reconcile [-fix]")
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch flag.Arg(0) {
+	case "reconcile":
+		// os.Exit só aqui: dentro de reconcile os defers (fechar o banco) rodam antes
+		os.Exit(reconcile(*dbPath, flag.Args()[1:]))
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// reconcile roda o comando reconcile e devolve o código de saída
+func reconcile(dbPath string, args []string) int {
+	cmd := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fix := cmd.Bool("fix", false, "posta os ajustes corretivos")
+	_ = cmd.Parse(args)
+
+	// ConnectPath também aplica migrações pendentes (precisa das colunas de delta)
+	if err := database.ConnectPath(dbPath); err != nil {
+		log.Printf("Erro ao conectar ao banco de dados: %v", err)
+		return 1
+	}
+	defer database.DB.Close()
+
+	productRepo := product.NewRepository(database.DB)
+	svc := stock.NewService(database.DB, stock.NewRepository(database.DB), productRepo.GetStockTx, productRepo.UpdateStockTx)
+
+	r, err := svc.Reconcile(context.Background(), *fix)
+	if err != nil {
+		log.Printf("Erro ao reconciliar: %v", err)
+		return 1
+	}
+	printReconciliation(r)
+
+	// sem -fix, divergência encontrada vira código de saída 1 (útil em scripts/cron)
+	if !*fix && r.Mismatches > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printReconciliation mostra o resultado em texto, um produto divergente por bloco
+func printReconciliation(r *stock.Reconciliation) {
+	fmt.Printf("%d produto(s) conferido(s), %d com divergência\n", r.Products, r.Mismatches)
+	for _, d := range r.Drifts {
+		fmt.Printf("  #%d %s: estoque %v | soma dos depósitos %v | ledger %v\n",
+			d.ProductID, d.Product, d.Stock, d.Balances, d.Ledger)
+		for _, w := range d.Warehouses {
+			line := fmt.Sprintf("    depósito %d: saldo %v | ledger %v | diferença %v",
+				w.WarehouseID, w.Balance, w.Ledger, w.Difference)
+			if w.MovementID != nil {
+				line += fmt.Sprintf(" -> ajuste #%d", *w.MovementID)
+			}
+			fmt.Println(line)
+		}
+	}
+	if r.Fixed && r.Mismatches > 0 {
+		fmt.Println("ajustes corretivos postados")
+	}
+}
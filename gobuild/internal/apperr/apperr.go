@@ -0,0 +1,56 @@
+// Package apperr classifica os erros das regras de negócio para a resposta HTTP: o serviço marca
+// o erro como não encontrado, conflito ou inválido e o handler responde com Status(err). O que não
+// foi marcado (falha de banco, por exemplo) responde 500.
+package apperr
+
+import (
+	"errors"   // sentinelas comparadas com errors.Is
+	"fmt"      // mensagem do erro
+	"net/http" // status de cada categoria
+)
+
+// Categorias de erro (compare com errors.Is)
+var (
+	ErrNotFound = errors.New("não encontrado")      // 404
+	ErrConflict = errors.New("conflito")            // 409: o estado atual não permite a operação
+	ErrInvalid  = errors.New("requisição inválida") // 400: dados recusados pela validação
+)
+
+// kindError é um erro com a categoria: a mensagem é só a do erro, e errors.Is encontra tanto a
+// categoria quanto o que a mensagem embrulhou com %w
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// NotFound cria um erro de registro não encontrado (formato como fmt.Errorf)
+func NotFound(format string, args ...any) error {
+	return &kindError{kind: ErrNotFound, err: fmt.Errorf(format, args...)}
+}
+
+// Conflict cria um erro de conflito com o estado atual (formato como fmt.Errorf)
+func Conflict(format string, args ...any) error {
+	return &kindError{kind: ErrConflict, err: fmt.Errorf(format, args...)}
+}
+
+// Invalid cria um erro de validação (formato como fmt.Errorf)
+func Invalid(format string, args ...any) error {
+	return &kindError{kind: ErrInvalid, err: fmt.Errorf(format, args...)}
+}
+
+// Status devolve o status HTTP da categoria do erro; erro sem categoria é 500
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalid):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,43 @@
+package apperr_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr"
+)
+
+// TestStatus confere o status de cada categoria, com o erro embrulhado por fmt.Errorf e a
+// mensagem preservada
+func TestStatus(t *testing.T) {
+	errFechado := apperr.Conflict("pedido fechado")
+	causa := errors.New("falha do banco")
+	tests := []struct {
+		name string
+		err  error
+		want int
+		msg  string
+	}{
+		{"não encontrado", apperr.NotFound("cliente com ID %d não encontrado", 7), http.StatusNotFound, "cliente com ID 7 não encontrado"},
+		{"conflito embrulhado", fmt.Errorf("%w (status %s)", errFechado, "RECEBIDO"), http.StatusConflict, "pedido fechado (status RECEBIDO)"},
+		{"inválido", apperr.Invalid("o nome é obrigatório"), http.StatusBadRequest, "o nome é obrigatório"},
+		{"inválido com causa", apperr.Invalid("linha %d: %w", 2, causa), http.StatusBadRequest, "linha 2: falha do banco"},
+		{"sem categoria", fmt.Errorf("erro ao ler cliente: %v", causa), http.StatusInternalServerError, "erro ao ler cliente: falha do banco"},
+	}
+	for _, tt := range tests {
+		if got := apperr.Status(tt.err); got != tt.want {
+			t.Errorf("%s: status %d, esperado %d", tt.name, got, tt.want)
+		}
+		if tt.err.Error() != tt.msg {
+			t.Errorf("%s: mensagem %q, esperado %q", tt.name, tt.err.Error(), tt.msg)
+		}
+	}
+	if !errors.Is(fmt.Errorf("%w (status %s)", errFechado, "RECEBIDO"), errFechado) {
+		t.Error("errors.Is deveria achar a sentinela do pacote")
+	}
+	if !errors.Is(apperr.Invalid("linha %d: %w", 2, causa), causa) {
+		t.Error("errors.Is deveria achar o erro embrulhado")
+	}
+}
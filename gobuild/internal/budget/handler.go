@@ -1,6 +1,7 @@
 package budget
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -21,28 +22,87 @@ func NewHandler(svc *Service) *Handler {
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.POST("", h.Create)
 	g.GET("", h.List)
+	g.GET("/expiring", h.Expiring)
 	g.GET("/:id", h.GetByID)
 	g.PUT("/:id/cancel", h.Cancel)
+	g.PUT("/:id/status", h.Transition)
+	g.PUT("/:id/discount-approval", h.ApproveDiscount)
 	g.PUT("/:id", h.Update)
 	g.DELETE("/:id", h.Delete)
 }
 
+// RegisterAdminRoutes registra as rotas administrativas de orçamento (ex.: grupo /api/admin)
+func (h *Handler) RegisterAdminRoutes(g *echo.Group) {
+	// limite de desconto sem aprovação do gerente
+	g.GET("/budget-settings", h.GetSettings)
+	g.PUT("/budget-settings", h.SetSettings)
+	// expira agora os orçamentos vencidos (o servidor também faz isso de hora em hora)
+	g.POST("/budgets/expire", h.ExpireOverdue)
+}
+
 // CreateItemRequest representa um item enviado pelo cliente
+// desconto opcional em % (discount_percent) ou em R$ (discount), não os dois
 type CreateItemRequest struct {
-	ProductID int     `json:"product_ID"`
-	Quantity  float64 `json:"quantity"`
+	ProductID       int     `json:"product_ID"`
+	Quantity        float64 `json:"quantity"`
+	DiscountPercent float64 `json:"discount_percent"`
+	Discount        float64 `json:"discount"`
+}
+
+// ChargesRequest são os valores do orçamento além dos itens (todos opcionais):
+// desconto geral em % ou em R$ (não os dois), frete e outras despesas
+// ex.: {"discount_percent": 5, "freight": 80, "other_charges": 25}
+type ChargesRequest struct {
+	DiscountPercent float64 `json:"discount_percent"`
+	Discount        float64 `json:"discount"`
+	Freight         float64 `json:"freight"`
+	OtherCharges    float64 `json:"other_charges"`
+}
+
+// CreateRentalRequest representa uma linha de locação enviada pelo cliente
+// ex.: {"asset_id": 1, "quantity": 2, "period": "SEMANAL", "periods": 2}
+// period é opcional (padrão: DIARIA) e periods também (padrão: 1)
+type CreateRentalRequest struct {
+	AssetID  int    `json:"asset_id"`
+	Quantity int    `json:"quantity"`
+	Period   string `json:"period"`
+	Periods  int    `json:"periods"`
 }
 
 // CreateBudgetRequest representa os dados para criar um orçamento
+// customer_id é obrigatório (cliente cadastrado em /api/customers)
+// warehouse_id é opcional (sem ele, usa o depósito padrão)
+// valid_until (AAAA-MM-DD) é opcional (sem ele, hoje + validity_days da configuração)
 type CreateBudgetRequest struct {
-	Customer string              `json:"customer"`
-	Items    []CreateItemRequest `json:"items"`
+	CustomerID  int                   `json:"customer_id"`
+	WarehouseID int                   `json:"warehouse_id"`
+	ValidUntil  string                `json:"valid_until"`
+	Items       []CreateItemRequest   `json:"items"`
+	Rentals     []CreateRentalRequest `json:"rentals"`
+	ChargesRequest
+}
+
+// TransitionRequest representa a mudança de status pedida pelo cliente
+// ex.: {"status": "APROVADO"}
+type TransitionRequest struct {
+	Status string `json:"status"`
 }
 
 // UpdateBudgetRequest representa os dados para atualizar um orçamento
+// valid_until é opcional (sem ele, mantém a validade atual)
 type UpdateBudgetRequest struct {
-	Customer string              `json:"customer"`
-	Items    []CreateItemRequest `json:"items"`
+	CustomerID  int                   `json:"customer_id"`
+	WarehouseID int                   `json:"warehouse_id"`
+	ValidUntil  string                `json:"valid_until"`
+	Items       []CreateItemRequest   `json:"items"`
+	Rentals     []CreateRentalRequest `json:"rentals"`
+	ChargesRequest
+}
+
+// DiscountApprovalRequest identifica o gerente que aprova o desconto
+// ex.: {"approved_by": "Maria (gerente)"}
+type DiscountApprovalRequest struct {
+	ApprovedBy string `json:"approved_by"`
 }
 
 func (h *Handler) Create(c echo.Context) error {
@@ -54,11 +114,7 @@ func (h *Handler) Create(c echo.Context) error {
 			"error": "JSON invalido",
 		})
 	}
-	budget, err := h.svc.Create(
-		c.Request().Context(),
-		req.Customer,
-		req.Items,
-	)
+	budget, err := h.svc.Create(c.Request().Context(), req)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": err.Error(),
@@ -117,11 +173,16 @@ func (h *Handler) Cancel(c echo.Context) error {
 	}
 	err = h.svc.Cancel(c.Request().Context(), id)
 	if err != nil {
-		if err.Error() == "orçamento não encontrado ou sem itens" {
+		if err.Error() == "orçamento não encontrado" {
 			return c.JSON(http.StatusNotFound, map[string]string{
 				"error": err.Error(),
 			})
 		}
+		if errors.Is(err, ErrTransicaoInvalida) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
@@ -131,6 +192,53 @@ func (h *Handler) Cancel(c echo.Context) error {
 	})
 }
 
+// Transition muda o status do orçamento (RASCUNHO, ENVIADO, APROVADO, EXPIRADO, CANCELADO; CONVERTIDO só pela venda)
+func (h *Handler) Transition(c echo.Context) error {
+	// 1 -> ler ID da URL
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "id inválido",
+		})
+	}
+
+	// 2 -> ler o status pedido
+	var req TransitionRequest
+	if err := c.Bind(&req); err != nil || req.Status == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "JSON inválido: informe o status",
+		})
+	}
+
+	// 3 -> chamar o service
+	budget, err := h.svc.Transition(c.Request().Context(), id, req.Status)
+	if err != nil {
+		if err.Error() == "orçamento não encontrado" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, ErrTransicaoInvalida) || errors.Is(err, ErrDescontoPendente) || errors.Is(err, ErrOrcamentoVencido) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		var shortage StockShortage
+		if errors.As(err, &shortage) { // aprovação ou conversão sem estoque (política BLOQUEAR)
+			return c.JSON(http.StatusConflict, map[string]interface{}{
+				"error":     err.Error(),
+				"available": shortage.AvailableQuantity(),
+			})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// 4 -> retornar o orçamento com o novo status
+	return c.JSON(http.StatusOK, budget)
+}
+
 // Update atualiza um orçamento existente
 func (h *Handler) Update(c echo.Context) error {
 
@@ -151,18 +259,25 @@ func (h *Handler) Update(c echo.Context) error {
 	}
 
 	// 3 -> chamar o service
-	budget, err := h.svc.Update(
-		c.Request().Context(),
-		id,
-		req.Customer,
-		req.Items,
-	)
-	if err != nil {
-		if err.Error() == "orçamento não encontrado" || err.Error() == "produto não encontrado" {
+	budget, err := h.svc.Update(c.Request().Context(), id, req)
+	if err != nil {
+		if err.Error() == "orçamento não encontrado" || err.Error() == "produto não encontrado" || err.Error() == "cliente não encontrado" {
 			return c.JSON(http.StatusNotFound, map[string]string{
 				"error": err.Error(),
 			})
 		}
+		if errors.Is(err, ErrNaoEditavel) || errors.Is(err, ErrDescontoPendente) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		var shortage StockShortage
+		if errors.As(err, &shortage) { // aumento de itens já baixados sem estoque
+			return c.JSON(http.StatusConflict, map[string]interface{}{
+				"error":     err.Error(),
+				"available": shortage.AvailableQuantity(),
+			})
+		}
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": err.Error(),
 		})
@@ -192,6 +307,11 @@ func (h *Handler) Delete(c echo.Context) error {
 				"error": err.Error(),
 			})
 		}
+		if errors.Is(err, ErrNaoEditavel) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
@@ -200,3 +320,107 @@ func (h *Handler) Delete(c echo.Context) error {
 	// 3 -> retornar 204 No Content (padrão REST para DELETE bem-sucedido)
 	return c.NoContent(http.StatusNoContent)
 }
+
+// ApproveDiscount registra a aprovação do gerente para o desconto acima do limite
+func (h *Handler) ApproveDiscount(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "id inválido",
+		})
+	}
+	var req DiscountApprovalRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "JSON inválido",
+		})
+	}
+	budget, err := h.svc.ApproveDiscount(c.Request().Context(), id, req.ApprovedBy)
+	if err != nil {
+		if err.Error() == "orçamento não encontrado" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, ErrDescontoNaoPendente) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, budget)
+}
+
+// GetSettings retorna a configuração dos orçamentos
+func (h *Handler) GetSettings(c echo.Context) error {
+	st, err := h.svc.GetSettings(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+// SetSettings grava a configuração dos orçamentos. Ex.: {"max_discount_percent": 5, "validity_days": 7}
+// Os campos que não vierem mantêm o valor atual.
+func (h *Handler) SetSettings(c echo.Context) error {
+	req, err := h.svc.GetSettings(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "JSON inválido",
+		})
+	}
+	st, err := h.svc.SetSettings(c.Request().Context(), *req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+// Expiring lista os orçamentos abertos que vencem nos próximos dias (?days=3; padrão 3)
+func (h *Handler) Expiring(c echo.Context) error {
+	days := 3
+	if v := c.QueryParam("days"); v != "" {
+		var err error
+		if days, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "days inválido",
+			})
+		}
+	}
+	budgets, err := h.svc.Expiring(c.Request().Context(), days)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, budgets)
+}
+
+// ExpireOverdue expira agora os orçamentos vencidos e retorna os IDs expirados
+func (h *Handler) ExpireOverdue(c echo.Context) error {
+	expired, err := h.svc.ExpireOverdue(c.Request().Context())
+	if expired == nil {
+		expired = []int64{}
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":   err.Error(),
+			"expired": expired,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"expired": expired,
+	})
+}
@@ -0,0 +1,192 @@
+package budget_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/budget"
+	"github.com/EtraudBits/golangProject/gobuild/internal/customer"
+	"github.com/EtraudBits/golangProject/gobuild/internal/database"
+	"github.com/EtraudBits/golangProject/gobuild/internal/pricing"
+	"github.com/EtraudBits/golangProject/gobuild/internal/product"
+	"github.com/EtraudBits/golangProject/gobuild/internal/rental"
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+	"github.com/labstack/echo/v4"
+)
+
+// orcamentoFixture é o que os testes usam: a API de orçamentos, um cliente e um produto com estoque
+type orcamentoFixture struct {
+	e          *echo.Echo
+	stock      *stock.Service
+	customerID int64
+	productID  int64
+}
+
+// orcamentoSetup abre um banco novo, registra as rotas de orçamento e cadastra um cliente e
+// um produto com o estoque informado
+func orcamentoSetup(t *testing.T, estoque float64) *orcamentoFixture {
+	t.Helper()
+	if err := database.ConnectPath(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("erro ao conectar: %v", err)
+	}
+	t.Cleanup(func() { _ = database.DB.Close() })
+	ctx := context.Background()
+
+	productRepo := product.NewRepository(database.DB)
+	stockSvc := stock.NewService(database.DB, stock.NewRepository(database.DB), productRepo.GetStockTx, productRepo.UpdateStockTx)
+	productSvc := product.NewService(productRepo, stockSvc)
+	customerSvc := customer.NewService(customer.NewRepository(database.DB))
+	svc := budget.NewService(budget.NewRepository(database.DB),
+		pricing.NewService(pricing.NewRepository(database.DB), productSvc), stockSvc,
+		rental.NewService(rental.NewRepository(database.DB)), customerSvc)
+	e := echo.New()
+	budget.NewHandler(svc).RegisterRoutes(e.Group("/api/budgets"))
+
+	f := &orcamentoFixture{e: e, stock: stockSvc}
+	var err error
+	if f.customerID, err = customerSvc.Create(ctx, &customer.Customer{Type: "PF", Name: "João da Obra"}); err != nil {
+		t.Fatalf("erro ao inserir cliente: %v", err)
+	}
+	if f.productID, err = productSvc.Create(ctx, &product.Produto{
+		Name: "Cimento CP-II 50kg", Preco: 25.5, Estoque: estoque, Unidade: "saco", Categoria: "Materiais",
+	}); err != nil {
+		t.Fatalf("erro ao inserir produto: %v", err)
+	}
+	return f
+}
+
+// requisitar faz a chamada na API e devolve o status e o corpo
+func (f *orcamentoFixture) requisitar(method, path, body string) (int, string) {
+	req := httptest.NewRequest(method, "/api/budgets"+path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	f.e.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+// itens monta o corpo do POST/PUT com uma linha do produto da fixture
+func (f *orcamentoFixture) itens(quantidade float64) string {
+	return fmt.Sprintf(`{"customer_id":%d,"items":[{"product_ID":%d,"quantity":%v}]}`, f.customerID, f.productID, quantidade)
+}
+
+// criar cria um orçamento com a quantidade informada e o leva ao status pedido
+func (f *orcamentoFixture) criar(t *testing.T, quantidade float64, status string) int64 {
+	t.Helper()
+	code, body := f.requisitar(http.MethodPost, "", f.itens(quantidade))
+	if code != http.StatusCreated {
+		t.Fatalf("criar orçamento: status %d: %s", code, body)
+	}
+	var b budget.Budget
+	if err := json.Unmarshal([]byte(body), &b); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if status != budget.StatusRascunho {
+		f.status(t, b.ID, status, http.StatusOK)
+	}
+	return b.ID
+}
+
+// status pede a mudança de status e confere o código da resposta
+func (f *orcamentoFixture) status(t *testing.T, id int64, status string, want int) {
+	t.Helper()
+	if code, body := f.requisitar(http.MethodPut, fmt.Sprintf("/%d/status", id), `{"status":"`+status+`"}`); code != want {
+		t.Fatalf("%s: status %d, esperado %d: %s", status, code, want, body)
+	}
+}
+
+// conferir compara o estoque físico do produto e o total reservado pelo orçamento
+func (f *orcamentoFixture) conferir(t *testing.T, budgetID int64, estoque, reservado float64) {
+	t.Helper()
+	var gotEstoque, gotReservado float64
+	if err := database.DB.QueryRow(`SELECT stock FROM products WHERE id = ?`, f.productID).Scan(&gotEstoque); err != nil {
+		t.Fatalf("erro ao ler estoque: %v", err)
+	}
+	if err := database.DB.QueryRow(
+		`SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations WHERE budget_id = ? AND status = 'ATIVA'`,
+		budgetID).Scan(&gotReservado); err != nil {
+		t.Fatalf("erro ao ler reservas: %v", err)
+	}
+	if gotEstoque != estoque || gotReservado != reservado {
+		t.Errorf("estoque/reservado = %v/%v, esperado %v/%v", gotEstoque, gotReservado, estoque, reservado)
+	}
+}
+
+// TestTransitionConvertidoRecusado: a conversão só acontece pela venda; pedir CONVERTIDO no
+// endpoint de status responde 409 e o orçamento continua aprovado com a reserva
+func TestTransitionConvertidoRecusado(t *testing.T) {
+	f := orcamentoSetup(t, 20)
+	id := f.criar(t, 5, budget.StatusAprovado)
+
+	f.status(t, id, budget.StatusConvertido, http.StatusConflict)
+	f.conferir(t, id, 20, 5)
+
+	// de um estado final também não sai
+	f.status(t, id, budget.StatusCancelado, http.StatusOK)
+	f.status(t, id, budget.StatusAprovado, http.StatusConflict)
+	f.conferir(t, id, 20, 0)
+}
+
+// TestUpdateAprovadoAjustaReserva: alterar a quantidade de um orçamento aprovado acerta a
+// reserva para a nova quantidade (o físico não muda); aumento acima do disponível é
+// recusado e a reserva fica como estava
+func TestUpdateAprovadoAjustaReserva(t *testing.T) {
+	f := orcamentoSetup(t, 20)
+	id := f.criar(t, 5, budget.StatusAprovado)
+	f.conferir(t, id, 20, 5)
+
+	path := fmt.Sprintf("/%d", id)
+	if code, body := f.requisitar(http.MethodPut, path, f.itens(8)); code != http.StatusOK {
+		t.Fatalf("aumentar: status %d: %s", code, body)
+	}
+	f.conferir(t, id, 20, 8)
+
+	if code, body := f.requisitar(http.MethodPut, path, f.itens(3)); code != http.StatusOK {
+		t.Fatalf("reduzir: status %d: %s", code, body)
+	}
+	f.conferir(t, id, 20, 3)
+
+	// outro orçamento segura 10: sobram 7 além dos 3 deste
+	f.criar(t, 10, budget.StatusAprovado)
+	if code, body := f.requisitar(http.MethodPut, path, f.itens(11)); code != http.StatusConflict {
+		t.Fatalf("acima do disponível: status %d, esperado 409: %s", code, body)
+	}
+	f.conferir(t, id, 20, 3)
+}
+
+// TestDeleteDevolveEstoque: apagar um orçamento aprovado libera a reserva; apagar um orçamento
+// antigo com estoque já baixado devolve a mercadoria ao estoque
+func TestDeleteDevolveEstoque(t *testing.T) {
+	f := orcamentoSetup(t, 20)
+
+	reservado := f.criar(t, 5, budget.StatusAprovado)
+	f.conferir(t, reservado, 20, 5)
+	if code, body := f.requisitar(http.MethodDelete, fmt.Sprintf("/%d", reservado), ""); code != http.StatusNoContent {
+		t.Fatalf("apagar aprovado: status %d: %s", code, body)
+	}
+	f.conferir(t, reservado, 20, 0)
+
+	// orçamento anterior às reservas (migração 0003): APROVADO com o estoque já baixado
+	baixado := f.criar(t, 4, budget.StatusRascunho)
+	if _, err := f.stock.CreateMovement(context.Background(), &stock.Movement{
+		ProductID: int(f.productID), Type: "Saida", Quantity: 4,
+		Reason: stock.MotivoVenda, Document: fmt.Sprintf("orcamento:%d", baixado),
+	}); err != nil {
+		t.Fatalf("erro na baixa: %v", err)
+	}
+	if _, err := database.DB.Exec(`UPDATE budgets SET status = ?, stock_status = ? WHERE id = ?`,
+		budget.StatusAprovado, budget.StockBaixado, baixado); err != nil {
+		t.Fatalf("erro ao marcar orçamento baixado: %v", err)
+	}
+	f.conferir(t, baixado, 16, 0)
+
+	if code, body := f.requisitar(http.MethodDelete, fmt.Sprintf("/%d", baixado), ""); code != http.StatusNoContent {
+		t.Fatalf("apagar baixado: status %d: %s", code, body)
+	}
+	f.conferir(t, baixado, 20, 0)
+}
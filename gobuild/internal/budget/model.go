@@ -1,22 +1,97 @@
 package budget
 
+// Status possíveis de um orçamento (ciclo de vida)
+//
+//	RASCUNHO -> ENVIADO -> APROVADO -> CONVERTIDO (venda)
+//	    \___________\__________\____-> CANCELADO / EXPIRADO
+const (
+	StatusRascunho   = "RASCUNHO"   // em edição, sem efeito no estoque
+	StatusEnviado    = "ENVIADO"    // enviado ao cliente, sem efeito no estoque
+	StatusAprovado   = "APROVADO"   // cliente aprovou -> estoque é reservado
+	StatusConvertido = "CONVERTIDO" // virou venda -> reserva vira Saida (estado final)
+	StatusExpirado   = "EXPIRADO"   // passou da validade -> reserva liberada (estado final)
+	StatusCancelado  = "CANCELADO"  // cancelado -> reserva liberada (estado final)
+)
+
+// Situação do estoque vinculado ao orçamento (coluna budgets.stock_status).
+// Guardamos separado do status para saber o que devolver ao cancelar/expirar.
+const (
+	StockNenhum    = "NENHUM"    // orçamento não mexeu no estoque
+	StockReservado = "RESERVADO" // estoque reservado (stock_reservations), físico intacto
+	StockBaixado   = "BAIXADO"   // estoque físico já foi baixado (Saida)
+)
+
+// DefaultWarehouseID é o depósito usado quando o orçamento não escolhe um
+// (mesmo id do depósito padrão criado pela migração 0005)
+const DefaultWarehouseID = 1
+
+// Situação do desconto do orçamento (coluna budgets.discount_status).
+// Desconto acima do limite (budget_settings) precisa da aprovação do gerente antes de o
+// orçamento ser enviado, aprovado ou convertido.
+const (
+	DescontoNenhum   = "NENHUM"   // sem desconto ou dentro do limite
+	DescontoPendente = "PENDENTE" // acima do limite, aguardando o gerente
+	DescontoAprovado = "APROVADO" // acima do limite, aprovado pelo gerente
+)
+
 // budget representa um orçamento (cabeçalho)
 // Nota principal do orçamento
+//
+// Composição do total: Gross - ItemsDiscount - Discount + Freight + OtherCharges = Total
 type Budget struct {
-	ID        int64        `json:"id"`         //ID do Orçamento
-	Customer  string       `json:"customer"`   // Nome do cliente
-	Total     float64      `json:"total"`      // Valor total do orçamento -> será calculado no service, não no handler
-	Status    string       `json:"status"`     // status do orçamento
-	CreatedAt string       `json:"created_at"` // Data de criação
-	Items     []BudgetItem `json:"items"`      // itens do orçamento
+	ID                 int64          `json:"id"`                             //ID do Orçamento
+	Customer           string         `json:"customer"`                       // Nome do cliente (como era na criação/alteração)
+	CustomerID         *int           `json:"customer_id"`                    // cliente do cadastro (nulo em orçamentos antigos sem nome)
+	Gross              float64        `json:"gross"`                          // bruto: itens (quantidade x preço) + locações
+	ItemsDiscount      float64        `json:"items_discount"`                 // soma dos descontos dos itens
+	DiscountPercent    float64        `json:"discount_percent"`               // desconto geral em % (sobre o bruto menos os descontos dos itens)
+	Discount           float64        `json:"discount"`                       // desconto geral em R$
+	Freight            float64        `json:"freight"`                        // frete
+	OtherCharges       float64        `json:"other_charges"`                  // outras despesas (montagem, taxa de entrega...)
+	Total              float64        `json:"total"`                          // Valor líquido do orçamento -> será calculado no service, não no handler
+	DiscountStatus     string         `json:"discount_status"`                // NENHUM, PENDENTE ou APROVADO (ver Desconto*)
+	DiscountApprovedBy string         `json:"discount_approved_by,omitempty"` // gerente que aprovou o desconto
+	DiscountApprovedAt string         `json:"discount_approved_at,omitempty"`
+	Status             string         `json:"status"`                // status do orçamento (RASCUNHO, ENVIADO, ...)
+	StockStatus        string         `json:"stock_status"`          // efeito no estoque (NENHUM, RESERVADO, BAIXADO)
+	WarehouseID        int            `json:"warehouse_id"`          // depósito de onde sai o material
+	ValidUntil         string         `json:"valid_until,omitempty"` // validade (AAAA-MM-DD); vencido, o orçamento expira
+	CreatedAt          string         `json:"created_at"`            // Data de criação
+	Items              []BudgetItem   `json:"items"`                 // itens do orçamento
+	Rentals            []BudgetRental `json:"rentals,omitempty"`     // equipamentos para locação (não mexem no estoque)
 }
 
 type BudgetItem struct {
-	ID        int64   `json:"id"`         // ID do item
-	BudgetID  int64   `json:"budget_id"`  // ID do orçamento (FK)
-	ProductID int     `json:"product_id"` // ID do produto
-	Product   string  `json:"product"`    // nome do produto (para exibição)
-	Quantity  float64 `json:"quantity"`   //Quantidade
-	UnitPrice float64 `json:"unit_price"` // Preço Unitário
-	Subtotal  float64 `json:"subtotal"`   // Quantity * unitprice -> também será calculado no service
+	ID              int64   `json:"id"`                   // ID do item
+	BudgetID        int64   `json:"budget_id"`            // ID do orçamento (FK)
+	ProductID       int     `json:"product_id"`           // ID do produto
+	Product         string  `json:"product"`              // nome do produto (para exibição)
+	Quantity        float64 `json:"quantity"`             //Quantidade
+	UnitPrice       float64 `json:"unit_price"`           // Preço Unitário
+	PriceList       string  `json:"price_list,omitempty"` // tabela/faixa que deu o preço (vazio = preço de cadastro)
+	DiscountPercent float64 `json:"discount_percent"`     // desconto do item em % (sobre quantidade x preço)
+	Discount        float64 `json:"discount"`             // desconto do item em R$
+	Subtotal        float64 `json:"subtotal"`             // Quantity * unitprice - Discount -> também será calculado no service
+}
+
+// BudgetRental é uma linha de locação do orçamento (betoneira, andaime...): cotação de
+// quantidade x períodos x preço do período. O contrato de locação é aberto a partir do
+// orçamento aprovado (POST /api/rentals/contracts com budget_id).
+type BudgetRental struct {
+	ID       int64   `json:"id"`
+	BudgetID int64   `json:"budget_id"`
+	AssetID  int     `json:"asset_id"` // equipamento (rental_assets)
+	Asset    string  `json:"asset"`    // nome do equipamento (para exibição)
+	Quantity int     `json:"quantity"` // unidades
+	Period   string  `json:"period"`   // DIARIA, SEMANAL ou MENSAL
+	Periods  int     `json:"periods"`  // quantos períodos (ex.: 2 semanas)
+	Rate     float64 `json:"rate"`     // preço do período por unidade
+	Subtotal float64 `json:"subtotal"` // Quantity * Periods * Rate
+}
+
+// Settings é a configuração dos orçamentos (GET/PUT /api/admin/budget-settings)
+type Settings struct {
+	MaxDiscountPercent float64 `json:"max_discount_percent"` // desconto máximo (%) sem aprovação do gerente
+	ValidityDays       int     `json:"validity_days"`        // validade padrão do orçamento (dias a partir da criação)
+	UpdatedAt          string  `json:"updated_at"`
 }
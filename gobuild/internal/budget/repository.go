@@ -33,10 +33,23 @@ func (r *Repository) CreateBudget(
 	}
 	// Inserindo o orçamento
 	result, err := tx.ExecContext(ctx,
-		`INSERT INTO budgets (customer, total)
-	VALUES (?, ?)`,
+		`INSERT INTO budgets (customer, customer_id, gross, items_discount, discount_percent, discount,
+		freight, other_charges, total, discount_status, status, stock_status, warehouse_id, valid_until)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))`,
 		budget.Customer,
+		budget.CustomerID,
+		budget.Gross,
+		budget.ItemsDiscount,
+		budget.DiscountPercent,
+		budget.Discount,
+		budget.Freight,
+		budget.OtherCharges,
 		budget.Total,
+		budget.DiscountStatus,
+		budget.Status,
+		budget.StockStatus,
+		budget.WarehouseID,
+		budget.ValidUntil,
 	)
 	if err != nil {
 		tx.Rollback()
@@ -53,13 +66,16 @@ func (r *Repository) CreateBudget(
 	// Inserindo os Itens
 	for _, item := range items {
 		_, err := tx.ExecContext(ctx,
-			`INSERT INTO budget_items (budget_id, product_id, product, quantity, unit_price, subtotal)
-	VALUES(?, ?, ?, ?, ?, ?)`,
+			`INSERT INTO budget_items (budget_id, product_id, product, quantity, unit_price, price_list, discount_percent, discount, subtotal)
+	VALUES(?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?)`,
 			budgetID,
 			item.ProductID,
 			item.Product,
 			item.Quantity,
 			item.UnitPrice,
+			item.PriceList,
+			item.DiscountPercent,
+			item.Discount,
 			item.Subtotal,
 		)
 		if err != nil {
@@ -68,6 +84,12 @@ func (r *Repository) CreateBudget(
 		}
 	}
 
+	// Inserindo as linhas de locação
+	if err := insertRentalsTx(ctx, tx, budgetID, budget.Rentals); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
 	// commit final -> aqui o banco confirma tudo
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("erro ao commitar transação %w", err)
@@ -83,7 +105,7 @@ func (r *Repository) ListBudgets(ctx context.Context) ([]Budget, error) {
 	// 1-> Busca todos os orçamentos (cabeçalho)
 
 	rows, err := r.DB.QueryContext(ctx,
-		`SELECT id, customer, total, created_at
+		`SELECT `+budgetColumns+`
 	FROM budgets
 	ORDER BY created_at DESC`,
 	)
@@ -96,15 +118,9 @@ func (r *Repository) ListBudgets(ctx context.Context) ([]Budget, error) {
 
 	//2-> Itera sobre os orçamentos
 	for rows.Next() {
-		var b Budget
-
 		//Mapeia colunas -> struct
-		if err := rows.Scan(
-			&b.ID,
-			&b.Customer,
-			&b.Total,
-			&b.CreatedAt,
-		); err != nil {
+		b, err := scanBudget(rows.Scan)
+		if err != nil {
 			return nil, fmt.Errorf("erro ao ler orçamento: %w", err)
 		}
 		budgets = append(budgets, b)
@@ -123,7 +139,7 @@ func (r *Repository) ListItemsByBudget(
 ) ([]BudgetItem, error) {
 
 	rows, err := r.DB.QueryContext(ctx,
-		`SELECT id, budget_id, product_id, product, quantity, unit_price, subtotal
+		`SELECT id, budget_id, product_id, product, quantity, unit_price, COALESCE(price_list, ''), discount_percent, discount, subtotal
 		 FROM budget_items
 		 WHERE budget_id = ?
 		 ORDER BY id`,
@@ -146,6 +162,9 @@ func (r *Repository) ListItemsByBudget(
 			&it.Product,
 			&it.Quantity,
 			&it.UnitPrice,
+			&it.PriceList,
+			&it.DiscountPercent,
+			&it.Discount,
 			&it.Subtotal,
 		); err != nil {
 			return nil, fmt.Errorf("erro ao escanear item do orçamento: %w", err)
@@ -161,19 +180,50 @@ func (r *Repository) ListItemsByBudget(
 	return items, nil
 }
 
+// budgetColumns são as colunas do cabeçalho lidas por scanBudget (mesma ordem)
+const budgetColumns = `id, customer, customer_id, gross, items_discount, discount_percent, discount,
+	freight, other_charges, total, discount_status, COALESCE(discount_approved_by, ''),
+	COALESCE(discount_approved_at, ''), status, stock_status, warehouse_id, COALESCE(valid_until, ''), created_at`
+
+// scanBudget lê o cabeçalho do orçamento de uma linha (Row ou Rows)
+func scanBudget(scan func(dest ...any) error) (Budget, error) {
+	var b Budget
+	err := scan(&b.ID, &b.Customer, &b.CustomerID, &b.Gross, &b.ItemsDiscount, &b.DiscountPercent, &b.Discount,
+		&b.Freight, &b.OtherCharges, &b.Total, &b.DiscountStatus, &b.DiscountApprovedBy,
+		&b.DiscountApprovedAt, &b.Status, &b.StockStatus, &b.WarehouseID, &b.ValidUntil, &b.CreatedAt)
+	return b, err
+}
+
+// queryer é o que *sql.DB e *sql.Tx têm em comum para leitura
+// (permite usar a mesma consulta dentro ou fora de uma transação)
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
 // GetByID busca um orçamento pelo ID junto com seus itens
 func (r *Repository) GetByID(ctx context.Context, id int64) (*Budget, error) {
+	return getByID(ctx, r.DB, id)
+}
+
+// GetByIDTx busca o orçamento dentro de uma transação (lê o status já com o lock de escrita,
+// evitando que duas transições concorrentes partam do mesmo status)
+func (r *Repository) GetByIDTx(ctx context.Context, tx *sql.Tx, id int64) (*Budget, error) {
+	return getByID(ctx, tx, id)
+}
+
+func getByID(ctx context.Context, q queryer, id int64) (*Budget, error) {
 
 	// 1-> Busca o orçamento (cabeçalho)
-	row := r.DB.QueryRowContext(ctx,
-		`SELECT id, customer, total, created_at
+	row := q.QueryRowContext(ctx,
+		`SELECT `+budgetColumns+`
 		FROM budgets
 		WHERE id = ?`,
 		id,
 	)
 
-	var b Budget
-	if err := row.Scan(&b.ID, &b.Customer, &b.Total, &b.CreatedAt); err != nil {
+	b, err := scanBudget(row.Scan)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Orçamento não encontrado
 		}
@@ -181,10 +231,11 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Budget, error) {
 	}
 
 	// 2-> Busca os itens do orçamento
-	rows, err := r.DB.QueryContext(ctx,
-		`SELECT id, budget_id, product_id, product, quantity, unit_price, subtotal
+	rows, err := q.QueryContext(ctx,
+		`SELECT id, budget_id, product_id, product, quantity, unit_price, COALESCE(price_list, ''), discount_percent, discount, subtotal
 		FROM budget_items
-		WHERE budget_id = ?`,
+		WHERE budget_id = ?
+		ORDER BY id`,
 		b.ID,
 	)
 
@@ -202,121 +253,301 @@ func (r *Repository) GetByID(ctx context.Context, id int64) (*Budget, error) {
 			&item.Product,
 			&item.Quantity,
 			&item.UnitPrice,
+			&item.PriceList,
+			&item.DiscountPercent,
+			&item.Discount,
 			&item.Subtotal,
 		); err != nil {
 			return nil, err
 		}
 		b.Items = append(b.Items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 3-> Busca as linhas de locação
+	rentals, err := q.QueryContext(ctx,
+		`SELECT id, budget_id, asset_id, asset, quantity, period, periods, rate, subtotal
+		FROM budget_rentals
+		WHERE budget_id = ?
+		ORDER BY id`,
+		b.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rentals.Close()
+
+	for rentals.Next() {
+		var r BudgetRental
+		if err := rentals.Scan(
+			&r.ID,
+			&r.BudgetID,
+			&r.AssetID,
+			&r.Asset,
+			&r.Quantity,
+			&r.Period,
+			&r.Periods,
+			&r.Rate,
+			&r.Subtotal,
+		); err != nil {
+			return nil, err
+		}
+		b.Rentals = append(b.Rentals, r)
+	}
+	if err := rentals.Err(); err != nil {
+		return nil, err
+	}
 	return &b, nil
 }
 
-func (r *Repository) Cancel(ctx context.Context, id int64) error {
-	_, err := r.DB.ExecContext(ctx,
-		`UPDATE budgets SET status = 'CANCELADO' WHERE id = ?`,
+// UpdateStatusTx grava o novo status e a situação do estoque do orçamento dentro da transação
+func (r *Repository) UpdateStatusTx(ctx context.Context, tx *sql.Tx, id int64, status, stockStatus string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE budgets SET status = ?, stock_status = ? WHERE id = ?`,
+		status,
+		stockStatus,
 		id,
 	)
-	return err
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status do orçamento: %w", err)
+	}
+	return nil
 }
 
-// UpdateBudget atualiza um orçamento e seus itens dentro de uma transação
-func (r *Repository) UpdateBudget(
+// UpdateBudgetTx atualiza um orçamento e seus itens dentro da transação recebida
+// (o service usa a mesma transação para acertar o estoque)
+func (r *Repository) UpdateBudgetTx(
 	ctx context.Context,
+	tx *sql.Tx,
 	budget *Budget,
 	items []BudgetItem,
 ) error {
 
-	// 1-> Inicia a transação
-	tx, err := r.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-
-	// 2-> Atualiza o cabeçalho do orçamento
-	_, err = tx.ExecContext(ctx,
-		`UPDATE budgets SET customer = ?, total = ? WHERE id = ?`,
+	// 1-> Atualiza o cabeçalho do orçamento
+	_, err := tx.ExecContext(ctx,
+		`UPDATE budgets SET customer = ?, customer_id = ?, gross = ?, items_discount = ?, discount_percent = ?,
+			discount = ?, freight = ?, other_charges = ?, total = ?, discount_status = ?,
+			discount_approved_by = NULLIF(?, ''), discount_approved_at = NULLIF(?, ''), warehouse_id = ?,
+			valid_until = NULLIF(?, '') WHERE id = ?`,
 		budget.Customer,
+		budget.CustomerID,
+		budget.Gross,
+		budget.ItemsDiscount,
+		budget.DiscountPercent,
+		budget.Discount,
+		budget.Freight,
+		budget.OtherCharges,
 		budget.Total,
+		budget.DiscountStatus,
+		budget.DiscountApprovedBy,
+		budget.DiscountApprovedAt,
+		budget.WarehouseID,
+		budget.ValidUntil,
 		budget.ID,
 	)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return fmt.Errorf("erro ao atualizar orçamento: %w", err)
 	}
 
-	// 3-> Remove os itens antigos
+	// 2-> Remove os itens antigos
 	_, err = tx.ExecContext(ctx,
 		`DELETE FROM budget_items WHERE budget_id = ?`,
 		budget.ID,
 	)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return fmt.Errorf("erro ao remover itens do orçamento: %w", err)
 	}
 
-	// 4-> Insere os novos itens
+	// 3-> Insere os novos itens
 	for _, item := range items {
 		_, err := tx.ExecContext(ctx,
-			`INSERT INTO budget_items (budget_id, product_id, product, quantity, unit_price, subtotal)
-			 VALUES (?, ?, ?, ?, ?, ?)`,
+			`INSERT INTO budget_items (budget_id, product_id, product, quantity, unit_price, price_list, discount_percent, discount, subtotal)
+			 VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?)`,
 			budget.ID,
 			item.ProductID,
 			item.Product,
 			item.Quantity,
 			item.UnitPrice,
+			item.PriceList,
+			item.DiscountPercent,
+			item.Discount,
 			item.Subtotal,
 		)
 		if err != nil {
-			tx.Rollback()
-			return err
+			return fmt.Errorf("erro ao inserir item do orçamento: %w", err)
 		}
 	}
 
-	// 5-> Commit da transação
-	return tx.Commit()
+	// 4-> Troca as linhas de locação
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM budget_rentals WHERE budget_id = ?`,
+		budget.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao remover locações do orçamento: %w", err)
+	}
+	return insertRentalsTx(ctx, tx, budget.ID, budget.Rentals)
 }
 
-// DeleteBudget remove um orçamento e seus itens dentro de uma transação
-func (r *Repository) DeleteBudget(ctx context.Context, id int64) error {
-	// 1-> Inicia a transação
-	tx, err := r.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("erro ao iniciar transação: %w", err)
+// insertRentalsTx insere as linhas de locação do orçamento dentro da transação recebida
+func insertRentalsTx(ctx context.Context, tx *sql.Tx, budgetID int64, rentals []BudgetRental) error {
+	for _, r := range rentals {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO budget_rentals (budget_id, asset_id, asset, quantity, period, periods, rate, subtotal)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			budgetID,
+			r.AssetID,
+			r.Asset,
+			r.Quantity,
+			r.Period,
+			r.Periods,
+			r.Rate,
+			r.Subtotal,
+		)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir locação do orçamento: %w", err)
+		}
 	}
+	return nil
+}
 
-	// 2-> Deleta os itens do orçamento primeiro (evita lixo no banco)
-	_, err = tx.ExecContext(ctx,
+// DeleteBudgetTx remove um orçamento e seus itens dentro da transação recebida
+func (r *Repository) DeleteBudgetTx(ctx context.Context, tx *sql.Tx, id int64) error {
+	// 1-> Deleta os itens do orçamento primeiro (evita lixo no banco)
+	_, err := tx.ExecContext(ctx,
 		`DELETE FROM budget_items WHERE budget_id = ?`,
 		id,
 	)
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("erro ao deletar itens do orçamento: %w", err)
 	}
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM budget_rentals WHERE budget_id = ?`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao deletar locações do orçamento: %w", err)
+	}
 
-	// 3-> Deleta o orçamento
+	// 2-> Deleta o orçamento
 	result, err := tx.ExecContext(ctx,
 		`DELETE FROM budgets WHERE id = ?`,
 		id,
 	)
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("erro ao deletar orçamento: %w", err)
 	}
 
-	// 4-> Verifica se o orçamento existia (RowsAffected garante que o ID existe)
+	// 3-> Verifica se o orçamento existia (RowsAffected garante que o ID existe)
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("erro ao verificar linhas afetadas: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		tx.Rollback()
 		return sql.ErrNoRows // Orçamento não encontrado
 	}
 
-	// 5-> Commit da transação
-	return tx.Commit()
+	return nil
+}
+
+// ApproveDiscountTx grava a aprovação do desconto pelo gerente
+func (r *Repository) ApproveDiscountTx(ctx context.Context, tx *sql.Tx, id int64, approvedBy string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE budgets SET discount_status = ?, discount_approved_by = ?, discount_approved_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		DescontoAprovado,
+		approvedBy,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao aprovar desconto do orçamento: %w", err)
+	}
+	return nil
+}
+
+// GetSettings lê a configuração dos orçamentos (linha única da budget_settings)
+func (r *Repository) GetSettings(ctx context.Context) (*Settings, error) {
+	var st Settings
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT max_discount_percent, validity_days, updated_at FROM budget_settings WHERE id = 1`,
+	).Scan(&st.MaxDiscountPercent, &st.ValidityDays, &st.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar configuração dos orçamentos: %w", err)
+	}
+	return &st, nil
+}
+
+// SaveSettings grava a configuração dos orçamentos
+func (r *Repository) SaveSettings(ctx context.Context, st Settings) error {
+	_, err := r.DB.ExecContext(ctx,
+		`INSERT INTO budget_settings (id, max_discount_percent, validity_days) VALUES (1, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET max_discount_percent = excluded.max_discount_percent,
+			validity_days = excluded.validity_days, updated_at = CURRENT_TIMESTAMP`,
+		st.MaxDiscountPercent,
+		st.ValidityDays,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar configuração dos orçamentos: %w", err)
+	}
+	return nil
+}
+
+// ListOverdue retorna os IDs dos orçamentos abertos (RASCUNHO, ENVIADO, APROVADO) com a
+// validade anterior a today (AAAA-MM-DD)
+func (r *Repository) ListOverdue(ctx context.Context, today string) ([]int64, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id FROM budgets
+		WHERE status IN (?, ?, ?) AND valid_until IS NOT NULL AND valid_until < ?
+		ORDER BY valid_until, id`,
+		StatusRascunho, StatusEnviado, StatusAprovado, today,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar orçamentos vencidos: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao ler orçamento vencido: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
 }
 
+// ListExpiring retorna os orçamentos abertos (sem itens) com validade entre from e to
+// (AAAA-MM-DD, inclusive), os que vencem primeiro antes
+func (r *Repository) ListExpiring(ctx context.Context, from, to string) ([]Budget, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+budgetColumns+`
+		FROM budgets
+		WHERE status IN (?, ?, ?) AND valid_until BETWEEN ? AND ?
+		ORDER BY valid_until, id`,
+		StatusRascunho, StatusEnviado, StatusAprovado, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar orçamentos a vencer: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := []Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler orçamento: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
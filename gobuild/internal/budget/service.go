@@ -6,103 +6,409 @@ import (
 
 	// para verificar sql.ErrNoRows
 	"errors" // criar erros claros de negócio
+	"fmt"
+	"math"    // arredondamento dos valores em reais
+	"strings" // limpar o nome do gerente na aprovação
+	"time"    // validade do orçamento e rotina de expiração
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409) lida pela venda
 )
 
 //cria uma interface que não depende diretamente do modulo product
 // ProductReader define o que o budget precisa saber sobre produtos
 
 type ProductReader interface {
-	GetByID(ctx context.Context, id int) (*ProductLite, error) // o budget só quer: ID, Nome e Preço -> então usaremos o ProductLite (struct)
+	// GetPrice retorna o produto com o preço unitário que vale para o cliente e a quantidade
+	// (tabela de preço do cliente e faixas por quantidade); nil se o produto não existir.
+	// o budget só quer: ID, Nome e Preço -> então usaremos o ProductLite (struct)
+	GetPrice(ctx context.Context, productID, customerID int, quantity float64) (*ProductLite, error)
+}
+
+// CustomerReader define o que o budget precisa saber sobre clientes
+// (o budget não depende diretamente do módulo customer)
+type CustomerReader interface {
+	// GetCustomer retorna ID, nome e se o cliente está ativo; nil se não existir
+	GetCustomer(ctx context.Context, id int) (*CustomerLite, error)
+}
+
+// RentalReader define o que o budget precisa saber sobre equipamentos para locação
+// (o budget não depende diretamente do módulo rental)
+type RentalReader interface {
+	// GetRate retorna o equipamento com o preço do período (DIARIA, SEMANAL, MENSAL); nil se não existir
+	GetRate(ctx context.Context, assetID int, period string) (*RentalLite, error)
 }
 
 // StockService define o que o budget precisa saber sobre estoque
+// As operações recebem a transação do budget: status do orçamento e estoque mudam no mesmo commit.
+// reason/document ficam gravados no movimento (o budget sempre informa o próprio ID, ver stockDocument).
 type StockService interface { // interface para checar estoque -> para o budget não depender diretamente do módulo de estoque
-	// Saida reduz o estoque de um produto
-	Saida(ctx context.Context, productID int, quantity float64) error
-	// Entrada aumenta o estoque de um produto
-	Entrada(ctx context.Context, productID int, quantity float64) error
+	// SaidaTx reduz o estoque de um produto no depósito
+	SaidaTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, quantity float64, reason, document string) error
+	// EntradaTx aumenta o estoque de um produto no depósito
+	EntradaTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, quantity float64, reason, document string) error
+	// ReserveTx separa estoque do depósito para o orçamento sem baixar o físico
+	ReserveTx(ctx context.Context, tx *sql.Tx, budgetID int64, warehouseID, productID int, quantity float64) error
+	// ReleaseTx libera as reservas do orçamento
+	ReleaseTx(ctx context.Context, tx *sql.Tx, budgetID int64) error
+	// CommitTx transforma as reservas do orçamento em Saida definitiva
+	CommitTx(ctx context.Context, tx *sql.Tx, budgetID int64) error
+}
+
+// StockShortage é como o budget reconhece o erro de estoque insuficiente devolvido pelo
+// StockService, sem depender do módulo de estoque (o handler responde 409 com o disponível)
+type StockShortage interface {
+	error
+	AvailableQuantity() float64
+}
+
+// Motivos gravados nos movimentos de estoque do orçamento (mesmos códigos do módulo de estoque)
+const (
+	motivoVenda     = "VENDA"     // baixa do orçamento convertido
+	motivoDevolucao = "DEVOLUCAO" // mercadoria baixada que volta ao estoque
+)
+
+// stockDocument é a referência do orçamento gravada nos movimentos de estoque (ex.: "orcamento:7")
+func stockDocument(budgetID int64) string {
+	return fmt.Sprintf("orcamento:%d", budgetID)
+}
+
+// Erros de negócio do ciclo de vida (409 Conflict; a venda responde com apperr.Status)
+var (
+	ErrTransicaoInvalida = apperr.Conflict("transição de status não permitida")
+	ErrNaoEditavel       = apperr.Conflict("orçamento não pode ser alterado neste status")
+	// desconto acima do limite sem aprovação: o orçamento não sai de RASCUNHO
+	ErrDescontoPendente    = apperr.Conflict("desconto acima do limite aguardando aprovação do gerente")
+	ErrDescontoNaoPendente = apperr.Conflict("orçamento não tem desconto aguardando aprovação")
+	// validade vencida: o orçamento só pode expirar ou ser cancelado (ou renovado no PUT)
+	ErrOrcamentoVencido = apperr.Conflict("orçamento fora da validade")
+)
+
+// DefaultExpiryInterval é de quanto em quanto tempo o servidor procura orçamentos vencidos
+const DefaultExpiryInterval = time.Hour
+
+// todayDate é a data de hoje sem hora, em UTC: o mesmo relógio do CURRENT_TIMESTAMP gravado
+// pelo SQLite, para a validade não virar um dia antes ou depois perto da meia-noite
+func todayDate() time.Time {
+	t := time.Now().UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// today é a data de hoje (AAAA-MM-DD), comparada com valid_until
+func today() string {
+	return todayDate().Format(time.DateOnly)
+}
+
+// parseValidUntil confere a validade pedida (AAAA-MM-DD, não pode já ter passado)
+func parseValidUntil(v string) (string, error) {
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return "", fmt.Errorf("valid_until inválida (%s): use AAAA-MM-DD", v)
+	}
+	if v = t.Format(time.DateOnly); v < today() {
+		return "", fmt.Errorf("valid_until (%s) já passou", v)
+	}
+	return v, nil
+}
+
+// transitions define, para cada status, para quais status ele pode ir.
+// CONVERTIDO, EXPIRADO e CANCELADO são finais.
+var transitions = map[string][]string{
+	StatusRascunho: {StatusEnviado, StatusAprovado, StatusExpirado, StatusCancelado},
+	StatusEnviado:  {StatusRascunho, StatusAprovado, StatusExpirado, StatusCancelado},
+	StatusAprovado: {StatusConvertido, StatusExpirado, StatusCancelado},
+}
+
+// canTransition informa se a mudança from -> to é permitida
+func canTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// validStatus informa se o status existe
+func validStatus(status string) bool {
+	switch status {
+	case StatusRascunho, StatusEnviado, StatusAprovado, StatusConvertido, StatusExpirado, StatusCancelado:
+		return true
+	}
+	return false
+}
+
+// editable informa se os itens do orçamento ainda podem ser alterados
+// (em APROVADO o Update acerta a reserva/baixa junto com os itens)
+func editable(status string) bool {
+	return status == StatusRascunho || status == StatusEnviado || status == StatusAprovado
 }
 
 type ProductLite struct {
-	ID int
-	Name string
-	Price float64
+	ID        int
+	Name      string
+	Price     float64
+	PriceList string // tabela/faixa que deu o preço (vazio = preço de cadastro)
+}
+
+// CustomerLite é o que o budget usa do cliente: o nome fica gravado no orçamento (como era na época)
+type CustomerLite struct {
+	ID     int
+	Name   string
+	Active bool
+}
+
+// RentalLite é o que o budget usa do equipamento: ID, nome e preço do período pedido
+type RentalLite struct {
+	ID     int
+	Name   string
+	Period string
+	Rate   float64
 }
 
 //Criação do Service
 
 type Service struct {
-	repo *Repository //fala com o banco (budget_repository)
-	product ProductReader //lê produtos (via interface)
-	stock StockService // checa estoque (via interface)
+	repo     *Repository    //fala com o banco (budget_repository)
+	product  ProductReader  //lê produtos (via interface)
+	stock    StockService   // checa estoque (via interface)
+	rental   RentalReader   // preço dos equipamentos para locação (via interface)
+	customer CustomerReader // cadastro de clientes (via interface)
 }
 
 // Construtor do Service (falicita testes e facilita manutenção) -> injeção de dependência
-func NewService (repo *Repository, product ProductReader, stock StockService,) *Service {
+func NewService(repo *Repository, product ProductReader, stock StockService, rental RentalReader, customer CustomerReader) *Service {
 	return &Service{
-		repo: repo,
-		product: product,
-		stock: stock,
+		repo:     repo,
+		product:  product,
+		stock:    stock,
+		rental:   rental,
+		customer: customer,
+	}
+}
+
+// customerFor busca o cliente do orçamento (obrigatório e ativo)
+func (s *Service) customerFor(ctx context.Context, id int) (*CustomerLite, error) {
+	if id == 0 {
+		return nil, errors.New("cliente é obrigatório (customer_id)")
+	}
+	c, err := s.customer.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, errors.New("cliente não encontrado")
 	}
+	if !c.Active {
+		return nil, fmt.Errorf("cliente %d está inativo", id)
+	}
+	return c, nil
 }
 
-//Regra principal (criar Orçamento)
-func (s *Service) Create(ctx context.Context, customer string, items []CreateItemRequest) (*Budget, error) {
-	if customer == "" {
-		return nil, errors.New("cliente é obrigatório!")
+// Regra principal (criar Orçamento)
+func (s *Service) Create(ctx context.Context, req CreateBudgetRequest) (*Budget, error) {
+	items := req.Items
+	customer, err := s.customerFor(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(items) == 0 {
-		return nil, errors.New("orçamento precisa de ao menos um item")
+	if len(items) == 0 && len(req.Rentals) == 0 {
+		return nil, errors.New("orçamento precisa de ao menos um item ou locação")
 	}
 
 	budget := &Budget{
-		Customer: customer,
-		Total: 0, //total começa zerado.
+		Customer:    customer.Name,
+		CustomerID:  &customer.ID,
+		Status:      StatusRascunho, // orçamento nasce como rascunho: é só uma cotação
+		StockStatus: StockNenhum,    // estoque só é mexido na aprovação
+		WarehouseID: req.WarehouseID,
+	}
+	if budget.WarehouseID == 0 {
+		budget.WarehouseID = DefaultWarehouseID
+	}
+	// validade: a pedida ou hoje + os dias da configuração
+	if req.ValidUntil != "" {
+		if budget.ValidUntil, err = parseValidUntil(req.ValidUntil); err != nil {
+			return nil, err
+		}
+	} else {
+		settings, err := s.repo.GetSettings(ctx)
+		if err != nil {
+			return nil, err
+		}
+		budget.ValidUntil = todayDate().AddDate(0, 0, settings.ValidityDays).Format(time.DateOnly)
 	}
 
 	//processar itens
-	var budgetItems []BudgetItem // lista de itens finais
+	budgetItems, err := s.buildItems(ctx, customer.ID, items) // lista de itens finais
+	if err != nil {
+		return nil, err
+	}
+	rentals, err := s.buildRentals(ctx, req.Rentals)
+	if err != nil {
+		return nil, err
+	}
+	budget.Items = budgetItems
+	budget.Rentals = rentals
+
+	// bruto, descontos, frete e total (e se o desconto precisa do gerente)
+	if err := s.applyTotals(ctx, budget, req.ChargesRequest); err != nil {
+		return nil, err
+	}
+	//Salva no banco (persistencia isolada no repository)
+	id, err := s.repo.CreateBudget(ctx, budget, budgetItems)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.ID = int64(id)
+	return budget, nil
+}
 
-	for _, item := range items {
-		p, err := s.product.GetByID(ctx, item.ProductID)
+// buildItems valida os itens pedidos, busca o preço de cada produto para o cliente e a
+// quantidade e aplica o desconto do item (em % ou em R$): subtotal = quantidade x preço - desconto
+func (s *Service) buildItems(ctx context.Context, customerID int, reqs []CreateItemRequest) ([]BudgetItem, error) {
+	var items []BudgetItem
+	for i, item := range reqs {
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantidade deve ser maior que zero")
+		}
+		p, err := s.product.GetPrice(ctx, item.ProductID, customerID, item.Quantity)
 		if err != nil {
 			return nil, err
 		}
 		if p == nil {
 			return nil, errors.New("produto não encontrado")
 		}
-		//calcula o subtotal
-		subtotal := item.Quantity * p.Price
-
-		//criação item do orçamento
-		bi := BudgetItem {
-			ProductID: p.ID,
-			Product: p.Name,
-			Quantity: item.Quantity,
-			UnitPrice: p.Price,
-			Subtotal: subtotal,
+		gross := item.Quantity * p.Price
+		percent, discount, err := discountFor(gross, item.DiscountPercent, item.Discount)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, err)
 		}
-		//somar total
-		budget.Total += subtotal
-		budgetItems = append(budgetItems, bi)
+		items = append(items, BudgetItem{
+			ProductID:       p.ID,
+			Product:         p.Name,
+			Quantity:        item.Quantity,
+			UnitPrice:       p.Price,
+			PriceList:       p.PriceList,
+			DiscountPercent: percent,
+			Discount:        discount,
+			Subtotal:        round2(gross - discount),
+		})
 	}
-	//Salva no banco (persistencia isolada no repository)
-	id, err := s.repo.CreateBudget(ctx, budget, budgetItems)
+	return items, nil
+}
+
+// round2 arredonda valores em reais para 2 casas
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// discountFor calcula o desconto sobre base, informado em % (percent) ou em R$ (value), nunca
+// os dois. Retorna o desconto nas duas formas: percentual e valor.
+func discountFor(base, percent, value float64) (float64, float64, error) {
+	switch {
+	case percent < 0 || value < 0:
+		return 0, 0, errors.New("desconto não pode ser negativo")
+	case percent > 0 && value > 0:
+		return 0, 0, errors.New("informe o desconto em percentual ou em valor, não os dois")
+	case percent > 100:
+		return 0, 0, fmt.Errorf("desconto de %.2f%% passa de 100%%", percent)
+	case percent > 0:
+		return percent, round2(base * percent / 100), nil
+	case value > base:
+		return 0, 0, fmt.Errorf("desconto de %.2f maior que o valor (%.2f)", value, base)
+	case value > 0:
+		return round2(value / base * 100), value, nil
+	}
+	return 0, 0, nil
+}
+
+// discountLevels retorna o desconto efetivo do orçamento (% do bruto tirado pelos descontos
+// dos itens e pelo geral) e o maior desconto de um item (%)
+func discountLevels(b *Budget) (effective, maxItem float64) {
+	for _, it := range b.Items {
+		maxItem = math.Max(maxItem, it.DiscountPercent)
+	}
+	if b.Gross > 0 {
+		effective = round2((b.ItemsDiscount + b.Discount) / b.Gross * 100)
+	}
+	return effective, maxItem
+}
+
+// applyTotals monta a composição do orçamento a partir dos itens e locações já calculados:
+// bruto - descontos dos itens - desconto geral + frete + outras despesas = total.
+// O desconto geral incide sobre o bruto menos os descontos dos itens. Se o desconto efetivo
+// ou o de algum item passar do limite configurado, o desconto fica PENDENTE de aprovação.
+func (s *Service) applyTotals(ctx context.Context, b *Budget, ch ChargesRequest) error {
+	if ch.Freight < 0 || ch.OtherCharges < 0 {
+		return errors.New("frete e outras despesas não podem ser negativos")
+	}
+	b.Gross, b.ItemsDiscount = 0, 0
+	for _, it := range b.Items {
+		b.Gross += it.Quantity * it.UnitPrice
+		b.ItemsDiscount += it.Discount
+	}
+	for _, r := range b.Rentals {
+		b.Gross += r.Subtotal
+	}
+	b.Gross = round2(b.Gross)
+	b.ItemsDiscount = round2(b.ItemsDiscount)
+
+	percent, discount, err := discountFor(b.Gross-b.ItemsDiscount, ch.DiscountPercent, ch.Discount)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("desconto do orçamento: %w", err)
+	}
+	b.DiscountPercent = percent
+	b.Discount = discount
+	b.Freight = round2(ch.Freight)
+	b.OtherCharges = round2(ch.OtherCharges)
+	b.Total = round2(b.Gross - b.ItemsDiscount - b.Discount + b.Freight + b.OtherCharges)
+
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
 	}
-	
-	// Dar saída no estoque para cada item do orçamento
-	for _, item := range budgetItems {
-		err := s.stock.Saida(ctx, item.ProductID, item.Quantity)
+	b.DiscountStatus = DescontoNenhum
+	b.DiscountApprovedBy, b.DiscountApprovedAt = "", ""
+	if effective, maxItem := discountLevels(b); effective > settings.MaxDiscountPercent || maxItem > settings.MaxDiscountPercent {
+		b.DiscountStatus = DescontoPendente
+	}
+	return nil
+}
+
+// buildRentals valida as linhas de locação pedidas e calcula o subtotal de cada uma pelo preço
+// do período do equipamento (sem período, DIARIA; sem quantidade de períodos, 1)
+func (s *Service) buildRentals(ctx context.Context, reqs []CreateRentalRequest) ([]BudgetRental, error) {
+	var rentals []BudgetRental
+	for i, r := range reqs {
+		if r.Quantity <= 0 {
+			return nil, fmt.Errorf("locação %d: quantidade deve ser maior que zero", i+1)
+		}
+		if r.Periods < 0 {
+			return nil, fmt.Errorf("locação %d: quantidade de períodos não pode ser negativa", i+1)
+		}
+		if r.Periods == 0 {
+			r.Periods = 1
+		}
+		lite, err := s.rental.GetRate(ctx, r.AssetID, r.Period)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("locação %d: %w", i+1, err)
+		}
+		if lite == nil {
+			return nil, errors.New("equipamento não encontrado")
 		}
+		rentals = append(rentals, BudgetRental{
+			AssetID:  lite.ID,
+			Asset:    lite.Name,
+			Quantity: r.Quantity,
+			Period:   lite.Period,
+			Periods:  r.Periods,
+			Rate:     lite.Rate,
+			Subtotal: float64(r.Quantity*r.Periods) * lite.Rate,
+		})
 	}
-	
-	budget.ID = int64(id)
-	return budget, nil
+	return rentals, nil
 }
 
 // GetByID retorna um orçamento completo (cabeçalho + itens)
@@ -114,7 +420,7 @@ func (s *Service) GetByID(ctx context.Context, id int64) (*Budget, error) {
 	}
 
 	if budget == nil {
-		return nil, errors.New("orçamento não encontrado")
+		return nil, apperr.NotFound("orçamento não encontrado")
 	}
 
 	// 2 -> Buscar os itens do orçamento
@@ -130,10 +436,9 @@ func (s *Service) GetByID(ctx context.Context, id int64) (*Budget, error) {
 	return budget, nil
 }
 
-	
 // List retorna todos os orçamentos com seus itens
 func (s *Service) List(ctx context.Context) ([]Budget, error) {
-	
+
 	// delega a busca pra o repository
 	budgets, err := s.repo.ListBudgets(ctx)
 	if err != nil {
@@ -145,103 +450,466 @@ func (s *Service) List(ctx context.Context) ([]Budget, error) {
 	// - paginação
 	// - permissões
 
-	// retorno final	
+	// retorno final
 	return budgets, nil
+}
+
+// Cancel cancela um orçamento (atalho para Transition -> CANCELADO)
+func (s *Service) Cancel(ctx context.Context, id int64) error {
+	_, err := s.Transition(ctx, id, StatusCancelado)
+	return err
+}
+
+// Transition muda o status do orçamento respeitando o ciclo de vida e aplica o efeito no estoque
+// (ver applyStockEffect). Tudo em uma única transação (status + reservas/movimentações).
+// CONVERTIDO não passa por aqui: a conversão é feita pela venda (ConvertTx), que registra o
+// pagamento e as parcelas.
+func (s *Service) Transition(ctx context.Context, id int64, to string) (*Budget, error) {
+	if !validStatus(to) {
+		return nil, fmt.Errorf("status inválido: %s", to)
+	}
+	if to == StatusConvertido {
+		return nil, fmt.Errorf("%w: a conversão em venda é feita em POST /api/sales", ErrTransicaoInvalida)
 	}
 
-	// cria metodo cancelar orçamento
-	func (s *Service) Cancel(ctx context.Context, id int64) error {
-		// buscar item do orçamento
-		items, err := s.repo.ListItemsByBudget(ctx, id)
-		if err != nil {
-			return err		
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	budget, err := s.transitionTx(ctx, tx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %w", err)
+	}
+	return budget, nil
+}
+
+// ConvertTx converte o orçamento APROVADO em venda dentro da transação recebida (usado pelo
+// módulo de vendas): as reservas viram Saida e o orçamento fica CONVERTIDO.
+// Retorna o orçamento com os itens para a venda guardar.
+func (s *Service) ConvertTx(ctx context.Context, tx *sql.Tx, id int64) (*Budget, error) {
+	return s.transitionTx(ctx, tx, id, StatusConvertido)
+}
+
+// CancelConvertedTx desfaz a venda do orçamento dentro da transação recebida (usado pelo
+// módulo de vendas): o que foi baixado volta ao estoque e o orçamento fica CANCELADO.
+func (s *Service) CancelConvertedTx(ctx context.Context, tx *sql.Tx, id int64) error {
+	budget, err := s.repo.GetByIDTx(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if budget == nil {
+		return apperr.NotFound("orçamento não encontrado")
+	}
+	if budget.Status != StatusConvertido {
+		return fmt.Errorf("%w: orçamento %d está %s", ErrTransicaoInvalida, id, budget.Status)
+	}
+	if err := s.releaseStock(ctx, tx, budget); err != nil {
+		return err
+	}
+	return s.repo.UpdateStatusTx(ctx, tx, id, StatusCancelado, StockNenhum)
+}
+
+// transitionTx valida a mudança de status, aplica o efeito no estoque e grava o novo status
+// dentro da transação recebida
+func (s *Service) transitionTx(ctx context.Context, tx *sql.Tx, id int64, to string) (*Budget, error) {
+	// 1 -> lê o orçamento dentro da transação
+	budget, err := s.repo.GetByIDTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, apperr.NotFound("orçamento não encontrado")
+	}
+
+	// 2 -> valida a transição
+	if !canTransition(budget.Status, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrTransicaoInvalida, budget.Status, to)
+	}
+	// desconto acima do limite: só vai para o cliente depois da aprovação do gerente
+	if budget.DiscountStatus == DescontoPendente && (to == StatusEnviado || to == StatusAprovado || to == StatusConvertido) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrDescontoPendente, budget.Status, to)
+	}
+	// validade vencida: o preço pode ter mudado, não segue adiante sem renovar
+	if budget.ValidUntil != "" && budget.ValidUntil < today() && (to == StatusEnviado || to == StatusAprovado || to == StatusConvertido) {
+		return nil, fmt.Errorf("%w: venceu em %s (altere valid_until para renovar)", ErrOrcamentoVencido, budget.ValidUntil)
+	}
+
+	// 3 -> efeito no estoque
+	stockStatus, err := s.applyStockEffect(ctx, tx, budget, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4 -> grava o novo status
+	if err := s.repo.UpdateStatusTx(ctx, tx, id, to, stockStatus); err != nil {
+		return nil, err
+	}
+
+	budget.Status = to
+	budget.StockStatus = stockStatus
+	return budget, nil
+}
+
+// applyStockEffect aplica o efeito da transição no estoque e retorna o novo stock_status:
+// - APROVADO: reserva os itens (estoque físico não muda)
+// - CONVERTIDO: reservas viram Saida (ou baixa direto, se não havia reserva)
+// - CANCELADO / EXPIRADO: libera as reservas ou devolve o que tinha sido baixado
+func (s *Service) applyStockEffect(ctx context.Context, tx *sql.Tx, budget *Budget, to string) (string, error) {
+	switch to {
+	case StatusAprovado:
+		if budget.StockStatus == StockNenhum {
+			if err := s.reserveItems(ctx, tx, budget); err != nil {
+				return "", err
+			}
+			return StockReservado, nil
+		}
+	case StatusConvertido:
+		switch budget.StockStatus {
+		case StockReservado:
+			if err := s.stock.CommitTx(ctx, tx, budget.ID); err != nil {
+				return "", err
+			}
+			return StockBaixado, nil
+		case StockNenhum:
+			for _, item := range budget.Items {
+				if err := s.stock.SaidaTx(ctx, tx, budget.WarehouseID, item.ProductID, item.Quantity, motivoVenda, stockDocument(budget.ID)); err != nil {
+					return "", err
+				}
+			}
+			return StockBaixado, nil
+		}
+	case StatusCancelado, StatusExpirado:
+		if err := s.releaseStock(ctx, tx, budget); err != nil {
+			return "", err
 		}
+		return StockNenhum, nil
+	}
+	return budget.StockStatus, nil
+}
 
-		if len(items) == 0 {
-			return errors.New("orçamento não encontrado ou sem itens")
+// reserveItems cria uma reserva por item do orçamento, no depósito do orçamento
+func (s *Service) reserveItems(ctx context.Context, tx *sql.Tx, budget *Budget) error {
+	for _, item := range budget.Items {
+		if err := s.stock.ReserveTx(ctx, tx, budget.ID, budget.WarehouseID, item.ProductID, item.Quantity); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		// Devolve o estoque para cada item
-		for _, item := range items {
-			err := s.stock.Entrada(ctx, item.ProductID, item.Quantity) //metodo entrada no stock/service.go
-			if err != nil {
+// releaseStock desfaz o que o orçamento segura no estoque (reserva ou baixa)
+func (s *Service) releaseStock(ctx context.Context, tx *sql.Tx, budget *Budget) error {
+	switch budget.StockStatus {
+	case StockReservado:
+		return s.stock.ReleaseTx(ctx, tx, budget.ID)
+	case StockBaixado:
+		for _, item := range budget.Items {
+			if err := s.stock.EntradaTx(ctx, tx, budget.WarehouseID, item.ProductID, item.Quantity, motivoDevolucao, stockDocument(budget.ID)); err != nil {
 				return err
 			}
 		}
-
-		// Atualiza o status do orçamento para "Cancelado"
-		return s.repo.Cancel(ctx, id)
 	}
+	return nil
+}
 
-	// Update atualiza um orçamento existente
-	func (s *Service) Update(
-		ctx context.Context,
-		budgetID int64,
-		customer string,
-		items []CreateItemRequest,
-	) (*Budget, error) {
+// Update atualiza um orçamento existente.
+// Na mesma transação da troca dos itens, o estoque segurado pelo orçamento é acertado:
+// - reservado (APROVADO): libera as reservas antigas e reserva os itens novos;
+// - baixado (orçamentos antigos): a diferença por produto vira Saida (aumentou) ou Entrada (diminuiu).
+func (s *Service) Update(ctx context.Context, budgetID int64, req UpdateBudgetRequest) (*Budget, error) {
+	items := req.Items
 
-		// 1 -> Validar dados (semelhante ao Create)
+	// 1 -> Validar dados (semelhante ao Create)
 
-		if customer == "" {
-			return nil, errors.New("cliente é obrigatório!")
+	customer, err := s.customerFor(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 && len(req.Rentals) == 0 {
+		return nil, errors.New("orçamento precisa de ao menos um item ou locação")
+	}
+
+	budget := &Budget{
+		ID:          budgetID,
+		Customer:    customer.Name,
+		CustomerID:  &customer.ID,
+		WarehouseID: req.WarehouseID,
+	}
+	if req.ValidUntil != "" { // renova/muda a validade (sem ela mantém a atual)
+		if budget.ValidUntil, err = parseValidUntil(req.ValidUntil); err != nil {
+			return nil, err
 		}
+	}
+
+	//processar itens
+	budgetItems, err := s.buildItems(ctx, customer.ID, items) // lista de itens finais
+	if err != nil {
+		return nil, err
+	}
+	rentals, err := s.buildRentals(ctx, req.Rentals)
+	if err != nil {
+		return nil, err
+	}
+	budget.Items = budgetItems // usados abaixo para refazer reserva/baixa
+	budget.Rentals = rentals
+	if err := s.applyTotals(ctx, budget, req.ChargesRequest); err != nil {
+		return nil, err
+	}
 
-		if len(items) == 0 {
-			return nil, errors.New("orçamento precisa de ao menos um item")
+	// 2 -> transação: lê o orçamento atual, troca os itens e acerta o estoque
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	current, err := s.repo.GetByIDTx(ctx, tx, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, apperr.NotFound("orçamento não encontrado")
+	}
+	if !editable(current.Status) {
+		return nil, fmt.Errorf("%w (%s)", ErrNaoEditavel, current.Status)
+	}
+	budget.Status = current.Status
+	budget.StockStatus = current.StockStatus
+	budget.CreatedAt = current.CreatedAt
+
+	// desconto já aprovado continua valendo se a alteração não aumentou os descontos
+	if budget.DiscountStatus == DescontoPendente && current.DiscountStatus == DescontoAprovado {
+		effective, maxItem := discountLevels(budget)
+		approvedEffective, approvedMaxItem := discountLevels(current)
+		if effective <= approvedEffective && maxItem <= approvedMaxItem {
+			budget.DiscountStatus = DescontoAprovado
+			budget.DiscountApprovedBy = current.DiscountApprovedBy
+			budget.DiscountApprovedAt = current.DiscountApprovedAt
 		}
+	}
+	if budget.DiscountStatus == DescontoPendente && current.Status != StatusRascunho {
+		return nil, fmt.Errorf("%w: em %s a alteração só pode manter ou reduzir o desconto aprovado", ErrDescontoPendente, current.Status)
+	}
+	if budget.WarehouseID == 0 {
+		budget.WarehouseID = current.WarehouseID // mantém o depósito se não vier no pedido
+	}
+	if budget.ValidUntil == "" {
+		budget.ValidUntil = current.ValidUntil
+	}
 
-		budget := &Budget{
-			ID: budgetID,
-			Customer: customer,
-			Total: 0, //total começa zerado.
+	// persistencia no banco via repository
+	if err := s.repo.UpdateBudgetTx(ctx, tx, budget, budgetItems); err != nil {
+		return nil, err
+	}
+
+	// 3 -> acerta o estoque segurado pelo orçamento
+	switch current.StockStatus {
+	case StockReservado:
+		if err := s.stock.ReleaseTx(ctx, tx, budgetID); err != nil {
+			return nil, err
+		}
+		if err := s.reserveItems(ctx, tx, budget); err != nil {
+			return nil, err
 		}
+	case StockBaixado:
+		if err := s.applyDeltas(ctx, tx, current, budget); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %w", err)
+	}
 
-		//processar itens
-		var budgetItems []BudgetItem // lista de itens finais
+	return budget, nil
+}
 
-		for _, item := range items {
-			p, err := s.product.GetByID(ctx, item.ProductID)
-			if err != nil {
-				return nil, err
+// applyDeltas posta as movimentações que levam o estoque baixado pelo orçamento antigo
+// para o que o orçamento novo pede, por depósito e produto
+// (delta > 0 -> Saida, delta < 0 -> Entrada). Trocar o depósito devolve no antigo e baixa no novo.
+func (s *Service) applyDeltas(ctx context.Context, tx *sql.Tx, old, updated *Budget) error {
+	type key struct{ warehouseID, productID int }
+	deltas := map[key]float64{}
+	var order []key // mantém a ordem dos produtos (movimentações previsíveis no histórico)
+	add := func(k key, qty float64) {
+		if _, ok := deltas[k]; !ok {
+			order = append(order, k)
+		}
+		deltas[k] += qty
+	}
+	for _, it := range old.Items {
+		add(key{old.WarehouseID, it.ProductID}, -it.Quantity)
+	}
+	for _, it := range updated.Items {
+		add(key{updated.WarehouseID, it.ProductID}, it.Quantity)
+	}
+
+	for _, k := range order {
+		delta := deltas[k]
+		switch {
+		case delta > 0:
+			if err := s.stock.SaidaTx(ctx, tx, k.warehouseID, k.productID, delta, motivoVenda, stockDocument(updated.ID)); err != nil {
+				return err
 			}
-			if p == nil {
-				return nil, errors.New("produto não encontrado")
+		case delta < 0:
+			if err := s.stock.EntradaTx(ctx, tx, k.warehouseID, k.productID, -delta, motivoDevolucao, stockDocument(updated.ID)); err != nil {
+				return err
 			}
-			//calcula o subtotal
-			subtotal := item.Quantity * p.Price
-
-			//criação item do orçamento
-			budgetItems = append(budgetItems, BudgetItem {
-				ProductID: p.ID,
-				Product: p.Name,
-				Quantity: item.Quantity,
-				UnitPrice: p.Price,
-				Subtotal: subtotal,
-			})
-			//somar total
-			budget.Total += subtotal
-		}
-
-		// persistencia no banco via repository
-		err := s.repo.UpdateBudget(ctx, budget, budgetItems)
-		if err != nil {
-			return nil, err
 		}
-		
-		// associar os itens ao budget antes de retornar
-		budget.Items = budgetItems
-		return budget, nil
 	}
-// Delete remove um orçamento e seus itens
+	return nil
+}
+
+// Delete remove um orçamento e seus itens.
+// O estoque segurado pelo orçamento (reserva ou baixa) é liberado na mesma transação.
+// Orçamentos convertidos em venda não podem ser apagados: a mercadoria já saiu.
 func (s *Service) Delete(ctx context.Context, id int64) error {
-	// Chamar o repository para deletar
-	err := s.repo.DeleteBudget(ctx, id)
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	// 1 -> lê o orçamento dentro da transação
+	budget, err := s.repo.GetByIDTx(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if budget == nil {
+		return apperr.NotFound("orçamento não encontrado")
+	}
+	if budget.Status == StatusConvertido {
+		return fmt.Errorf("%w (%s)", ErrNaoEditavel, budget.Status)
+	}
+
+	// 2 -> libera reservas / devolve o estoque baixado
+	if err := s.releaseStock(ctx, tx, budget); err != nil {
+		return err
+	}
+
+	// 3 -> Chamar o repository para deletar
+	err = s.repo.DeleteBudgetTx(ctx, tx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return errors.New("orçamento não encontrado")
+			return apperr.NotFound("orçamento não encontrado")
 		}
 		return err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar transação: %w", err)
+	}
 	return nil
 }
+
+// ApproveDiscount registra a aprovação do gerente para o desconto acima do limite.
+// Depois da aprovação o orçamento pode ser enviado, aprovado e convertido normalmente.
+func (s *Service) ApproveDiscount(ctx context.Context, id int64, approvedBy string) (*Budget, error) {
+	approvedBy = strings.TrimSpace(approvedBy)
+	if approvedBy == "" {
+		return nil, errors.New("informe o gerente que aprova o desconto (approved_by)")
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	budget, err := s.repo.GetByIDTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, apperr.NotFound("orçamento não encontrado")
+	}
+	if budget.DiscountStatus != DescontoPendente {
+		return nil, fmt.Errorf("%w (desconto %s)", ErrDescontoNaoPendente, budget.DiscountStatus)
+	}
+	if err := s.repo.ApproveDiscountTx(ctx, tx, id, approvedBy); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %w", err)
+	}
+	return s.GetByID(ctx, id)
+}
+
+// GetSettings retorna a configuração dos orçamentos (limite de desconto)
+func (s *Service) GetSettings(ctx context.Context) (*Settings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// SetSettings grava a configuração dos orçamentos. O novo limite e a nova validade valem para
+// os orçamentos criados ou alterados depois; os já gravados mantêm o que tinham.
+func (s *Service) SetSettings(ctx context.Context, st Settings) (*Settings, error) {
+	if st.MaxDiscountPercent < 0 || st.MaxDiscountPercent > 100 {
+		return nil, fmt.Errorf("limite de desconto inválido: %.2f (use de 0 a 100)", st.MaxDiscountPercent)
+	}
+	if st.ValidityDays < 1 || st.ValidityDays > 365 {
+		return nil, fmt.Errorf("validade padrão inválida: %d dias (use de 1 a 365)", st.ValidityDays)
+	}
+	if err := s.repo.SaveSettings(ctx, st); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSettings(ctx)
+}
+
+// Expiring retorna os orçamentos abertos que vencem de hoje até daqui a days dias
+func (s *Service) Expiring(ctx context.Context, days int) ([]Budget, error) {
+	if days < 0 {
+		return nil, errors.New("days não pode ser negativo")
+	}
+	to := todayDate().AddDate(0, 0, days).Format(time.DateOnly)
+	return s.repo.ListExpiring(ctx, today(), to)
+}
+
+// ExpireOverdue marca como EXPIRADO os orçamentos abertos com a validade vencida, liberando o
+// estoque que eles seguram (mesmo caminho do PUT /status). Retorna os IDs expirados; o erro de
+// um orçamento não impede os demais.
+func (s *Service) ExpireOverdue(ctx context.Context) ([]int64, error) {
+	ids, err := s.repo.ListOverdue(ctx, today())
+	if err != nil {
+		return nil, err
+	}
+	var expired []int64
+	var errs []error
+	for _, id := range ids {
+		if _, err := s.Transition(ctx, id, StatusExpirado); err != nil {
+			if errors.Is(err, ErrTransicaoInvalida) {
+				continue // convertido/cancelado depois da busca
+			}
+			errs = append(errs, fmt.Errorf("orçamento %d: %w", id, err))
+			continue
+		}
+		expired = append(expired, id)
+	}
+	return expired, errors.Join(errs...)
+}
+
+// RunExpiryJob roda ExpireOverdue na hora e depois a cada interval, até o ctx ser cancelado.
+// O servidor chama em uma goroutine; notify (opcional) recebe o resultado de cada rodada.
+func (s *Service) RunExpiryJob(ctx context.Context, interval time.Duration, notify func(expired []int64, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		expired, err := s.ExpireOverdue(ctx)
+		if ctx.Err() != nil {
+			return // desligando: a rodada interrompida não é erro
+		}
+		if notify != nil {
+			notify(expired, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package budget
+
+import "testing"
+
+// TestCanTransition percorre todos os pares de status: só as arestas do ciclo de vida são
+// aceitas, os estados finais não saem para lugar nenhum e nenhum status volta para si mesmo
+func TestCanTransition(t *testing.T) {
+	statuses := []string{StatusRascunho, StatusEnviado, StatusAprovado, StatusConvertido, StatusExpirado, StatusCancelado}
+	allowed := map[[2]string]bool{
+		{StatusRascunho, StatusEnviado}:    true,
+		{StatusRascunho, StatusAprovado}:   true,
+		{StatusRascunho, StatusExpirado}:   true,
+		{StatusRascunho, StatusCancelado}:  true,
+		{StatusEnviado, StatusRascunho}:    true,
+		{StatusEnviado, StatusAprovado}:    true,
+		{StatusEnviado, StatusExpirado}:    true,
+		{StatusEnviado, StatusCancelado}:   true,
+		{StatusAprovado, StatusConvertido}: true,
+		{StatusAprovado, StatusExpirado}:   true,
+		{StatusAprovado, StatusCancelado}:  true,
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]string{from, to}]
+			if got := canTransition(from, to); got != want {
+				t.Errorf("%s -> %s: permitido = %v, esperado %v", from, to, got, want)
+			}
+		}
+	}
+	// status desconhecido não sai nem entra
+	if canTransition("PAGO", StatusCancelado) || canTransition(StatusRascunho, "PAGO") {
+		t.Error("status desconhecido não deveria transitar")
+	}
+}
@@ -0,0 +1,121 @@
+package customer
+
+import (
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// Handler expõe os endpoints HTTP de clientes
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de cliente no grupo Echo (ex.: /api/customers)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("", h.Create)
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.PUT("/:id", h.Update)
+	// orçamentos do cliente e total comprado
+	g.GET("/:id/budgets", h.History)
+	// une um cadastro duplicado ao cliente
+	g.POST("/:id/merge", h.Merge)
+}
+
+// MergeRequest indica o cadastro duplicado a unir. Ex.: {"from_id": 12}
+type MergeRequest struct {
+	FromID int `json:"from_id"`
+}
+
+// Create cria um cliente.
+// Ex.: {"type": "PJ", "name": "Construtora Exemplo Ltda", "document": "11.222.333/0001-81",
+// "state_registration": "ISENTO", "phones": [{"number": "(11) 99999-0000", "label": "celular"}],
+// "addresses": [{"label": "obra", "street": "Rua A", "number": "10", "city": "São Paulo", "state": "SP", "zip": "01001-000"}]}
+func (h *Handler) Create(c echo.Context) error {
+	var req Customer
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	id, err := h.svc.Create(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]int64{"id": id})
+}
+
+// List retorna os clientes (?ativos=true para só os ativos, ?q= busca por nome ou documento)
+func (h *Handler) List(c echo.Context) error {
+	list, err := h.svc.List(c.Request().Context(), c.QueryParam("ativos") == "true", c.QueryParam("q"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// Get retorna um cliente por id, com endereços e telefones
+func (h *Handler) Get(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	cust, err := h.svc.Get(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, cust)
+}
+
+// Update atualiza o cadastro do cliente (inclusive ativo/inativo)
+func (h *Handler) Update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req Customer
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	req.ID = id
+	if err := h.svc.Update(c.Request().Context(), &req); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "cliente atualizado com sucesso"})
+}
+
+// History retorna os orçamentos do cliente e o total comprado
+func (h *Handler) History(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	hist, err := h.svc.History(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, hist)
+}
+
+// Merge une o cadastro from_id ao cliente da URL (o from_id deixa de existir)
+func (h *Handler) Merge(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req MergeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	cust, err := h.svc.Merge(c.Request().Context(), id, req.FromID)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, cust)
+}
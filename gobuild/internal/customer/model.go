@@ -0,0 +1,56 @@
+package customer
+
+// Customer representa um cliente: pessoa física (CPF) ou jurídica (CNPJ).
+// Clientes migrados dos orçamentos antigos vêm sem tipo e sem documento até o cadastro ser
+// completado.
+type Customer struct {
+	ID                int       `json:"id"`                 // id auto-incremental (PK)
+	Type              string    `json:"type"`               // PF ou PJ
+	Name              string    `json:"name"`               // nome completo ou razão social
+	TradeName         string    `json:"trade_name"`         // nome fantasia (PJ, opcional)
+	Document          string    `json:"document"`           // CPF ou CNPJ, só com os dígitos
+	StateRegistration string    `json:"state_registration"` // inscrição estadual (PJ): dígitos ou ISENTO
+	Email             string    `json:"email"`
+	Notes             string    `json:"notes"`
+	PriceTierID       *int      `json:"price_tier_id"` // tabela de preço (varejo, atacado, construtora...); nula = preço de cadastro
+	Active            bool      `json:"active"`
+	CreatedAt         string    `json:"created_at"`
+	Addresses         []Address `json:"addresses,omitempty"` // endereços (entrega, cobrança...)
+	Phones            []Phone   `json:"phones,omitempty"`
+}
+
+// Address é um endereço do cliente
+type Address struct {
+	Label      string `json:"label"` // ex.: "entrega", "obra", "cobrança"
+	Street     string `json:"street"`
+	Number     string `json:"number"`
+	Complement string `json:"complement"`
+	District   string `json:"district"` // bairro
+	City       string `json:"city"`
+	State      string `json:"state"` // UF (2 letras)
+	Zip        string `json:"zip"`   // CEP, só com os dígitos
+}
+
+// Phone é um telefone do cliente (com DDD, só com os dígitos)
+type Phone struct {
+	Number string `json:"number"`
+	Label  string `json:"label"` // ex.: "celular", "obra"
+}
+
+// History é o histórico de orçamentos do cliente. Compras são os orçamentos convertidos em venda.
+type History struct {
+	CustomerID     int             `json:"customer_id"`
+	Budgets        []BudgetSummary `json:"budgets"`
+	BudgetCount    int             `json:"budget_count"`
+	PurchaseCount  int             `json:"purchase_count"`
+	TotalPurchases float64         `json:"total_purchases"`
+	LastPurchaseAt string          `json:"last_purchase_at,omitempty"`
+}
+
+// BudgetSummary é um orçamento no histórico do cliente
+type BudgetSummary struct {
+	ID        int64   `json:"id"`
+	Total     float64 `json:"total"`
+	Status    string  `json:"status"`
+	CreatedAt string  `json:"created_at"`
+}
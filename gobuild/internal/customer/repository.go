@@ -0,0 +1,262 @@
+package customer
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL de clientes, endereços e telefones
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de clientes
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// customerColumns são as colunas lidas por scanCustomer (mesma ordem)
+const customerColumns = `id, COALESCE(type, ''), name, COALESCE(trade_name, ''), COALESCE(document, ''),
+	COALESCE(state_registration, ''), COALESCE(email, ''), COALESCE(notes, ''), price_tier_id, active, created_at`
+
+// scanCustomer lê um cliente de uma linha (Row ou Rows), sem endereços e telefones
+func scanCustomer(scan func(dest ...any) error) (Customer, error) {
+	var c Customer
+	err := scan(&c.ID, &c.Type, &c.Name, &c.TradeName, &c.Document,
+		&c.StateRegistration, &c.Email, &c.Notes, &c.PriceTierID, &c.Active, &c.CreatedAt)
+	return c, err
+}
+
+// CreateTx insere o cliente com endereços e telefones e retorna o ID gerado
+func (r *Repository) CreateTx(ctx context.Context, tx *sql.Tx, c *Customer) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO customers (type, name, trade_name, document, state_registration, email, notes, price_tier_id, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		nullString(c.Type), c.Name, nullString(c.TradeName), nullString(c.Document),
+		nullString(c.StateRegistration), nullString(c.Email), nullString(c.Notes), c.PriceTierID, c.Active)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir cliente: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do cliente: %v", err)
+	}
+	if err := r.replaceContactsTx(ctx, tx, int(id), c); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateTx atualiza o cadastro e substitui endereços e telefones do cliente
+func (r *Repository) UpdateTx(ctx context.Context, tx *sql.Tx, c *Customer) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE customers SET type = ?, name = ?, trade_name = ?, document = ?, state_registration = ?,
+			email = ?, notes = ?, price_tier_id = ?, active = ? WHERE id = ?`,
+		nullString(c.Type), c.Name, nullString(c.TradeName), nullString(c.Document),
+		nullString(c.StateRegistration), nullString(c.Email), nullString(c.Notes), c.PriceTierID, c.Active, c.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar cliente: %v", err)
+	}
+	return r.replaceContactsTx(ctx, tx, c.ID, c)
+}
+
+// replaceContactsTx apaga endereços e telefones do cliente e grava as listas informadas
+func (r *Repository) replaceContactsTx(ctx context.Context, tx *sql.Tx, customerID int, c *Customer) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customer_addresses WHERE customer_id = ?`, customerID); err != nil {
+		return fmt.Errorf("erro ao remover endereços do cliente: %v", err)
+	}
+	for _, a := range c.Addresses {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO customer_addresses (customer_id, label, street, number, complement, district, city, state, zip)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			customerID, nullString(a.Label), a.Street, nullString(a.Number), nullString(a.Complement),
+			nullString(a.District), a.City, a.State, nullString(a.Zip))
+		if err != nil {
+			return fmt.Errorf("erro ao inserir endereço do cliente: %v", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customer_phones WHERE customer_id = ?`, customerID); err != nil {
+		return fmt.Errorf("erro ao remover telefones do cliente: %v", err)
+	}
+	for _, p := range c.Phones {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO customer_phones (customer_id, number, label) VALUES (?, ?, ?)`,
+			customerID, p.Number, nullString(p.Label))
+		if err != nil {
+			return fmt.Errorf("erro ao inserir telefone do cliente: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetAll retorna os clientes (sem endereços e telefones) por nome, só os ativos se activeOnly.
+// search filtra por nome, nome fantasia ou documento.
+func (r *Repository) GetAll(ctx context.Context, activeOnly bool, search string) ([]Customer, error) {
+	like := "%" + search + "%"
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+customerColumns+` FROM customers
+		WHERE (? = 0 OR active = 1)
+		  AND (? = '' OR name LIKE ? OR trade_name LIKE ? OR document LIKE ?)
+		ORDER BY name, id`, activeOnly, search, like, like, like)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar clientes: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Customer{}
+	for rows.Next() {
+		c, err := scanCustomer(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear cliente: %v", err)
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos clientes: %v", err)
+	}
+	return list, nil
+}
+
+// GetByID busca um cliente pelo ID com endereços e telefones (nil, nil se não existir)
+func (r *Repository) GetByID(ctx context.Context, id int) (*Customer, error) {
+	c, err := scanCustomer(r.DB.QueryRowContext(ctx,
+		`SELECT `+customerColumns+` FROM customers WHERE id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // cliente não encontrado
+		}
+		return nil, fmt.Errorf("erro ao escanear cliente: %v", err)
+	}
+	if c.Addresses, err = r.listAddresses(ctx, id); err != nil {
+		return nil, err
+	}
+	if c.Phones, err = r.listPhones(ctx, id); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// IDByDocument retorna o ID do cliente com o CPF/CNPJ (0 se não houver)
+func (r *Repository) IDByDocument(ctx context.Context, document string) (int, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, `SELECT id FROM customers WHERE document = ?`, document).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar cliente pelo documento: %v", err)
+	}
+	return id, nil
+}
+
+// PriceTierActive informa se a tabela de preço existe e se está ativa
+func (r *Repository) PriceTierActive(ctx context.Context, id int) (exists, active bool, err error) {
+	err = r.DB.QueryRowContext(ctx, `SELECT active FROM price_tiers WHERE id = ?`, id).Scan(&active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("erro ao buscar tabela de preço: %v", err)
+	}
+	return true, active, nil
+}
+
+// listAddresses retorna os endereços do cliente na ordem de cadastro
+func (r *Repository) listAddresses(ctx context.Context, customerID int) ([]Address, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT COALESCE(label, ''), street, COALESCE(number, ''), COALESCE(complement, ''),
+			COALESCE(district, ''), city, state, COALESCE(zip, '')
+		FROM customer_addresses WHERE customer_id = ? ORDER BY id`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar endereços do cliente: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Address{}
+	for rows.Next() {
+		var a Address
+		if err := rows.Scan(&a.Label, &a.Street, &a.Number, &a.Complement, &a.District, &a.City, &a.State, &a.Zip); err != nil {
+			return nil, fmt.Errorf("erro ao escanear endereço: %v", err)
+		}
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos endereços: %v", err)
+	}
+	return list, nil
+}
+
+// listPhones retorna os telefones do cliente na ordem de cadastro
+func (r *Repository) listPhones(ctx context.Context, customerID int) ([]Phone, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT number, COALESCE(label, '') FROM customer_phones WHERE customer_id = ? ORDER BY id`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar telefones do cliente: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Phone{}
+	for rows.Next() {
+		var p Phone
+		if err := rows.Scan(&p.Number, &p.Label); err != nil {
+			return nil, fmt.Errorf("erro ao escanear telefone: %v", err)
+		}
+		list = append(list, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos telefones: %v", err)
+	}
+	return list, nil
+}
+
+// ListBudgets retorna os orçamentos do cliente (mais recentes primeiro)
+func (r *Repository) ListBudgets(ctx context.Context, customerID int) ([]BudgetSummary, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, total, status, created_at FROM budgets
+		WHERE customer_id = ? ORDER BY created_at DESC, id DESC`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar orçamentos do cliente: %v", err)
+	}
+	defer rows.Close()
+
+	list := []BudgetSummary{}
+	for rows.Next() {
+		var b BudgetSummary
+		if err := rows.Scan(&b.ID, &b.Total, &b.Status, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear orçamento: %v", err)
+		}
+		list = append(list, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos orçamentos: %v", err)
+	}
+	return list, nil
+}
+
+// MergeTx passa os orçamentos do cliente fromID para o targetID e apaga o cadastro duplicado
+// (com endereços e telefones; o service grava os do duplicado no targetID)
+func (r *Repository) MergeTx(ctx context.Context, tx *sql.Tx, targetID, fromID int) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE budgets SET customer_id = ? WHERE customer_id = ?`, targetID, fromID); err != nil {
+		return fmt.Errorf("erro ao transferir orçamentos do cliente: %v", err)
+	}
+	for _, table := range []string{"customer_addresses", "customer_phones"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE customer_id = ?`, fromID); err != nil {
+			return fmt.Errorf("erro ao apagar %s do cliente duplicado: %v", table, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM customers WHERE id = ?`, fromID); err != nil {
+		return fmt.Errorf("erro ao apagar cliente duplicado: %v", err)
+	}
+	return nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
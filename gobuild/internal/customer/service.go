@@ -0,0 +1,334 @@
+package customer
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"fmt"     // para formatação de strings e erros
+	"strings" // para limpar os campos de texto
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/budget" // CustomerLite e status (interface budget.CustomerReader)
+	"github.com/EtraudBits/golangProject/gobuild/internal/taxid"  // validação de CPF/CNPJ
+)
+
+// ErrDocumentoDuplicado indica CPF/CNPJ já cadastrado em outro cliente (o handler responde 409)
+var ErrDocumentoDuplicado = apperr.Conflict("já existe cliente com este documento")
+
+// ufs são as unidades da federação aceitas nos endereços
+var ufs = map[string]bool{
+	"AC": true, "AL": true, "AP": true, "AM": true, "BA": true, "CE": true, "DF": true,
+	"ES": true, "GO": true, "MA": true, "MT": true, "MS": true, "MG": true, "PA": true,
+	"PB": true, "PR": true, "PE": true, "PI": true, "RJ": true, "RN": true, "RS": true,
+	"RO": true, "RR": true, "SC": true, "SP": true, "SE": true, "TO": true,
+}
+
+// Service contém as regras de negócio de clientes
+type Service struct {
+	repo *Repository // dependencia do repositorio para persistencia
+}
+
+// NewService cria uma nova instância do serviço de clientes
+func NewService(r *Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// onlyDigits tira a pontuação (. - / ( ) e espaço) de CEP, telefone e inscrição estadual;
+// ok é false se sobrar outro caractere
+func onlyDigits(v string) (string, bool) {
+	var b strings.Builder
+	for _, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case strings.ContainsRune(".-/() ", r):
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// validate limpa e valida o cadastro antes de salvar (documento, CEP e telefones ficam só
+// com os dígitos). O documento é opcional (cliente de balcão), mas se vier precisa ser um
+// CPF (PF) ou CNPJ (PJ) válido.
+func validate(c *Customer) error {
+	c.Type = strings.ToUpper(strings.TrimSpace(c.Type))
+	c.Name = strings.TrimSpace(c.Name)
+	c.TradeName = strings.TrimSpace(c.TradeName)
+	c.Email = strings.TrimSpace(c.Email)
+	if c.Name == "" {
+		return apperr.Invalid("o nome do cliente não pode ser vazio")
+	}
+	if c.Type != taxid.PessoaFisica && c.Type != taxid.PessoaJuridica {
+		return apperr.Invalid("tipo de pessoa inválido: %q (use PF ou PJ)", c.Type)
+	}
+	if strings.TrimSpace(c.Document) != "" {
+		doc, ok := taxid.Document(c.Type, c.Document)
+		if !ok {
+			if c.Type == taxid.PessoaFisica {
+				return apperr.Invalid("CPF inválido: %s", c.Document)
+			}
+			return apperr.Invalid("CNPJ inválido: %s", c.Document)
+		}
+		c.Document = doc
+	} else {
+		c.Document = ""
+	}
+
+	// inscrição estadual: só para PJ; dígitos (o formato muda por estado) ou ISENTO
+	ie := strings.ToUpper(strings.TrimSpace(c.StateRegistration))
+	switch {
+	case ie == "" || ie == "ISENTO":
+	case c.Type != taxid.PessoaJuridica:
+		return apperr.Invalid("inscrição estadual só se aplica a pessoa jurídica")
+	default:
+		digits, ok := onlyDigits(ie)
+		if !ok || len(digits) < 2 || len(digits) > 14 {
+			return apperr.Invalid("inscrição estadual inválida: %s (use os dígitos ou ISENTO)", c.StateRegistration)
+		}
+		ie = digits
+	}
+	c.StateRegistration = ie
+
+	if c.Email != "" && !strings.Contains(c.Email, "@") {
+		return apperr.Invalid("e-mail inválido: %s", c.Email)
+	}
+	for i := range c.Addresses {
+		a := &c.Addresses[i]
+		a.Street = strings.TrimSpace(a.Street)
+		a.City = strings.TrimSpace(a.City)
+		a.State = strings.ToUpper(strings.TrimSpace(a.State))
+		if a.Street == "" || a.City == "" {
+			return apperr.Invalid("endereço %d: informe logradouro e cidade", i+1)
+		}
+		if !ufs[a.State] {
+			return apperr.Invalid("endereço %d: UF inválida: %q", i+1, a.State)
+		}
+		if a.Zip != "" {
+			zip, ok := onlyDigits(a.Zip)
+			if !ok || len(zip) != 8 {
+				return apperr.Invalid("endereço %d: CEP inválido: %s", i+1, a.Zip)
+			}
+			a.Zip = zip
+		}
+	}
+	for i := range c.Phones {
+		number, ok := onlyDigits(c.Phones[i].Number)
+		if !ok || len(number) < 10 || len(number) > 11 {
+			return apperr.Invalid("telefone %d: informe DDD + número (10 ou 11 dígitos): %s", i+1, c.Phones[i].Number)
+		}
+		c.Phones[i].Number = number
+	}
+	return nil
+}
+
+// checkDocument recusa documento já usado por outro cliente (exceptID é o próprio, na atualização)
+func (s *Service) checkDocument(ctx context.Context, document string, exceptID int) error {
+	if document == "" {
+		return nil
+	}
+	id, err := s.repo.IDByDocument(ctx, document)
+	if err != nil {
+		return err
+	}
+	if id != 0 && id != exceptID {
+		return fmt.Errorf("%w (cliente %d)", ErrDocumentoDuplicado, id)
+	}
+	return nil
+}
+
+// checkPriceTier recusa tabela de preço inexistente ou inativa (sem tabela é permitido)
+func (s *Service) checkPriceTier(ctx context.Context, tierID *int) error {
+	if tierID == nil {
+		return nil
+	}
+	exists, active, err := s.repo.PriceTierActive(ctx, *tierID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apperr.Invalid("tabela de preço %d não existe", *tierID)
+	}
+	if !active {
+		return apperr.Invalid("tabela de preço %d está inativa", *tierID)
+	}
+	return nil
+}
+
+// Create cadastra um cliente (ativo) com endereços e telefones
+func (s *Service) Create(ctx context.Context, c *Customer) (int64, error) {
+	if err := validate(c); err != nil {
+		return 0, fmt.Errorf("validação do cliente falhou: %w", err)
+	}
+	if err := s.checkDocument(ctx, c.Document, 0); err != nil {
+		return 0, err
+	}
+	if err := s.checkPriceTier(ctx, c.PriceTierID); err != nil {
+		return 0, err
+	}
+	c.Active = true
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	id, err := s.repo.CreateTx(ctx, tx, c)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return id, nil
+}
+
+// List retorna os clientes (só os ativos se activeOnly), filtrando por nome/documento
+func (s *Service) List(ctx context.Context, activeOnly bool, search string) ([]Customer, error) {
+	search = strings.TrimSpace(search)
+	// busca por documento formatado (123.456.789-09) casa com o gravado só com dígitos
+	if digits, ok := onlyDigits(search); ok && digits != "" {
+		search = digits
+	}
+	return s.repo.GetAll(ctx, activeOnly, search)
+}
+
+// Get retorna um cliente por ID com endereços e telefones, ou erro se não encontrado
+func (s *Service) Get(ctx context.Context, id int) (*Customer, error) {
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, apperr.NotFound("cliente com ID %d não encontrado", id)
+	}
+	return c, nil
+}
+
+// Update atualiza o cadastro do cliente; endereços e telefones informados substituem os atuais
+func (s *Service) Update(ctx context.Context, c *Customer) error {
+	if err := validate(c); err != nil {
+		return fmt.Errorf("validação do cliente falhou: %w", err)
+	}
+	if _, err := s.Get(ctx, c.ID); err != nil {
+		return err
+	}
+	if err := s.checkDocument(ctx, c.Document, c.ID); err != nil {
+		return err
+	}
+	if err := s.checkPriceTier(ctx, c.PriceTierID); err != nil {
+		return err
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.UpdateTx(ctx, tx, c); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return nil
+}
+
+// Merge une um cadastro duplicado (fromID) ao cliente id: os orçamentos passam para o cliente,
+// endereços e telefones são somados e os campos vazios do cliente são completados com os do
+// duplicado. Documentos diferentes indicam clientes diferentes e não são unidos.
+func (s *Service) Merge(ctx context.Context, id, fromID int) (*Customer, error) {
+	if id == fromID {
+		return nil, apperr.Invalid("informe outro cliente para unir")
+	}
+	target, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.Get(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Document != "" && from.Document != "" && target.Document != from.Document {
+		return nil, apperr.Invalid("clientes %d e %d têm documentos diferentes", id, fromID)
+	}
+	if target.Type != "" && from.Type != "" && target.Type != from.Type {
+		return nil, apperr.Invalid("clientes %d e %d têm tipos de pessoa diferentes", id, fromID)
+	}
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&target.Type, from.Type)
+	fill(&target.Document, from.Document)
+	fill(&target.TradeName, from.TradeName)
+	fill(&target.StateRegistration, from.StateRegistration)
+	fill(&target.Email, from.Email)
+	if target.PriceTierID == nil {
+		target.PriceTierID = from.PriceTierID
+	}
+	target.Addresses = append(target.Addresses, from.Addresses...)
+	target.Phones = append(target.Phones, from.Phones...)
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	// o duplicado sai antes: o documento dele é único e pode passar para o cliente
+	if err := s.repo.MergeTx(ctx, tx, id, fromID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateTx(ctx, tx, target); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// History retorna os orçamentos do cliente e o total comprado (orçamentos convertidos em venda)
+func (s *Service) History(ctx context.Context, id int) (*History, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	budgets, err := s.repo.ListBudgets(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	h := &History{CustomerID: id, Budgets: budgets, BudgetCount: len(budgets)}
+	for _, b := range budgets {
+		if b.Status != budget.StatusConvertido {
+			continue
+		}
+		h.PurchaseCount++
+		h.TotalPurchases += b.Total
+		if b.CreatedAt > h.LastPurchaseAt {
+			h.LastPurchaseAt = b.CreatedAt
+		}
+	}
+	return h, nil
+}
+
+// GetCustomer implementa a interface budget.CustomerReader: ID, nome e se está ativo
+// (nil se o cliente não existe)
+func (s *Service) GetCustomer(ctx context.Context, id int) (*budget.CustomerLite, error) {
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return &budget.CustomerLite{
+		ID:     c.ID,
+		Name:   c.Name,
+		Active: c.Active,
+	}, nil
+}
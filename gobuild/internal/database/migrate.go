@@ -0,0 +1,269 @@
+package database
+
+import (
+	"context"      // para passar contexto nas transações de migração
+	"database/sql" // pacotes padrão para manipulação de banco de dados
+	"embed"        // arquivos .sql embutidos no binário
+	"fmt"          // para formatação de strings e erros
+	"io/fs"        // leitura do diretório embutido
+	"sort"         // ordena as migrações por versão
+	"strconv"      // converte o prefixo numérico do arquivo
+	"strings"      // separa nome/direção do arquivo
+)
+
+// As migrações ficam em migrations/NNNN_descricao.up.sql e NNNN_descricao.down.sql.
+// O número é a versão do schema; cada versão precisa ter os dois arquivos.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration representa uma versão do schema (par up/down)
+type Migration struct {
+	Version int    // número do prefixo do arquivo (0001 -> 1)
+	Name    string // descrição (ex.: "budget_items")
+	Up      string // SQL para aplicar
+	Down    string // SQL para desfazer
+}
+
+// MigrationStatus é a situação de uma migração no banco (usado pelo comando status)
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt string
+}
+
+// loadMigrations lê os arquivos embutidos e devolve as migrações ordenadas por versão
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler migrações embutidas: %v", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		// formato esperado: 0002_budget_items.up.sql
+		name := e.Name()
+		base := strings.TrimSuffix(name, ".sql")
+		direction := ""
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migração %s sem sufixo .up.sql/.down.sql", name)
+		}
+		base = strings.TrimSuffix(base, "."+direction)
+
+		prefix, desc, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migração %s fora do padrão NNNN_descricao", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("versão inválida na migração %s", name)
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler migração %s: %v", name, err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: desc}
+			byVersion[version] = m
+		}
+		if m.Name != desc {
+			return nil, fmt.Errorf("versão %d usada por duas migrações (%s e %s)", version, m.Name, desc)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migração %04d_%s precisa dos arquivos up e down", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// LatestVersion retorna a maior versão de schema conhecida por este binário
+func LatestVersion() (int, error) {
+	list, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(list) == 0 {
+		return 0, nil
+	}
+	return list[len(list)-1].Version, nil
+}
+
+// ensureMigrationsTable cria a tabela de controle se ainda não existir
+func ensureMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela schema_migrations: %v", err)
+	}
+	return nil
+}
+
+// CurrentVersion retorna a maior versão aplicada no banco (0 se nenhuma)
+func CurrentVersion(db *sql.DB) (int, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("erro ao ler versão do schema: %v", err)
+	}
+	return version, nil
+}
+
+// MigrateUp aplica, em ordem, todas as migrações ainda não aplicadas.
+// Retorna quantas foram aplicadas.
+func MigrateUp(db *sql.DB) (int, error) {
+	list, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range list {
+		if m.Version <= current {
+			continue
+		}
+		if err := runMigration(db, m, true); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// MigrateDown desfaz as últimas `steps` migrações aplicadas (da mais nova para a mais antiga).
+// Retorna quantas foram desfeitas.
+func MigrateDown(db *sql.DB, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("número de passos deve ser maior que zero")
+	}
+	list, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	latest := 0
+	if len(list) > 0 {
+		latest = list[len(list)-1].Version
+	}
+	if current > latest {
+		return 0, fmt.Errorf("schema do banco (v%d) é mais novo que este binário (v%d): não há SQL de down disponível", current, latest)
+	}
+
+	reverted := 0
+	for i := len(list) - 1; i >= 0 && reverted < steps; i-- {
+		m := list[i]
+		if m.Version > current {
+			continue
+		}
+		if err := runMigration(db, m, false); err != nil {
+			return reverted, err
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Status lista todas as migrações conhecidas indicando quais já foram aplicadas
+func Status(db *sql.DB) ([]MigrationStatus, error) {
+	list, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	appliedAt := map[int]string{}
+	for rows.Next() {
+		var v int
+		var at string
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, fmt.Errorf("erro ao escanear schema_migrations: %v", err)
+		}
+		appliedAt[v] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração de schema_migrations: %v", err)
+	}
+
+	var status []MigrationStatus
+	for _, m := range list {
+		at, ok := appliedAt[m.Version]
+		status = append(status, MigrationStatus{Version: m.Version, Name: m.Name, Applied: ok, AppliedAt: at})
+	}
+	return status, nil
+}
+
+// runMigration executa o SQL de up ou down e atualiza schema_migrations na mesma transação:
+// ou a migração inteira entra, ou nada muda.
+func runMigration(db *sql.DB, m Migration, up bool) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação da migração %04d: %v", m.Version, err)
+	}
+
+	script, direction := m.Up, "up"
+	if !up {
+		script, direction = m.Down, "down"
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("erro na migração %04d_%s (%s): %v", m.Version, m.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, m.Version)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("erro ao registrar migração %04d: %v", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar migração %04d: %v", m.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS budgets;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS products;
@@ -0,0 +1,30 @@
+-- Esquema inicial (o mesmo que migrate() criava antes do controle de versão).
+-- IF NOT EXISTS mantém compatibilidade com data.db antigos, que já têm essas tabelas.
+
+CREATE TABLE IF NOT EXISTS products (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	price REAL NOT NULL DEFAULT 0,
+	stock REAL NOT NULL DEFAULT 0,
+	unit TEXT NOT NULL,
+	category TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- histórico de movimentações de estoque
+-- - tipo: "Entrada", "Saida", "Ajuste"
+CREATE TABLE IF NOT EXISTS stock_movements (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	tipo TEXT NOT NULL,
+	quantidade REAL NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS budgets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	customer TEXT NOT NULL,
+	total REAL NOT NULL,
+	status TEXT NOT NULL DEFAULT 'ATIVO',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_budget_items_budget;
+DROP TABLE IF EXISTS budget_items;
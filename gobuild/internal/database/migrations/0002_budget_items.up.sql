@@ -0,0 +1,12 @@
+-- itens do orçamento (usados por budget.Repository, nunca haviam sido criados)
+CREATE TABLE IF NOT EXISTS budget_items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	budget_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	product TEXT NOT NULL,
+	quantity REAL NOT NULL,
+	unit_price REAL NOT NULL,
+	subtotal REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_items_budget ON budget_items (budget_id);
@@ -0,0 +1,5 @@
+-- volta para o modelo antigo: ATIVO = estoque baixado, o resto vira CANCELADO
+UPDATE budgets SET status = 'ATIVO' WHERE stock_status = 'BAIXADO';
+UPDATE budgets SET status = 'CANCELADO' WHERE stock_status <> 'BAIXADO';
+
+ALTER TABLE budgets DROP COLUMN stock_status;
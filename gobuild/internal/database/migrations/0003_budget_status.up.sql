@@ -0,0 +1,6 @@
+-- ciclo de vida do orçamento: RASCUNHO, ENVIADO, APROVADO, CONVERTIDO, EXPIRADO, CANCELADO
+-- stock_status guarda o efeito no estoque (NENHUM ou BAIXADO)
+ALTER TABLE budgets ADD COLUMN stock_status TEXT NOT NULL DEFAULT 'NENHUM';
+
+-- orçamentos ATIVO antigos já tinham baixado o estoque na criação
+UPDATE budgets SET status = 'APROVADO', stock_status = 'BAIXADO' WHERE status = 'ATIVO';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_stock_reservations_budget;
+DROP INDEX IF EXISTS idx_stock_reservations_product;
+DROP TABLE IF EXISTS stock_reservations;
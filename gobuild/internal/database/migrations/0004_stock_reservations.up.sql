@@ -0,0 +1,14 @@
+-- reservas de estoque feitas por orçamentos aprovados
+-- status: ATIVA, LIBERADA (cancelado/expirado), CONVERTIDA (virou Saida), EXPIRADA (passou de expires_at)
+CREATE TABLE IF NOT EXISTS stock_reservations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	budget_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	quantity REAL NOT NULL,
+	status TEXT NOT NULL DEFAULT 'ATIVA',
+	expires_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product ON stock_reservations (product_id, status);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_budget ON stock_reservations (budget_id);
@@ -0,0 +1,7 @@
+-- products.stock já guarda o total, então basta descartar os saldos por depósito
+ALTER TABLE budgets DROP COLUMN warehouse_id;
+ALTER TABLE stock_reservations DROP COLUMN warehouse_id;
+ALTER TABLE stock_movements DROP COLUMN warehouse_id;
+
+DROP TABLE IF EXISTS stock_balances;
+DROP TABLE IF EXISTS warehouses;
@@ -0,0 +1,27 @@
+-- depósitos (loja, pátio, ...) e saldo de estoque por depósito
+-- products.stock continua existindo como o total somado de todos os depósitos
+CREATE TABLE IF NOT EXISTS warehouses (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	address TEXT,
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- depósito padrão (id 1): recebe o estoque que já existia
+INSERT INTO warehouses (id, name) VALUES (1, 'Loja');
+
+CREATE TABLE IF NOT EXISTS stock_balances (
+	warehouse_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	quantity REAL NOT NULL DEFAULT 0,
+	PRIMARY KEY (warehouse_id, product_id)
+);
+
+INSERT INTO stock_balances (warehouse_id, product_id, quantity)
+SELECT 1, id, stock FROM products;
+
+-- todo movimento, reserva e orçamento passa a ter um depósito (os antigos ficam no padrão)
+ALTER TABLE stock_movements ADD COLUMN warehouse_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE stock_reservations ADD COLUMN warehouse_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE budgets ADD COLUMN warehouse_id INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_movement_lots;
+DROP INDEX IF EXISTS idx_stock_lots_expires;
+DROP TABLE IF EXISTS stock_lots;
@@ -0,0 +1,22 @@
+-- lotes com validade (cimento, argamassa, rejunte...), por produto e depósito
+-- expires_at é TEXT no formato YYYY-MM-DD (NULL = lote sem validade)
+CREATE TABLE IF NOT EXISTS stock_lots (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	warehouse_id INTEGER NOT NULL,
+	lot TEXT NOT NULL,
+	expires_at TEXT,
+	quantity REAL NOT NULL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (product_id, warehouse_id, lot)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_lots_expires ON stock_lots (expires_at);
+
+-- quanto de cada lote um movimento movimentou (Entrada em um lote, Saida FEFO em vários)
+CREATE TABLE IF NOT EXISTS stock_movement_lots (
+	movement_id INTEGER NOT NULL,
+	lot_id INTEGER NOT NULL,
+	quantity REAL NOT NULL,
+	PRIMARY KEY (movement_id, lot_id)
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS inventory_count_entries;
+DROP TABLE IF EXISTS inventory_count_items;
+DROP TABLE IF EXISTS inventory_counts;
+
+ALTER TABLE stock_movements DROP COLUMN delta;
+ALTER TABLE stock_movements DROP COLUMN previous_quantity;
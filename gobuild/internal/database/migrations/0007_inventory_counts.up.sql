@@ -0,0 +1,37 @@
+-- todo movimento passa a registrar o saldo do depósito antes dele e a diferença aplicada
+-- (no Ajuste é o que mostra quanto havia e quanto foi corrigido); antigos ficam NULL
+ALTER TABLE stock_movements ADD COLUMN previous_quantity REAL;
+ALTER TABLE stock_movements ADD COLUMN delta REAL;
+
+-- sessões de inventário (contagem física) de um depósito, opcionalmente só de uma categoria
+CREATE TABLE IF NOT EXISTS inventory_counts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	warehouse_id INTEGER NOT NULL DEFAULT 1,
+	category TEXT,
+	status TEXT NOT NULL DEFAULT 'ABERTA',
+	start_movement_id INTEGER NOT NULL DEFAULT 0,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	closed_at DATETIME
+);
+
+-- produtos da contagem com o saldo esperado e o preço congelados na abertura
+CREATE TABLE IF NOT EXISTS inventory_count_items (
+	count_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	expected REAL NOT NULL DEFAULT 0,
+	price REAL NOT NULL DEFAULT 0,
+	moved INTEGER NOT NULL DEFAULT 0,
+	movement_id INTEGER,
+	PRIMARY KEY (count_id, product_id)
+);
+
+-- quantidades contadas: uma linha por contador e produto (a contada é a soma dos contadores)
+CREATE TABLE IF NOT EXISTS inventory_count_entries (
+	count_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	counter TEXT NOT NULL,
+	quantity REAL NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (count_id, product_id, counter)
+);
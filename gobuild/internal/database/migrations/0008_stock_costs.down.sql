@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS stock_cost_consumptions;
+DROP INDEX IF EXISTS idx_stock_cost_layers_product;
+DROP TABLE IF EXISTS stock_cost_layers;
+
+ALTER TABLE stock_movements DROP COLUMN average_cost;
+ALTER TABLE stock_movements DROP COLUMN cost_fifo;
+ALTER TABLE stock_movements DROP COLUMN cost_average;
+ALTER TABLE stock_movements DROP COLUMN unit_cost;
+
+ALTER TABLE products DROP COLUMN average_cost;
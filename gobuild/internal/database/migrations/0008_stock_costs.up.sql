@@ -0,0 +1,35 @@
+-- custo do estoque: custo médio ponderado por produto e camadas FIFO
+ALTER TABLE products ADD COLUMN average_cost REAL NOT NULL DEFAULT 0;
+
+-- custo de cada movimento: unit_cost é o custo unitário informado na entrada (ou o aplicado
+-- na saída), cost_average/cost_fifo o custo total pelos dois métodos (na Saida é o CMV)
+-- e average_cost o custo médio do produto depois do movimento
+ALTER TABLE stock_movements ADD COLUMN unit_cost REAL;
+ALTER TABLE stock_movements ADD COLUMN cost_average REAL;
+ALTER TABLE stock_movements ADD COLUMN cost_fifo REAL;
+ALTER TABLE stock_movements ADD COLUMN average_cost REAL;
+
+-- camadas FIFO: cada entrada abre uma camada com o custo dela; saídas consomem da mais antiga
+CREATE TABLE IF NOT EXISTS stock_cost_layers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	movement_id INTEGER,
+	unit_cost REAL NOT NULL DEFAULT 0,
+	quantity REAL NOT NULL,
+	remaining REAL NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_cost_layers_product ON stock_cost_layers (product_id, id);
+
+-- quanto de cada camada um movimento consumiu (permite avaliar o estoque em datas passadas)
+CREATE TABLE IF NOT EXISTS stock_cost_consumptions (
+	movement_id INTEGER NOT NULL,
+	layer_id INTEGER NOT NULL,
+	quantity REAL NOT NULL,
+	PRIMARY KEY (movement_id, layer_id)
+);
+
+-- o estoque que já existia vira uma camada de custo desconhecido (zero)
+INSERT INTO stock_cost_layers (product_id, unit_cost, quantity, remaining)
+SELECT id, 0, stock, stock FROM products WHERE stock > 0;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_stock_movements_document;
+DROP INDEX IF EXISTS idx_stock_movements_reason;
+
+ALTER TABLE stock_movements DROP COLUMN notes;
+ALTER TABLE stock_movements DROP COLUMN document;
+ALTER TABLE stock_movements DROP COLUMN reason;
@@ -0,0 +1,9 @@
+-- motivo (lista controlada: COMPRA, VENDA, DEVOLUCAO, QUEBRA, PERDA, TRANSFERENCIA, INVENTARIO),
+-- documento de origem (ex.: "nf:12345", "orcamento:7") e observação livre de cada movimento.
+-- Nulos nos movimentos anteriores a esta migração.
+ALTER TABLE stock_movements ADD COLUMN reason TEXT;
+ALTER TABLE stock_movements ADD COLUMN document TEXT;
+ALTER TABLE stock_movements ADD COLUMN notes TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_reason ON stock_movements (product_id, reason);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_document ON stock_movements (document);
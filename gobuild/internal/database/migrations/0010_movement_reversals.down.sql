@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_stock_movements_reversal_of;
+
+ALTER TABLE stock_movements DROP COLUMN reversal_of;
@@ -0,0 +1,5 @@
+-- estorno: o movimento compensatório aponta para o movimento estornado
+-- (uma saída estornada com vários lotes gera uma entrada por lote, todas com o mesmo reversal_of)
+ALTER TABLE stock_movements ADD COLUMN reversal_of INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_reversal_of ON stock_movements (reversal_of);
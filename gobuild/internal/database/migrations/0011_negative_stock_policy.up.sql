@@ -0,0 +1,16 @@
+-- política de estoque negativo: BLOQUEAR, PERMITIR ou AVISAR (permite e avisa na resposta).
+-- Vale a regra mais específica: PRODUTO (ref = id do produto) > CATEGORIA (ref = categoria) > GLOBAL.
+CREATE TABLE IF NOT EXISTS stock_negative_policies (
+	scope TEXT NOT NULL,
+	ref TEXT NOT NULL DEFAULT '',
+	policy TEXT NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (scope, ref)
+);
+
+-- política global padrão: BLOQUEAR (saída sem saldo responde 409).
+-- Banco atualizado que já tem saldo negativo em algum depósito começa em AVISAR, para o balcão não
+-- travar no dia da atualização; depois de acertar os saldos, troque para BLOQUEAR em
+-- PUT /api/admin/stock/negative-policy.
+INSERT OR IGNORE INTO stock_negative_policies (scope, ref, policy)
+SELECT 'GLOBAL', '', CASE WHEN EXISTS (SELECT 1 FROM stock_balances WHERE quantity < 0) THEN 'AVISAR' ELSE 'BLOQUEAR' END;
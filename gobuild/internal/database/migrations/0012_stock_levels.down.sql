@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_stock_alert_events_product;
+DROP TABLE IF EXISTS stock_alert_events;
+
+ALTER TABLE products DROP COLUMN reorder_point;
+ALTER TABLE products DROP COLUMN max_stock;
+ALTER TABLE products DROP COLUMN min_stock;
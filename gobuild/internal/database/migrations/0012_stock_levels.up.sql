@@ -0,0 +1,20 @@
+-- níveis de estoque do produto (0 = não configurado): mínimo (estoque de segurança),
+-- máximo (até onde repor) e ponto de pedido (abaixo dele o produto entra nos alertas)
+ALTER TABLE products ADD COLUMN min_stock REAL NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN max_stock REAL NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN reorder_point REAL NOT NULL DEFAULT 0;
+
+-- eventos de alerta: cada Saida que leva o estoque do produto ao ponto de pedido (ou abaixo)
+-- grava um evento na mesma transação do movimento
+CREATE TABLE IF NOT EXISTS stock_alert_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	movement_id INTEGER NOT NULL,
+	previous_stock REAL NOT NULL,
+	stock REAL NOT NULL,
+	reorder_point REAL NOT NULL,
+	suggested_quantity REAL NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_alert_events_product ON stock_alert_events (product_id, id);
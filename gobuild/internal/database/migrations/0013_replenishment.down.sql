@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_stock_movements_tipo_created;
+
+ALTER TABLE products DROP COLUMN lead_time_days;
@@ -0,0 +1,5 @@
+-- prazo de entrega do fornecedor em dias (0 = usar o padrão do relatório de reposição)
+ALTER TABLE products ADD COLUMN lead_time_days INTEGER NOT NULL DEFAULT 0;
+
+-- o relatório de reposição lê as Saidas por período
+CREATE INDEX IF NOT EXISTS idx_stock_movements_tipo_created ON stock_movements (tipo, created_at);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_purchase_order_receipts_order;
+DROP TABLE IF EXISTS purchase_order_receipts;
+DROP TABLE IF EXISTS purchase_order_items;
+DROP INDEX IF EXISTS idx_purchase_orders_supplier;
+DROP TABLE IF EXISTS purchase_orders;
+
+DROP INDEX IF EXISTS idx_product_suppliers_code;
+DROP INDEX IF EXISTS idx_product_suppliers_product;
+DROP TABLE IF EXISTS product_suppliers;
+
+DROP INDEX IF EXISTS idx_supplier_contacts_supplier;
+DROP TABLE IF EXISTS supplier_contacts;
+DROP TABLE IF EXISTS suppliers;
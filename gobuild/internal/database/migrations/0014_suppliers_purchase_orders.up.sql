@@ -0,0 +1,77 @@
+-- fornecedores (CNPJ só com dígitos) e seus contatos
+CREATE TABLE IF NOT EXISTS suppliers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	trade_name TEXT,
+	cnpj TEXT NOT NULL UNIQUE,
+	email TEXT,
+	phone TEXT,
+	lead_time_days INTEGER NOT NULL DEFAULT 0,
+	notes TEXT,
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS supplier_contacts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	supplier_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	role TEXT,
+	email TEXT,
+	phone TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_supplier_contacts_supplier ON supplier_contacts (supplier_id);
+
+-- produtos que cada fornecedor vende: código do produto no fornecedor e último custo pago
+-- (atualizado pelo recebimento dos pedidos de compra)
+CREATE TABLE IF NOT EXISTS product_suppliers (
+	supplier_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	supplier_code TEXT,
+	last_cost REAL,
+	last_purchase_at DATETIME,
+	PRIMARY KEY (supplier_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_suppliers_product ON product_suppliers (product_id);
+CREATE INDEX IF NOT EXISTS idx_product_suppliers_code ON product_suppliers (supplier_id, supplier_code);
+
+-- pedidos de compra: ABERTO -> PARCIALMENTE_RECEBIDO -> RECEBIDO, ou CANCELADO
+CREATE TABLE IF NOT EXISTS purchase_orders (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	supplier_id INTEGER NOT NULL,
+	warehouse_id INTEGER NOT NULL DEFAULT 1,
+	status TEXT NOT NULL DEFAULT 'ABERTO',
+	expected_at DATE,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders (supplier_id, status);
+
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+	order_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	supplier_code TEXT,
+	quantity REAL NOT NULL,
+	received REAL NOT NULL DEFAULT 0,
+	unit_cost REAL NOT NULL,
+	PRIMARY KEY (order_id, product_id)
+);
+
+-- cada recebimento (total ou parcial) de um item, com a Entrada postada no estoque
+CREATE TABLE IF NOT EXISTS purchase_order_receipts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	order_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	warehouse_id INTEGER NOT NULL,
+	quantity REAL NOT NULL,
+	unit_cost REAL NOT NULL,
+	movement_id INTEGER NOT NULL,
+	invoice TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_order_receipts_order ON purchase_order_receipts (order_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS nfe_import_items;
+DROP INDEX IF EXISTS ux_nfe_imports_access_key;
+DROP TABLE IF EXISTS nfe_imports;
+
+DROP INDEX IF EXISTS idx_products_ean;
+ALTER TABLE products DROP COLUMN ean;
@@ -0,0 +1,45 @@
+-- código de barras (EAN/GTIN) do produto: casa os itens da NF-e quando o fornecedor não
+-- tem o código vinculado
+ALTER TABLE products ADD COLUMN ean TEXT;
+CREATE INDEX IF NOT EXISTS idx_products_ean ON products (ean);
+
+-- NF-e de fornecedor importada: PENDENTE (itens sendo casados com produtos) -> CONFIRMADA
+-- (Entradas postadas) ou CANCELADA. A mesma chave de acesso só pode ser importada uma vez
+-- (uma importação cancelada libera a chave).
+CREATE TABLE IF NOT EXISTS nfe_imports (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	access_key TEXT NOT NULL,
+	number TEXT,
+	series TEXT,
+	issued_at TEXT,
+	supplier_cnpj TEXT,
+	supplier_name TEXT,
+	supplier_id INTEGER,
+	total REAL NOT NULL DEFAULT 0,
+	status TEXT NOT NULL DEFAULT 'PENDENTE',
+	warehouse_id INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	confirmed_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_nfe_imports_access_key ON nfe_imports (access_key) WHERE status <> 'CANCELADA';
+
+-- itens da nota (det) e o produto casado: pelo código no fornecedor, pelo EAN ou manual
+CREATE TABLE IF NOT EXISTS nfe_import_items (
+	import_id INTEGER NOT NULL,
+	line INTEGER NOT NULL,
+	supplier_code TEXT,
+	ean TEXT,
+	description TEXT,
+	ncm TEXT,
+	cfop TEXT,
+	unit TEXT,
+	quantity REAL NOT NULL,
+	unit_cost REAL NOT NULL,
+	total REAL NOT NULL,
+	product_id INTEGER,
+	match TEXT,
+	ignored INTEGER NOT NULL DEFAULT 0,
+	movement_id INTEGER,
+	PRIMARY KEY (import_id, line)
+);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_budget_rentals_budget;
+DROP TABLE IF EXISTS budget_rentals;
+
+DROP INDEX IF EXISTS idx_rental_returns_contract;
+DROP TABLE IF EXISTS rental_returns;
+DROP INDEX IF EXISTS idx_rental_contract_items_asset;
+DROP TABLE IF EXISTS rental_contract_items;
+DROP INDEX IF EXISTS idx_rental_contracts_budget;
+DROP INDEX IF EXISTS idx_rental_contracts_status;
+DROP TABLE IF EXISTS rental_contracts;
+
+DROP TABLE IF EXISTS rental_assets;
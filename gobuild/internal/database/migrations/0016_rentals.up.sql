@@ -0,0 +1,81 @@
+-- equipamentos para locação (betoneira, andaime, escora): quantity é o total de unidades do
+-- patrimônio; o disponível é calculado pelos contratos em andamento.
+-- weekly_rate / monthly_rate nulos = equipamento não é alugado por semana / mês
+CREATE TABLE IF NOT EXISTS rental_assets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	code TEXT UNIQUE,
+	category TEXT,
+	quantity INTEGER NOT NULL DEFAULT 1,
+	daily_rate REAL NOT NULL,
+	weekly_rate REAL,
+	monthly_rate REAL,
+	notes TEXT,
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- contratos de locação: RESERVADO -> EM_ANDAMENTO (retirada) -> DEVOLVIDO, ou CANCELADO
+CREATE TABLE IF NOT EXISTS rental_contracts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	customer TEXT NOT NULL,
+	budget_id INTEGER,
+	status TEXT NOT NULL DEFAULT 'RESERVADO',
+	start_date DATE NOT NULL,
+	expected_return DATE NOT NULL,
+	late_fee_percent REAL NOT NULL DEFAULT 0,
+	notes TEXT,
+	checked_out_at DATETIME,
+	closed_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rental_contracts_status ON rental_contracts (status);
+CREATE INDEX IF NOT EXISTS idx_rental_contracts_budget ON rental_contracts (budget_id);
+
+-- equipamentos do contrato: período (DIARIA, SEMANAL, MENSAL) e preço combinados;
+-- daily_rate é a diária usada no cálculo do atraso
+CREATE TABLE IF NOT EXISTS rental_contract_items (
+	contract_id INTEGER NOT NULL,
+	asset_id INTEGER NOT NULL,
+	quantity INTEGER NOT NULL,
+	returned INTEGER NOT NULL DEFAULT 0,
+	period TEXT NOT NULL,
+	rate REAL NOT NULL,
+	daily_rate REAL NOT NULL,
+	PRIMARY KEY (contract_id, asset_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rental_contract_items_asset ON rental_contract_items (asset_id);
+
+-- cada devolução (total ou parcial) com o valor cobrado: locação + atraso
+CREATE TABLE IF NOT EXISTS rental_returns (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	contract_id INTEGER NOT NULL,
+	asset_id INTEGER NOT NULL,
+	quantity INTEGER NOT NULL,
+	returned_at DATE NOT NULL,
+	days INTEGER NOT NULL,
+	late_days INTEGER NOT NULL DEFAULT 0,
+	rental_amount REAL NOT NULL,
+	late_fee REAL NOT NULL DEFAULT 0,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_rental_returns_contract ON rental_returns (contract_id);
+
+-- linhas de locação do orçamento (cotação: quantidade x períodos x preço do período)
+CREATE TABLE IF NOT EXISTS budget_rentals (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	budget_id INTEGER NOT NULL,
+	asset_id INTEGER NOT NULL,
+	asset TEXT NOT NULL,
+	quantity INTEGER NOT NULL,
+	period TEXT NOT NULL,
+	periods INTEGER NOT NULL,
+	rate REAL NOT NULL,
+	subtotal REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_rentals_budget ON budget_rentals (budget_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_budgets_customer;
+ALTER TABLE budgets DROP COLUMN customer_id;
+
+DROP INDEX IF EXISTS idx_customer_phones_customer;
+DROP TABLE IF EXISTS customer_phones;
+DROP INDEX IF EXISTS idx_customer_addresses_customer;
+DROP TABLE IF EXISTS customer_addresses;
+DROP INDEX IF EXISTS idx_customers_name;
+DROP TABLE IF EXISTS customers;
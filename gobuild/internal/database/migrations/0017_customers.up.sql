@@ -0,0 +1,60 @@
+-- clientes: pessoa física (CPF) ou jurídica (CNPJ); documento só com os dígitos.
+-- type e document ficam nulos nos clientes migrados dos orçamentos até o cadastro ser completado
+CREATE TABLE IF NOT EXISTS customers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT,
+	name TEXT NOT NULL,
+	trade_name TEXT,
+	document TEXT UNIQUE,
+	state_registration TEXT,
+	email TEXT,
+	notes TEXT,
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customers_name ON customers (name);
+
+CREATE TABLE IF NOT EXISTS customer_addresses (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	customer_id INTEGER NOT NULL,
+	label TEXT,
+	street TEXT NOT NULL,
+	number TEXT,
+	complement TEXT,
+	district TEXT,
+	city TEXT NOT NULL,
+	state TEXT NOT NULL,
+	zip TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_addresses_customer ON customer_addresses (customer_id);
+
+CREATE TABLE IF NOT EXISTS customer_phones (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	customer_id INTEGER NOT NULL,
+	number TEXT NOT NULL,
+	label TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_phones_customer ON customer_phones (customer_id);
+
+-- orçamento passa a apontar para o cliente; budgets.customer fica como o nome na época
+ALTER TABLE budgets ADD COLUMN customer_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_budgets_customer ON budgets (customer_id);
+
+-- migra os nomes digitados nos orçamentos: um cliente por nome (sem diferenciar maiúsculas
+-- nem espaços nas pontas). Grafias diferentes do mesmo cliente são unidas depois pelo
+-- POST /api/customers/:id/merge.
+INSERT INTO customers (name, notes)
+SELECT MIN(TRIM(customer)), 'migrado dos orçamentos'
+FROM budgets
+WHERE TRIM(COALESCE(customer, '')) <> ''
+GROUP BY LOWER(TRIM(customer));
+
+UPDATE budgets SET customer_id = (
+	SELECT c.id FROM customers c
+	WHERE c.notes = 'migrado dos orçamentos' AND LOWER(c.name) = LOWER(TRIM(budgets.customer))
+)
+WHERE customer_id IS NULL AND TRIM(COALESCE(customer, '')) <> '';
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS budget_settings;
+
+ALTER TABLE budgets DROP COLUMN discount_approved_at;
+ALTER TABLE budgets DROP COLUMN discount_approved_by;
+ALTER TABLE budgets DROP COLUMN discount_status;
+ALTER TABLE budgets DROP COLUMN other_charges;
+ALTER TABLE budgets DROP COLUMN freight;
+ALTER TABLE budgets DROP COLUMN discount;
+ALTER TABLE budgets DROP COLUMN discount_percent;
+ALTER TABLE budgets DROP COLUMN items_discount;
+ALTER TABLE budgets DROP COLUMN gross;
+
+ALTER TABLE budget_items DROP COLUMN discount;
+ALTER TABLE budget_items DROP COLUMN discount_percent;
@@ -0,0 +1,28 @@
+-- descontos do item: percentual informado e o valor (subtotal = quantidade x preço - desconto)
+ALTER TABLE budget_items ADD COLUMN discount_percent REAL NOT NULL DEFAULT 0;
+ALTER TABLE budget_items ADD COLUMN discount REAL NOT NULL DEFAULT 0;
+
+-- composição do orçamento: bruto - descontos dos itens - desconto geral + frete + outras despesas = total
+ALTER TABLE budgets ADD COLUMN gross REAL NOT NULL DEFAULT 0;
+ALTER TABLE budgets ADD COLUMN items_discount REAL NOT NULL DEFAULT 0;
+ALTER TABLE budgets ADD COLUMN discount_percent REAL NOT NULL DEFAULT 0;
+ALTER TABLE budgets ADD COLUMN discount REAL NOT NULL DEFAULT 0;
+ALTER TABLE budgets ADD COLUMN freight REAL NOT NULL DEFAULT 0;
+ALTER TABLE budgets ADD COLUMN other_charges REAL NOT NULL DEFAULT 0;
+
+-- desconto acima do limite fica PENDENTE até o gerente aprovar (NENHUM = dentro do limite)
+ALTER TABLE budgets ADD COLUMN discount_status TEXT NOT NULL DEFAULT 'NENHUM';
+ALTER TABLE budgets ADD COLUMN discount_approved_by TEXT;
+ALTER TABLE budgets ADD COLUMN discount_approved_at DATETIME;
+
+-- orçamentos antigos não têm desconto: o bruto é o próprio total
+UPDATE budgets SET gross = total;
+
+-- configuração dos orçamentos (uma linha só): limite de desconto sem aprovação do gerente
+CREATE TABLE IF NOT EXISTS budget_settings (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	max_discount_percent REAL NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO budget_settings (id, max_discount_percent) VALUES (1, 5);
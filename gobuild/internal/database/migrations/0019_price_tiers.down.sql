@@ -0,0 +1,5 @@
+ALTER TABLE budget_items DROP COLUMN price_list;
+ALTER TABLE customers DROP COLUMN price_tier_id;
+
+DROP TABLE IF EXISTS product_price_breaks;
+DROP TABLE IF EXISTS price_tiers;
@@ -0,0 +1,30 @@
+-- tabelas de preço (varejo, atacado, construtora...); o cliente aponta para uma tabela
+CREATE TABLE IF NOT EXISTS price_tiers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	description TEXT,
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO price_tiers (name, description) VALUES
+	('varejo', 'preço de balcão (preço de cadastro do produto)'),
+	('atacado', 'revenda e compras em volume'),
+	('construtora', 'construtoras cadastradas');
+
+-- faixas de preço por produto: a partir de min_quantity unidades o preço é price.
+-- tier_id = 0 vale para todos os clientes (ex.: preço do palete); senão só para a tabela.
+CREATE TABLE IF NOT EXISTS product_price_breaks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	tier_id INTEGER NOT NULL DEFAULT 0,
+	min_quantity REAL NOT NULL,
+	price REAL NOT NULL,
+	UNIQUE (product_id, tier_id, min_quantity)
+);
+
+-- tabela de preço do cliente (nula = preço de cadastro do produto)
+ALTER TABLE customers ADD COLUMN price_tier_id INTEGER;
+
+-- tabela/faixa que deu o preço do item do orçamento (nula = preço de cadastro)
+ALTER TABLE budget_items ADD COLUMN price_list TEXT;
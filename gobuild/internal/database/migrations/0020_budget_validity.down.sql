@@ -0,0 +1,4 @@
+ALTER TABLE budget_settings DROP COLUMN validity_days;
+
+DROP INDEX IF EXISTS idx_budgets_valid_until;
+ALTER TABLE budgets DROP COLUMN valid_until;
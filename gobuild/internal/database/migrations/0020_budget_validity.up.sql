@@ -0,0 +1,8 @@
+-- validade do orçamento (AAAA-MM-DD, vale até o fim do dia); nula = sem validade.
+-- Orçamentos antigos ficam sem validade para não expirarem todos de uma vez.
+ALTER TABLE budgets ADD COLUMN valid_until DATE;
+
+CREATE INDEX IF NOT EXISTS idx_budgets_valid_until ON budgets (status, valid_until);
+
+-- validade padrão (dias a partir da criação) quando o orçamento não informa valid_until
+ALTER TABLE budget_settings ADD COLUMN validity_days INTEGER NOT NULL DEFAULT 7;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS sale_installments;
+DROP INDEX IF EXISTS idx_sale_items_sale;
+DROP TABLE IF EXISTS sale_items;
+DROP INDEX IF EXISTS idx_sales_customer;
+DROP TABLE IF EXISTS sales;
@@ -0,0 +1,45 @@
+-- vendas: criadas a partir de um orçamento aprovado (um orçamento gera no máximo uma venda).
+-- Itens e valores são uma cópia do orçamento no momento da venda.
+CREATE TABLE IF NOT EXISTS sales (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	budget_id INTEGER NOT NULL UNIQUE,
+	customer_id INTEGER,
+	customer TEXT NOT NULL,
+	warehouse_id INTEGER NOT NULL,
+	gross REAL NOT NULL,
+	items_discount REAL NOT NULL DEFAULT 0,
+	discount REAL NOT NULL DEFAULT 0,
+	freight REAL NOT NULL DEFAULT 0,
+	other_charges REAL NOT NULL DEFAULT 0,
+	total REAL NOT NULL,
+	payment_method TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'CONCLUIDA',
+	notes TEXT,
+	cancel_reason TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	cancelled_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_customer ON sales (customer_id);
+
+CREATE TABLE IF NOT EXISTS sale_items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	sale_id INTEGER NOT NULL,
+	product_id INTEGER NOT NULL,
+	product TEXT NOT NULL,
+	quantity REAL NOT NULL,
+	unit_price REAL NOT NULL,
+	discount REAL NOT NULL DEFAULT 0,
+	subtotal REAL NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_items_sale ON sale_items (sale_id);
+
+-- parcelas do pagamento (à vista = uma parcela com vencimento na data da venda)
+CREATE TABLE IF NOT EXISTS sale_installments (
+	sale_id INTEGER NOT NULL,
+	number INTEGER NOT NULL,
+	due_date DATE NOT NULL,
+	amount REAL NOT NULL,
+	PRIMARY KEY (sale_id, number)
+);
@@ -0,0 +1 @@
+ALTER TABLE inventory_count_items DROP COLUMN unit_cost;
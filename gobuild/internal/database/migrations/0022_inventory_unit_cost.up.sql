@@ -0,0 +1,7 @@
+-- custo unitário (custo médio do estoque) usado para valorizar a diferença do item, gravado no
+-- fechamento; nulo enquanto a contagem está aberta (o relatório usa o custo médio do momento).
+-- Contagens já fechadas recebem o custo médio atual do produto.
+ALTER TABLE inventory_count_items ADD COLUMN unit_cost REAL;
+
+UPDATE inventory_count_items SET unit_cost = (SELECT average_cost FROM products WHERE products.id = inventory_count_items.product_id)
+WHERE count_id IN (SELECT id FROM inventory_counts WHERE status <> 'ABERTA');
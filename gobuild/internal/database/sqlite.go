@@ -3,99 +3,82 @@ package database
 import (
 	"database/sql" // pacotes padrão para manipulação de banco de dados
 	"fmt"          //para formtação de strings e erros
-	"time"         // para manipulação de tempo
 
 	_ "github.com/mattn/go-sqlite3" // driver SQLite (import por side effect)
 )
 
 var DB *sql.DB // Variável global para o banco de dados
 
+// DefaultPath é o arquivo SQLite usado pela aplicação
+const DefaultPath = "data.db"
+
 // conecta (abre/gera) o arquivo do banco de dados SQLite
 func Connect() error {
+	return ConnectPath(DefaultPath)
+}
 
+// ConnectPath abre (ou cria) o banco no caminho informado e executa as migrações.
+// Útil para testes, que usam um arquivo temporário em vez do data.db.
+//
+// Recusa subir se o banco estiver numa versão de schema mais nova que a deste binário
+// (ex.: data.db migrado por uma versão mais recente da aplicação).
+func ConnectPath(path string) error {
 
 	//Abre ou cria o arquivo do banco de dados SQLite
-	db, err := sql.Open("sqlite3", "data.db")
+	db, err := Open(path)
 	if err != nil {
-		return fmt.Errorf("erro ao conectar ao banco de dados: %v", err)
+		return err
 	}
 
-	// Verifica a conexão
-	if err := db.Ping(); err != nil {
-		// fecha o handle antes de retornar o erro (boa prática)
+	// confere a versão do schema antes de tocar em qualquer tabela
+	current, err := CurrentVersion(db)
+	if err != nil {
 		_ = db.Close()
-		return fmt.Errorf("erro ao verificar a conexão com o banco de dados: %v", err)
+		return err
+	}
+	latest, err := LatestVersion()
+	if err != nil {
+		_ = db.Close()
+		return err
+	}
+	if current > latest {
+		_ = db.Close()
+		return fmt.Errorf("schema do banco (v%d) é mais novo que este binário (v%d); atualize a aplicação", current, latest)
 	}
 
-	DB = db // Atribui a conexão à variável global
-
-	//executa  migrações iniciais (criação de tabelas se não existirem)
-	if err := migrate(); err != nil {
-	_ = DB.Close() // em caso de erro, fecha a conexão
-	DB = nil      // zera a variável global
-	return fmt.Errorf("erro ao executar migrações: %v", err)
+	//executa as migrações pendentes (criação/alteração de tabelas)
+	if _, err := MigrateUp(db); err != nil {
+		_ = db.Close() // em caso de erro, fecha a conexão
+		return fmt.Errorf("erro ao executar migrações: %v", err)
 	}
 
+	DB = db // Atribui a conexão à variável global
+
 	// mensagem de sucesso (log/feedback)
 	fmt.Println("SQLite conectado com sucesso!")
 
 	return nil
 }
 
-// migrate executa SQL de criação de tabelas iniciais
-// inclui a tabela products (se ainda não existir) e a nova tabela stock_movements
-func migrate() error {
-	//schemaProducts para tabela products (matenmos compatibilidade com o que já usado)
-	schemaProducts := ` 
-	CREATE TABLE IF NOT EXISTS products (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		price REAL NOT NULL DEFAULT 0,
-		stock REAL NOT NULL DEFAULT 0,
-		unit TEXT NOT NULL,
-		category TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP	
-	);
-	`
-	// Schema para a tebela stock_movements (nova tabela para histórico de movimentações de estoque)
-	// - product_id: referencia ao produto (não há FK forçada aqui para simplicidade)
-	// - tipo: "Entrada", "Saida", "ajuste"
-	// - quantidade: número (pode ser decimal)
-	// - created_at: timestamp automático
-	schemaStock := `
-	CREATE TABLE IF NOT EXISTS stock_movements (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		product_id INTEGER NOT NULL,
-		tipo TEXT NOT NULL,
-		quantidade REAL NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	// schema budget com status (adicionado campo status)
-	schemaBudget := `
-	CREATE TABLE IF NOT EXISTS budgets (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		customer TEXT NOT NULL,
-		total REAL NOT NULL,
-		status TEXT NOT NULL DEFAULT 'ATIVO',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	// execução da query de criação da tabela no DB.
-	if _, err := DB.Exec(schemaProducts); err != nil {
-		return fmt.Errorf("erro ao criar tabela products: %v", err)
-	}
-	if _, err := DB.Exec(schemaStock); err != nil {			
-		return fmt.Errorf("erro ao criar tabela stock_movements: %v", err)
-	}
-	if _, err := DB.Exec(schemaBudget); err != nil {			
-		return fmt.Errorf("erro ao criar tabela budgets: %v", err)
+// Open abre o arquivo SQLite e verifica a conexão, sem executar migrações
+// (usado diretamente pelo comando cmd/migrate).
+//
+// Parâmetros do driver:
+//   - _txlock=immediate: todo BEGIN já reserva a escrita, então transações concorrentes
+//     (ex.: duas saídas do mesmo produto) são serializadas em vez de lerem o mesmo estoque;
+//   - _busy_timeout=5000: quem chega depois espera até 5s pelo lock em vez de falhar com SQLITE_BUSY.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_txlock=immediate&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %v", err)
 	}
 
-	// exemplo opcional: podemos inserir um registro inicial se quisermos (comentei).
-	_ = time.Now() // usado se quisermos logs de timestamp; mantido para referencia futura.
+	// Verifica a conexão
+	if err := db.Ping(); err != nil {
+		// fecha o handle antes de retornar o erro (boa prática)
+		_ = db.Close()
+		return nil, fmt.Errorf("erro ao verificar a conexão com o banco de dados: %v", err)
+	}
 
-	return nil
+	return db, nil
 }
@@ -0,0 +1,140 @@
+package inventory
+
+import (
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// Handler expõe os endpoints HTTP de contagem de inventário
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de inventário no grupo Echo (ex.: /api/inventory)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("", h.Open)
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.POST("/:id/counts", h.Record)
+	g.GET("/:id/report", h.Report)
+	g.POST("/:id/close", h.Close)
+	g.POST("/:id/cancel", h.Cancel)
+}
+
+// OpenCountRequest abre uma contagem. Ex.: {"warehouse_id": 1, "category": "Materiais", "notes": "inventário anual"}
+// warehouse_id é opcional (padrão: depósito 1); sem category entram todos os produtos
+type OpenCountRequest struct {
+	WarehouseID int    `json:"warehouse_id"`
+	Category    string `json:"category"`
+	Notes       string `json:"notes"`
+}
+
+// RecordRequest traz o que um contador contou. Ex.: {"counter": "João", "items": [{"product_id": 1, "quantity": 48}]}
+type RecordRequest struct {
+	Counter string        `json:"counter"`
+	Items   []CountedItem `json:"items"`
+}
+
+// CountedItem é a quantidade contada de um produto
+type CountedItem struct {
+	ProductID int     `json:"product_id"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// Open abre uma contagem
+func (h *Handler) Open(c echo.Context) error {
+	var req OpenCountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	count, err := h.svc.Open(c.Request().Context(), req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, count)
+}
+
+// List retorna as contagens
+func (h *Handler) List(c echo.Context) error {
+	list, err := h.svc.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// Get retorna a contagem com os itens
+func (h *Handler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	count, err := h.svc.Get(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, count)
+}
+
+// Record registra as quantidades contadas por um contador
+func (h *Handler) Record(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req RecordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	count, err := h.svc.Record(c.Request().Context(), id, req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, count)
+}
+
+// Report retorna o relatório de divergências da contagem
+func (h *Handler) Report(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	report, err := h.svc.Report(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// Close fecha a contagem e posta os ajustes
+func (h *Handler) Close(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	report, err := h.svc.Close(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// Cancel cancela a contagem sem mexer no estoque
+func (h *Handler) Cancel(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	if err := h.svc.Cancel(c.Request().Context(), id); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "contagem cancelada"})
+}
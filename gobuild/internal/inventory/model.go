@@ -0,0 +1,62 @@
+package inventory
+
+// Status de uma contagem de inventário
+//
+//	ABERTA -> FECHADA (ajustes postados) | CANCELADA (nada muda no estoque)
+const (
+	StatusAberta    = "ABERTA"
+	StatusFechada   = "FECHADA"
+	StatusCancelada = "CANCELADA"
+)
+
+// DefaultWarehouseID é o depósito contado quando a contagem não informa um
+// (mesmo id do depósito padrão criado pela migração 0005)
+const DefaultWarehouseID = 1
+
+// Count é uma sessão de contagem física de um depósito (opcionalmente de uma categoria)
+type Count struct {
+	ID          int64  `json:"id"`
+	WarehouseID int    `json:"warehouse_id"`
+	Category    string `json:"category,omitempty"` // vazio = todos os produtos do depósito
+	Status      string `json:"status"`
+	Notes       string `json:"notes,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	ClosedAt    string `json:"closed_at,omitempty"`
+	Items       []Item `json:"items,omitempty"`
+}
+
+// Item é um produto da contagem: esperado (congelado na abertura) x contado
+type Item struct {
+	ProductID     int      `json:"product_id"`
+	Product       string   `json:"product"`
+	Expected      float64  `json:"expected"`                 // saldo do depósito na abertura
+	Counted       *float64 `json:"counted"`                  // soma dos contadores (null = não contado)
+	Variance      *float64 `json:"variance,omitempty"`       // contado - esperado
+	Price         float64  `json:"price"`                    // preço unitário na abertura
+	UnitCost      float64  `json:"unit_cost"`                // custo médio unitário (congelado no fechamento)
+	VarianceValue *float64 `json:"variance_value,omitempty"` // diferença x custo médio
+	Moved         bool     `json:"moved"`                    // teve movimento durante a contagem
+	MovementID    *int64   `json:"movement_id,omitempty"`    // Ajuste postado no fechamento
+	Entries       []Entry  `json:"entries,omitempty"`
+}
+
+// Entry é a quantidade que um contador registrou para um produto
+type Entry struct {
+	Counter   string  `json:"counter"`
+	Quantity  float64 `json:"quantity"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// Report é o relatório de divergências (esperado x contado) de uma contagem
+type Report struct {
+	CountID      int64   `json:"count_id"`
+	Status       string  `json:"status"`
+	Counted      int     `json:"counted"`       // produtos contados
+	Pending      int     `json:"pending"`       // produtos ainda não contados
+	WithVariance int     `json:"with_variance"` // contados com diferença
+	Moved        int     `json:"moved"`         // movimentados durante a contagem
+	GainValue    float64 `json:"gain_value"`    // sobras em dinheiro
+	LossValue    float64 `json:"loss_value"`    // faltas em dinheiro (positivo)
+	NetValue     float64 `json:"net_value"`     // sobras - faltas
+	Items        []Item  `json:"items"`         // todos os produtos da contagem
+}
@@ -0,0 +1,270 @@
+package inventory
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL das contagens de inventário
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de inventário
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// queryer é o que *sql.DB e *sql.Tx têm em comum para leitura
+// (permite ler a contagem dentro ou fora de uma transação)
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// WarehouseActiveTx informa se o depósito existe e está ativo
+func (r *Repository) WarehouseActiveTx(ctx context.Context, tx *sql.Tx, warehouseID int) (bool, error) {
+	var active bool
+	err := tx.QueryRowContext(ctx, `SELECT active FROM warehouses WHERE id = ?`, warehouseID).Scan(&active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao buscar depósito: %v", err)
+	}
+	return active, nil
+}
+
+// OpenCountIDTx retorna o ID da contagem aberta no depósito (0 se não houver)
+func (r *Repository) OpenCountIDTx(ctx context.Context, tx *sql.Tx, warehouseID int) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM inventory_counts WHERE warehouse_id = ? AND status = ? LIMIT 1`,
+		warehouseID, StatusAberta).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar contagem aberta: %v", err)
+	}
+	return id, nil
+}
+
+// CreateCountTx abre a contagem e congela os produtos do escopo (esperado = saldo atual do
+// depósito, preço atual). Guarda o último movimento existente para identificar, depois,
+// os produtos movimentados durante a contagem. Retorna o ID e quantos produtos entraram.
+func (r *Repository) CreateCountTx(ctx context.Context, tx *sql.Tx, c *Count) (int64, int64, error) {
+	var category any // NULL = todos os produtos
+	if c.Category != "" {
+		category = c.Category
+	}
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO inventory_counts (warehouse_id, category, status, start_movement_id, notes)
+		VALUES (?, ?, ?, (SELECT COALESCE(MAX(id), 0) FROM stock_movements), ?)`,
+		c.WarehouseID, category, StatusAberta, c.Notes)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao inserir contagem: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao obter ID da contagem: %v", err)
+	}
+
+	result, err = tx.ExecContext(ctx,
+		`INSERT INTO inventory_count_items (count_id, product_id, expected, price)
+		SELECT ?, p.id, COALESCE(b.quantity, 0), p.price
+		FROM products p
+		LEFT JOIN stock_balances b ON b.product_id = p.id AND b.warehouse_id = ?
+		WHERE ? IS NULL OR p.category = ?`,
+		id, c.WarehouseID, category, category)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao inserir itens da contagem: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao contar itens da contagem: %v", err)
+	}
+	return id, n, nil
+}
+
+// countColumns são as colunas lidas por scanCount (mesma ordem)
+const countColumns = `id, warehouse_id, COALESCE(category, ''), status, COALESCE(notes, ''), created_at, closed_at`
+
+// scanCount lê uma contagem de uma linha (Row ou Rows)
+func scanCount(scan func(dest ...any) error) (Count, error) {
+	var c Count
+	var closedAt sql.NullString // NULL enquanto aberta
+	err := scan(&c.ID, &c.WarehouseID, &c.Category, &c.Status, &c.Notes, &c.CreatedAt, &closedAt)
+	c.ClosedAt = closedAt.String
+	return c, err
+}
+
+// GetCount busca uma contagem pelo ID (nil, nil se não existir), sem os itens
+func (r *Repository) GetCount(ctx context.Context, q queryer, id int64) (*Count, error) {
+	c, err := scanCount(q.QueryRowContext(ctx,
+		`SELECT `+countColumns+` FROM inventory_counts WHERE id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // contagem não encontrada
+		}
+		return nil, fmt.Errorf("erro ao escanear contagem: %v", err)
+	}
+	return &c, nil
+}
+
+// ListCounts retorna todas as contagens, da mais recente para a mais antiga
+func (r *Repository) ListCounts(ctx context.Context) ([]Count, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+countColumns+` FROM inventory_counts ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contagens: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Count
+	for rows.Next() {
+		c, err := scanCount(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear contagem: %v", err)
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das contagens: %v", err)
+	}
+	return list, nil
+}
+
+// ListItems retorna os produtos da contagem com a soma do que foi contado.
+// Enquanto a contagem está aberta, "moved" é calculado na hora (movimentos do produto no
+// depósito depois da abertura) e o custo unitário é o custo médio atual do produto; depois de
+// fechada valem os gravados no fechamento.
+func (r *Repository) ListItems(ctx context.Context, q queryer, countID int64) ([]Item, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT i.product_id, COALESCE(p.name, ''), i.expected, i.price, COALESCE(i.unit_cost, p.average_cost, 0),
+			(SELECT SUM(e.quantity) FROM inventory_count_entries e
+			 WHERE e.count_id = i.count_id AND e.product_id = i.product_id),
+			CASE WHEN c.status = ? THEN EXISTS (
+				SELECT 1 FROM stock_movements m
+				WHERE m.product_id = i.product_id AND m.warehouse_id = c.warehouse_id
+				  AND m.id > c.start_movement_id)
+			ELSE i.moved END,
+			i.movement_id
+		FROM inventory_count_items i
+		JOIN inventory_counts c ON c.id = i.count_id
+		LEFT JOIN products p ON p.id = i.product_id
+		WHERE i.count_id = ?
+		ORDER BY p.name, i.product_id`, StatusAberta, countID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar itens da contagem: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Item
+	for rows.Next() {
+		var it Item
+		var counted sql.NullFloat64
+		var movementID sql.NullInt64
+		if err := rows.Scan(&it.ProductID, &it.Product, &it.Expected, &it.Price, &it.UnitCost, &counted, &it.Moved, &movementID); err != nil {
+			return nil, fmt.Errorf("erro ao escanear item da contagem: %v", err)
+		}
+		if counted.Valid {
+			it.Counted = &counted.Float64
+		}
+		if movementID.Valid {
+			it.MovementID = &movementID.Int64
+		}
+		list = append(list, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos itens da contagem: %v", err)
+	}
+	return list, nil
+}
+
+// ListEntries retorna, por produto, o que cada contador registrou
+func (r *Repository) ListEntries(ctx context.Context, countID int64) (map[int][]Entry, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT product_id, counter, quantity, created_at FROM inventory_count_entries
+		WHERE count_id = ? ORDER BY product_id, counter`, countID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contagens dos contadores: %v", err)
+	}
+	defer rows.Close()
+
+	byProduct := map[int][]Entry{}
+	for rows.Next() {
+		var productID int
+		var e Entry
+		if err := rows.Scan(&productID, &e.Counter, &e.Quantity, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear contagem do contador: %v", err)
+		}
+		byProduct[productID] = append(byProduct[productID], e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das contagens dos contadores: %v", err)
+	}
+	return byProduct, nil
+}
+
+// HasItemTx informa se o produto faz parte da contagem
+func (r *Repository) HasItemTx(ctx context.Context, tx *sql.Tx, countID int64, productID int) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM inventory_count_items WHERE count_id = ? AND product_id = ?)`,
+		countID, productID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar item da contagem: %v", err)
+	}
+	return exists, nil
+}
+
+// UpsertEntryTx grava a quantidade contada por um contador (recontar substitui a anterior)
+func (r *Repository) UpsertEntryTx(ctx context.Context, tx *sql.Tx, countID int64, productID int, counter string, quantity float64) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO inventory_count_entries (count_id, product_id, counter, quantity) VALUES (?, ?, ?, ?)
+		ON CONFLICT (count_id, product_id, counter)
+		DO UPDATE SET quantity = excluded.quantity, created_at = CURRENT_TIMESTAMP`,
+		countID, productID, counter, quantity)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar contagem: %v", err)
+	}
+	return nil
+}
+
+// GetBalanceTx retorna o saldo atual do produto no depósito (0 se não houver linha)
+func (r *Repository) GetBalanceTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int) (float64, error) {
+	var qty float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE((SELECT quantity FROM stock_balances WHERE warehouse_id = ? AND product_id = ?), 0)`,
+		warehouseID, productID).Scan(&qty)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar saldo do depósito: %v", err)
+	}
+	return qty, nil
+}
+
+// CloseItemTx grava, no fechamento, se o produto foi movimentado, o custo unitário que valoriza
+// a diferença e o Ajuste postado (se houve)
+func (r *Repository) CloseItemTx(ctx context.Context, tx *sql.Tx, countID int64, productID int, moved bool, unitCost float64, movementID *int64) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE inventory_count_items SET moved = ?, unit_cost = ?, movement_id = ? WHERE count_id = ? AND product_id = ?`,
+		moved, unitCost, movementID, countID, productID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar item da contagem: %v", err)
+	}
+	return nil
+}
+
+// UpdateStatusTx muda o status da contagem (fechada/cancelada ganha closed_at)
+func (r *Repository) UpdateStatusTx(ctx context.Context, tx *sql.Tx, id int64, status string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE inventory_counts SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?`, status, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status da contagem: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package inventory
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // transação compartilhada com o estoque
+	"fmt"          // para formatação de strings e erros
+	"strings"      // para limpar o nome do contador
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// StockService é o que o inventário precisa do estoque: o custo médio que valoriza as
+// diferenças e postar o Ajuste na transação do fechamento
+type StockService interface {
+	// AjusteTx define o saldo do produto no depósito e retorna o ID do movimento
+	// (reason/document ficam gravados no movimento: INVENTARIO, "inventario:<id>")
+	AjusteTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, quantity float64, reason, document string) (int64, error)
+	// Valuation retorna o valor do estoque na data (vazia = hoje), com o custo médio de cada
+	// produto com saldo
+	Valuation(ctx context.Context, date string) (*stock.Valuation, error)
+}
+
+var (
+	// ErrNaoAberta indica operação em contagem já fechada ou cancelada
+	ErrNaoAberta = apperr.Conflict("contagem não está aberta")
+	// ErrJaAberta indica que o depósito já tem uma contagem em andamento
+	ErrJaAberta = apperr.Conflict("já existe contagem aberta neste depósito")
+)
+
+// Service contém as regras de negócio das contagens de inventário
+type Service struct {
+	repo  *Repository  // dependencia do repositorio para persistencia
+	stock StockService // posta os ajustes no fechamento
+}
+
+// NewService cria uma nova instância do serviço de inventário
+func NewService(repo *Repository, stock StockService) *Service {
+	return &Service{
+		repo:  repo,
+		stock: stock,
+	}
+}
+
+// Open abre uma contagem para o depósito (padrão: depósito 1), congelando o saldo
+// esperado e o preço de cada produto (todos, ou só os da categoria informada)
+func (s *Service) Open(ctx context.Context, req OpenCountRequest) (*Count, error) {
+	c := &Count{
+		WarehouseID: req.WarehouseID,
+		Category:    strings.TrimSpace(req.Category),
+		Notes:       req.Notes,
+	}
+	if c.WarehouseID == 0 {
+		c.WarehouseID = DefaultWarehouseID
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	active, err := s.repo.WarehouseActiveTx(ctx, tx, c.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, apperr.Invalid("depósito %d não encontrado ou inativo", c.WarehouseID)
+	}
+	openID, err := s.repo.OpenCountIDTx(ctx, tx, c.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if openID != 0 {
+		return nil, fmt.Errorf("%w (contagem %d)", ErrJaAberta, openID)
+	}
+
+	id, n, err := s.repo.CreateCountTx(ctx, tx, c)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, apperr.Invalid("nenhum produto no escopo da contagem")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// List retorna as contagens (sem itens)
+func (s *Service) List(ctx context.Context) ([]Count, error) {
+	return s.repo.ListCounts(ctx)
+}
+
+// Get retorna a contagem com os itens e o que cada contador registrou
+func (s *Service) Get(ctx context.Context, id int64) (*Count, error) {
+	c, err := s.repo.GetCount(ctx, s.repo.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, apperr.NotFound("contagem com ID %d não encontrada", id)
+	}
+
+	c.Items, err = s.repo.ListItems(ctx, s.repo.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.ListEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// contagem aberta: a diferença vale o custo médio de agora (fechada, o gravado no fechamento)
+	var costs map[int]float64
+	if c.Status == StatusAberta {
+		if costs, err = s.averageCosts(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for i := range c.Items {
+		c.Items[i].Entries = entries[c.Items[i].ProductID]
+		if cost, ok := costs[c.Items[i].ProductID]; ok {
+			c.Items[i].UnitCost = cost
+		}
+		fillVariance(&c.Items[i])
+	}
+	return c, nil
+}
+
+// Record grava as quantidades contadas por um contador. Cada contador tem sua linha por
+// produto (recontar substitui a anterior) e a quantidade contada é a soma dos contadores
+// — ex.: um conta a loja e outro o pátio do mesmo depósito.
+func (s *Service) Record(ctx context.Context, id int64, req RecordRequest) (*Count, error) {
+	counter := strings.TrimSpace(req.Counter)
+	if counter == "" {
+		return nil, apperr.Invalid("informe o contador")
+	}
+	if len(req.Items) == 0 {
+		return nil, apperr.Invalid("informe ao menos um produto contado")
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	c, err := s.repo.GetCount(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, apperr.NotFound("contagem com ID %d não encontrada", id)
+	}
+	if c.Status != StatusAberta {
+		return nil, ErrNaoAberta
+	}
+
+	for _, it := range req.Items {
+		if it.Quantity < 0 {
+			return nil, apperr.Invalid("quantidade contada do produto %d não pode ser negativa", it.ProductID)
+		}
+		ok, err := s.repo.HasItemTx(ctx, tx, id, it.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, apperr.Invalid("produto %d não faz parte da contagem", it.ProductID)
+		}
+		if err := s.repo.UpsertEntryTx(ctx, tx, id, it.ProductID, counter, it.Quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// Report monta o relatório de divergências (esperado x contado, em quantidade e em dinheiro
+// pelo custo médio)
+func (s *Service) Report(ctx context.Context, id int64) (*Report, error) {
+	c, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{CountID: c.ID, Status: c.Status, Items: c.Items}
+	for _, it := range c.Items {
+		if it.Moved {
+			r.Moved++
+		}
+		if it.Counted == nil {
+			r.Pending++
+			continue
+		}
+		r.Counted++
+		if *it.Variance == 0 {
+			continue
+		}
+		r.WithVariance++
+		if *it.VarianceValue > 0 {
+			r.GainValue += *it.VarianceValue
+		} else {
+			r.LossValue -= *it.VarianceValue
+		}
+	}
+	r.NetValue = r.GainValue - r.LossValue
+	return r, nil
+}
+
+// Close fecha a contagem postando um Ajuste para cada produto contado com diferença.
+// O ajuste aplica a diferença (contado - esperado) sobre o saldo atual, então produtos
+// movimentados durante a contagem não perdem as vendas/entradas feitas nesse meio tempo.
+// Produtos não contados ficam como estão. A diferença de cada item é valorizada pelo custo
+// médio do estoque, gravado no item. Tudo na mesma transação.
+func (s *Service) Close(ctx context.Context, id int64) (*Report, error) {
+	// lido antes da transação: a valorização consulta o banco fora dela
+	costs, err := s.averageCosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	c, err := s.repo.GetCount(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, apperr.NotFound("contagem com ID %d não encontrada", id)
+	}
+	if c.Status != StatusAberta {
+		return nil, ErrNaoAberta
+	}
+
+	// lidos antes de postar os ajustes: "moved" ainda reflete só os movimentos de terceiros
+	items, err := s.repo.ListItems(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, it := range items {
+		var movementID *int64
+		if it.Counted != nil && *it.Counted != it.Expected {
+			current, err := s.repo.GetBalanceTx(ctx, tx, c.WarehouseID, it.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			target := current + (*it.Counted - it.Expected)
+			if target < 0 {
+				return nil, apperr.Invalid("produto %d: ajuste deixaria saldo negativo (%v); reconte o produto", it.ProductID, target)
+			}
+			mid, err := s.stock.AjusteTx(ctx, tx, c.WarehouseID, it.ProductID, target, "INVENTARIO", fmt.Sprintf("inventario:%d", id))
+			if err != nil {
+				return nil, fmt.Errorf("erro ao ajustar produto %d: %v", it.ProductID, err)
+			}
+			movementID = &mid
+		}
+		unitCost, ok := costs[it.ProductID]
+		if !ok {
+			unitCost = it.UnitCost // sem saldo na valorização: custo médio do cadastro
+		}
+		if err := s.repo.CloseItemTx(ctx, tx, id, it.ProductID, it.Moved, unitCost, movementID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateStatusTx(ctx, tx, id, StatusFechada); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Report(ctx, id)
+}
+
+// Cancel descarta uma contagem aberta (nenhum ajuste é postado)
+func (s *Service) Cancel(ctx context.Context, id int64) error {
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	c, err := s.repo.GetCount(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return apperr.NotFound("contagem com ID %d não encontrada", id)
+	}
+	if c.Status != StatusAberta {
+		return ErrNaoAberta
+	}
+	if err := s.repo.UpdateStatusTx(ctx, tx, id, StatusCancelada); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return nil
+}
+
+// averageCosts retorna o custo médio de cada produto com saldo, pela valorização do estoque
+func (s *Service) averageCosts(ctx context.Context) (map[int]float64, error) {
+	v, err := s.stock.Valuation(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao valorizar o estoque: %v", err)
+	}
+	costs := map[int]float64{}
+	for _, c := range v.Categories {
+		for _, p := range c.Products {
+			costs[p.ProductID] = p.AverageCost
+		}
+	}
+	return costs, nil
+}
+
+// fillVariance calcula a diferença (contado - esperado) e o valor dela pelo custo médio,
+// se o item foi contado
+func fillVariance(it *Item) {
+	if it.Counted == nil {
+		return
+	}
+	variance := *it.Counted - it.Expected
+	value := variance * it.UnitCost
+	it.Variance, it.VarianceValue = &variance, &value
+}
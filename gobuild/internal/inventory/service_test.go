@@ -0,0 +1,92 @@
+package inventory_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/database"
+	"github.com/EtraudBits/golangProject/gobuild/internal/inventory"
+	"github.com/EtraudBits/golangProject/gobuild/internal/product"
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// TestCloseComEntradaDuranteContagem: uma Entrada postada depois da abertura não se perde no
+// fechamento (o Ajuste aplica só contado - esperado sobre o saldo atual) e a falta é valorizada
+// pelo custo médio do estoque, não pelo preço de venda
+func TestCloseComEntradaDuranteContagem(t *testing.T) {
+	if err := database.ConnectPath(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("erro ao conectar: %v", err)
+	}
+	t.Cleanup(func() { _ = database.DB.Close() })
+	ctx := context.Background()
+
+	productRepo := product.NewRepository(database.DB)
+	stockSvc := stock.NewService(database.DB, stock.NewRepository(database.DB), productRepo.GetStockTx, productRepo.UpdateStockTx)
+	svc := inventory.NewService(inventory.NewRepository(database.DB), stockSvc)
+
+	id, err := product.NewService(productRepo, stockSvc).Create(ctx, &product.Produto{
+		Name: "Cimento CP-II 50kg", Preco: 25.5, Unidade: "saco", Categoria: "Materiais",
+	})
+	if err != nil {
+		t.Fatalf("erro ao inserir produto: %v", err)
+	}
+	productID := int(id)
+	entrada := func(qty, cost float64) {
+		t.Helper()
+		if _, err := stockSvc.CreateMovement(ctx, &stock.Movement{
+			ProductID: productID, Type: "Entrada", Quantity: qty, UnitCost: &cost,
+		}); err != nil {
+			t.Fatalf("erro na entrada: %v", err)
+		}
+	}
+
+	entrada(10, 20)
+	c, err := svc.Open(ctx, inventory.OpenCountRequest{})
+	if err != nil {
+		t.Fatalf("erro ao abrir contagem: %v", err)
+	}
+	if _, err := svc.Record(ctx, c.ID, inventory.RecordRequest{
+		Counter: "Ana", Items: []inventory.CountedItem{{ProductID: productID, Quantity: 8}},
+	}); err != nil {
+		t.Fatalf("erro ao registrar contagem: %v", err)
+	}
+	entrada(10, 26) // chega mercadoria no meio da contagem: custo médio (200 + 260) / 20 = 23
+
+	r, err := svc.Close(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("erro ao fechar contagem: %v", err)
+	}
+
+	var estoque float64
+	if err := database.DB.QueryRow(`SELECT stock FROM products WHERE id = ?`, productID).Scan(&estoque); err != nil {
+		t.Fatalf("erro ao ler estoque: %v", err)
+	}
+	if estoque != 18 { // 20 atuais + (8 - 10)
+		t.Errorf("estoque = %v, esperado 18", estoque)
+	}
+
+	if len(r.Items) != 1 {
+		t.Fatalf("itens = %d, esperado 1", len(r.Items))
+	}
+	it := r.Items[0]
+	if !it.Moved || it.MovementID == nil {
+		t.Errorf("item deveria estar movimentado e ajustado: %+v", it)
+	}
+	if it.UnitCost != 23 || it.VarianceValue == nil || *it.VarianceValue != -46 {
+		t.Errorf("custo/valor da diferença = %v/%v, esperado 23/-46", it.UnitCost, it.VarianceValue)
+	}
+	if r.LossValue != 46 || r.NetValue != -46 {
+		t.Errorf("falta/líquido = %v/%v, esperado 46/-46", r.LossValue, r.NetValue)
+	}
+
+	// fechada, a contagem mantém o custo do fechamento mesmo com compras mais caras depois
+	entrada(10, 50)
+	r, err = svc.Report(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("erro no relatório: %v", err)
+	}
+	if r.LossValue != 46 {
+		t.Errorf("falta depois de nova compra = %v, esperado 46", r.LossValue)
+	}
+}
@@ -0,0 +1,145 @@
+package nfe
+
+import (
+	"io"       // leitura do XML enviado
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+	"strings"  // para identificar erro de "não encontrado"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// maxXMLSize é o tamanho máximo aceito para o XML da NF-e
+const maxXMLSize = 5 << 20 // 5 MB
+
+// Handler expõe os endpoints HTTP de importação de NF-e
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de importação no grupo Echo (ex.: /api/nfe)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("/import", h.Import)
+	g.GET("/imports", h.List)
+	g.GET("/imports/:id", h.Get)
+	g.PUT("/imports/:id/items/:line", h.MapItem)
+	g.POST("/imports/:id/confirm", h.Confirm)
+	g.POST("/imports/:id/cancel", h.Cancel)
+}
+
+// MapItemRequest casa um item da nota manualmente. Ex.: {"product_id": 12} ou {"ignore": true}
+type MapItemRequest struct {
+	ProductID *int `json:"product_id"`
+	Ignore    bool `json:"ignore"`
+}
+
+// ConfirmRequest confirma a importação. Ex.: {"warehouse_id": 2} (opcional; padrão: depósito 1)
+type ConfirmRequest struct {
+	WarehouseID int `json:"warehouse_id"`
+}
+
+// Import recebe o XML da NF-e: multipart com o campo "file" ou o XML direto no corpo
+// (Content-Type application/xml ou text/xml)
+func (h *Handler) Import(c echo.Context) error {
+	var src io.Reader = c.Request().Body
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "envie o XML no campo \"file\""})
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "erro ao abrir o arquivo: " + err.Error()})
+		}
+		defer f.Close()
+		src = f
+	}
+	data, err := io.ReadAll(io.LimitReader(src, maxXMLSize+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "erro ao ler o XML: " + err.Error()})
+	}
+	if len(data) > maxXMLSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "XML maior que 5 MB"})
+	}
+
+	imp, err := h.svc.Import(c.Request().Context(), data)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, imp)
+}
+
+// List retorna as importações (?status=PENDENTE)
+func (h *Handler) List(c echo.Context) error {
+	list, err := h.svc.List(c.Request().Context(), c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// Get retorna a importação com os itens (os sem produto têm product_id nulo)
+func (h *Handler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	imp, err := h.svc.Get(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, imp)
+}
+
+// MapItem casa manualmente um item da nota com um produto (ou ignora o item)
+func (h *Handler) MapItem(c echo.Context) error {
+	id, err1 := strconv.ParseInt(c.Param("id"), 10, 64)
+	line, err2 := strconv.Atoi(c.Param("line"))
+	if err1 != nil || err2 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID ou linha inválidos"})
+	}
+	var req MapItemRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	imp, err := h.svc.MapItem(c.Request().Context(), id, line, req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, imp)
+}
+
+// Confirm posta as Entradas da nota no estoque
+func (h *Handler) Confirm(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req ConfirmRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	imp, err := h.svc.Confirm(c.Request().Context(), id, req.WarehouseID)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, imp)
+}
+
+// Cancel descarta uma importação pendente
+func (h *Handler) Cancel(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	if err := h.svc.Cancel(c.Request().Context(), id); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "importação cancelada"})
+}
@@ -0,0 +1,60 @@
+package nfe
+
+// Status de uma importação de NF-e
+//
+//	PENDENTE -> CONFIRMADA (Entradas postadas) | CANCELADA (libera a chave para nova importação)
+const (
+	StatusPendente   = "PENDENTE"
+	StatusConfirmada = "CONFIRMADA"
+	StatusCancelada  = "CANCELADA"
+)
+
+// Como o item da nota foi casado com o produto
+const (
+	MatchCodigo = "CODIGO_FORNECEDOR" // código do produto no fornecedor (product_suppliers)
+	MatchEAN    = "EAN"               // código de barras do produto
+	MatchManual = "MANUAL"            // informado pelo usuário
+)
+
+// DefaultWarehouseID é o depósito que recebe a nota quando a confirmação não informa um
+// (mesmo id do depósito padrão criado pela migração 0005)
+const DefaultWarehouseID = 1
+
+// Import é uma NF-e de fornecedor importada
+type Import struct {
+	ID           int64   `json:"id"`
+	AccessKey    string  `json:"access_key"` // chave de acesso (44 dígitos)
+	Number       string  `json:"number"`
+	Series       string  `json:"series"`
+	IssuedAt     string  `json:"issued_at"`
+	SupplierCNPJ string  `json:"supplier_cnpj"` // CNPJ do emitente
+	SupplierName string  `json:"supplier_name"`
+	SupplierID   *int    `json:"supplier_id"` // fornecedor cadastrado com o CNPJ do emitente (nulo se não houver)
+	Total        float64 `json:"total"`       // valor total da nota (vNF)
+	Status       string  `json:"status"`
+	WarehouseID  *int    `json:"warehouse_id,omitempty"` // depósito que recebeu (na confirmação)
+	Unmatched    int     `json:"unmatched"`              // itens ainda sem produto (nem ignorados)
+	CreatedAt    string  `json:"created_at"`
+	ConfirmedAt  string  `json:"confirmed_at,omitempty"`
+	Items        []Item  `json:"items,omitempty"`
+}
+
+// Item é um item (det) da nota. UnitCost é o custo de entrada por unidade:
+// (vProd - vDesc + vFrete + vSeg + vOutro) / qCom
+type Item struct {
+	Line         int     `json:"line"` // nItem
+	SupplierCode string  `json:"supplier_code"`
+	EAN          string  `json:"ean,omitempty"`
+	Description  string  `json:"description"`
+	NCM          string  `json:"ncm"`
+	CFOP         string  `json:"cfop"`
+	Unit         string  `json:"unit"`
+	Quantity     float64 `json:"quantity"`
+	UnitCost     float64 `json:"unit_cost"`
+	Total        float64 `json:"total"`
+	ProductID    *int    `json:"product_id"` // nulo = sem produto casado
+	Product      string  `json:"product,omitempty"`
+	Match        string  `json:"match,omitempty"`       // ver Match*
+	Ignored      bool    `json:"ignored"`               // não entra no estoque (ex.: brinde, serviço)
+	MovementID   *int64  `json:"movement_id,omitempty"` // Entrada postada na confirmação
+}
@@ -0,0 +1,210 @@
+package nfe
+
+import (
+	"bytes"        // leitura do elemento raiz
+	"encoding/xml" // leitura do XML da NF-e
+	"errors"       // para manipulação de erros
+	"fmt"          // para formatação de strings e erros
+	"strconv"      // valores numéricos da nota
+	"strings"      // limpeza dos campos de texto
+)
+
+// Estrutura do XML da NF-e (modelo 55), só com o que a importação usa. O XML pode vir como
+// nfeProc (nota autorizada, com o protocolo) ou só o elemento NFe. As tags não têm namespace:
+// o encoding/xml casa pelo nome local, com ou sem o xmlns do portal fiscal.
+type nfeProc struct {
+	NFe   nfeDoc `xml:"NFe"`
+	ChNFe string `xml:"protNFe>infProt>chNFe"`
+}
+
+type nfeDoc struct {
+	InfNFe struct {
+		ID  string `xml:"Id,attr"` // "NFe" + chave de acesso
+		Ide struct {
+			Serie string `xml:"serie"`
+			NNF   string `xml:"nNF"`
+			DhEmi string `xml:"dhEmi"`
+			DEmi  string `xml:"dEmi"` // leiaute antigo (2.00)
+		} `xml:"ide"`
+		Emit struct {
+			CNPJ  string `xml:"CNPJ"`
+			XNome string `xml:"xNome"`
+		} `xml:"emit"`
+		Det []struct {
+			NItem string `xml:"nItem,attr"`
+			Prod  struct {
+				CProd    string `xml:"cProd"`
+				CEAN     string `xml:"cEAN"`
+				CEANTrib string `xml:"cEANTrib"`
+				XProd    string `xml:"xProd"`
+				NCM      string `xml:"NCM"`
+				CFOP     string `xml:"CFOP"`
+				UCom     string `xml:"uCom"`
+				QCom     string `xml:"qCom"`
+				VProd    string `xml:"vProd"`
+				VFrete   string `xml:"vFrete"`
+				VSeg     string `xml:"vSeg"`
+				VDesc    string `xml:"vDesc"`
+				VOutro   string `xml:"vOutro"`
+			} `xml:"prod"`
+		} `xml:"det"`
+		Total struct {
+			VNF string `xml:"ICMSTot>vNF"`
+		} `xml:"total"`
+	} `xml:"infNFe"`
+}
+
+// Parse lê o XML de uma NF-e e devolve a importação (ainda sem fornecedor e produtos casados)
+func Parse(data []byte) (*Import, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc nfeDoc
+	var protKey string
+	switch root {
+	case "nfeProc":
+		var proc nfeProc
+		if err := xml.Unmarshal(data, &proc); err != nil {
+			return nil, fmt.Errorf("XML da NF-e inválido: %v", err)
+		}
+		doc, protKey = proc.NFe, proc.ChNFe
+	case "NFe":
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("XML da NF-e inválido: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("XML não é uma NF-e (elemento raiz %s; esperado nfeProc ou NFe)", root)
+	}
+
+	inf := doc.InfNFe
+	key := strings.TrimSpace(protKey)
+	if key == "" {
+		key = strings.TrimPrefix(strings.TrimSpace(inf.ID), "NFe")
+	}
+	if !validAccessKey(key) {
+		return nil, fmt.Errorf("chave de acesso inválida: %q", key)
+	}
+	if len(inf.Det) == 0 {
+		return nil, errors.New("a NF-e não tem itens")
+	}
+
+	imp := &Import{
+		AccessKey:    key,
+		Number:       strings.TrimSpace(inf.Ide.NNF),
+		Series:       strings.TrimSpace(inf.Ide.Serie),
+		IssuedAt:     strings.TrimSpace(inf.Ide.DhEmi),
+		SupplierCNPJ: strings.TrimSpace(inf.Emit.CNPJ),
+		SupplierName: strings.TrimSpace(inf.Emit.XNome),
+		Status:       StatusPendente,
+	}
+	if imp.IssuedAt == "" {
+		imp.IssuedAt = strings.TrimSpace(inf.Ide.DEmi)
+	}
+	if imp.SupplierCNPJ == "" {
+		return nil, errors.New("a NF-e não tem o CNPJ do emitente (emitente pessoa física não é aceito)")
+	}
+	if imp.Total, err = parseValue(inf.Total.VNF, false); err != nil {
+		return nil, fmt.Errorf("vNF: %v", err)
+	}
+
+	for i, det := range inf.Det {
+		p := det.Prod
+		it := Item{
+			Line:         i + 1,
+			SupplierCode: strings.TrimSpace(p.CProd),
+			EAN:          gtin(p.CEAN),
+			Description:  strings.TrimSpace(p.XProd),
+			NCM:          strings.TrimSpace(p.NCM),
+			CFOP:         strings.TrimSpace(p.CFOP),
+			Unit:         strings.TrimSpace(p.UCom),
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(det.NItem)); err == nil {
+			it.Line = n
+		}
+		if it.EAN == "" {
+			it.EAN = gtin(p.CEANTrib)
+		}
+
+		var values [6]float64
+		for j, v := range []string{p.QCom, p.VProd, p.VFrete, p.VSeg, p.VDesc, p.VOutro} {
+			if values[j], err = parseValue(v, j >= 2); err != nil {
+				return nil, fmt.Errorf("item %d: %v", it.Line, err)
+			}
+		}
+		qty, prod, frete, seg, desc, outro := values[0], values[1], values[2], values[3], values[4], values[5]
+		if qty <= 0 {
+			return nil, fmt.Errorf("item %d: quantidade deve ser maior que zero", it.Line)
+		}
+		it.Quantity = qty
+		it.Total = prod - desc + frete + seg + outro
+		it.UnitCost = it.Total / qty
+		imp.Items = append(imp.Items, it)
+	}
+	return imp, nil
+}
+
+// rootElement devolve o nome local do primeiro elemento do XML
+func rootElement(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("XML da NF-e inválido: %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local, nil
+		}
+	}
+}
+
+// parseValue lê um valor numérico da nota (ponto decimal); optional aceita campo ausente (0)
+func parseValue(v string, optional bool) (float64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		if optional {
+			return 0, nil
+		}
+		return 0, errors.New("valor obrigatório ausente")
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("valor inválido: %q", v)
+	}
+	return f, nil
+}
+
+// gtin limpa o cEAN: "SEM GTIN" e vazio viram ""
+func gtin(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.EqualFold(v, "SEM GTIN") {
+		return ""
+	}
+	return v
+}
+
+// validAccessKey confere a chave de acesso: 44 dígitos com o último sendo o dígito
+// verificador (módulo 11, pesos 2 a 9 da direita para a esquerda)
+func validAccessKey(key string) bool {
+	if len(key) != 44 {
+		return false
+	}
+	sum, weight := 0, 2
+	for i := 42; i >= 0; i-- {
+		c := key[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += int(c-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+	dv := 11 - sum%11
+	if dv >= 10 {
+		dv = 0
+	}
+	return key[43] == byte('0'+dv)
+}
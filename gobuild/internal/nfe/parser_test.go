@@ -0,0 +1,113 @@
+package nfe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const chaveFixture = "35261011222333000181550010000123451876543218"
+
+// lerFixture lê a nota autorizada (nfeProc) de testdata
+func lerFixture(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "nfe_proc.xml"))
+	if err != nil {
+		t.Fatalf("erro ao ler fixture: %v", err)
+	}
+	return string(data)
+}
+
+// TestParseNfeProc confere cabeçalho e itens da nota autorizada, com frete, seguro, desconto e
+// outras despesas rateados no custo do item e o cEANTrib quando o cEAN é "SEM GTIN"
+func TestParseNfeProc(t *testing.T) {
+	imp, err := Parse([]byte(lerFixture(t)))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if imp.AccessKey != chaveFixture || imp.Number != "12345" || imp.Series != "1" ||
+		imp.IssuedAt != "2026-10-15T09:30:00-03:00" || imp.SupplierCNPJ != "11222333000181" ||
+		imp.SupplierName != "Cimentos Exemplo Ltda" || imp.Total != 3046 || imp.Status != StatusPendente {
+		t.Errorf("cabeçalho inesperado: %+v", imp)
+	}
+
+	want := []Item{
+		{Line: 1, SupplierCode: "CP2-50", EAN: "7891234567895", Description: "CIMENTO CP-II 50KG",
+			NCM: "25232910", CFOP: "5102", Unit: "SC", Quantity: 100, UnitCost: 25.5, Total: 2550},
+		{Line: 2, SupplierCode: "AR-M", EAN: "7890000000017", Description: "AREIA MEDIA",
+			NCM: "25059000", CFOP: "5102", Unit: "M3", Quantity: 4, UnitCost: 124, Total: 496},
+	}
+	if len(imp.Items) != len(want) {
+		t.Fatalf("itens = %d, esperado %d", len(imp.Items), len(want))
+	}
+	for i, w := range want {
+		got := imp.Items[i]
+		if got.Line != w.Line || got.SupplierCode != w.SupplierCode || got.EAN != w.EAN ||
+			got.Description != w.Description || got.NCM != w.NCM || got.CFOP != w.CFOP || got.Unit != w.Unit ||
+			got.Quantity != w.Quantity || got.UnitCost != w.UnitCost || got.Total != w.Total {
+			t.Errorf("item %d = %+v, esperado %+v", i+1, got, w)
+		}
+	}
+}
+
+// TestParseSoNFe: sem o protocolo, a chave vem do Id do infNFe
+func TestParseSoNFe(t *testing.T) {
+	xml := lerFixture(t)
+	start := strings.Index(xml, "<NFe ")
+	end := strings.Index(xml, "</NFe>") + len("</NFe>")
+	imp, err := Parse([]byte(xml[start:end]))
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if imp.AccessKey != chaveFixture || len(imp.Items) != 2 {
+		t.Errorf("chave = %s, itens = %d", imp.AccessKey, len(imp.Items))
+	}
+}
+
+// TestParseInvalido confere as notas recusadas
+func TestParseInvalido(t *testing.T) {
+	xml := lerFixture(t)
+	tests := []struct {
+		name string
+		xml  string
+		erro string
+	}{
+		{"não é XML", "chave=123", "XML da NF-e inválido"},
+		{"raiz errada", "<CTe></CTe>", "XML não é uma NF-e"},
+		{"chave com dígito errado", strings.ReplaceAll(xml, chaveFixture, chaveFixture[:43]+"9"), "chave de acesso inválida"},
+		{"sem CNPJ do emitente", strings.Replace(xml, "<CNPJ>11222333000181</CNPJ>", "<CPF>52998224725</CPF>", 1), "CNPJ do emitente"},
+		{"quantidade zero", strings.Replace(xml, "<qCom>4</qCom>", "<qCom>0</qCom>", 1), "item 2: quantidade"},
+		{"valor inválido", strings.Replace(xml, "<vProd>480.00</vProd>", "<vProd>480,00</vProd>", 1), "item 2: valor inválido"},
+		{"sem vNF", strings.Replace(xml, "<vNF>3046.00</vNF>", "", 1), "vNF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.xml))
+			if err == nil || !strings.Contains(err.Error(), tt.erro) {
+				t.Errorf("erro = %v, esperado contendo %q", err, tt.erro)
+			}
+		})
+	}
+}
+
+// TestValidAccessKey confere o dígito verificador (módulo 11) e o formato da chave
+func TestValidAccessKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{chaveFixture, true},
+		{"35261011444777000161550020000007771123456783", true},
+		{"35261011444777000161550020000007771123456780", false}, // dígito errado
+		{chaveFixture[:43], false},                              // 43 dígitos
+		{chaveFixture + "0", false},                             // 45 dígitos
+		{"3526101122233300018155001000012345187654321A", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := validAccessKey(tt.key); got != tt.valid {
+			t.Errorf("validAccessKey(%q) = %v, esperado %v", tt.key, got, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package nfe
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL das importações de NF-e
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de importações de NF-e
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// queryer é o que *sql.DB e *sql.Tx têm em comum para leitura
+// (permite ler a importação dentro ou fora de uma transação)
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ActiveImportIDTx retorna o ID da importação não cancelada com a chave (0 se não houver)
+func (r *Repository) ActiveImportIDTx(ctx context.Context, tx *sql.Tx, accessKey string) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM nfe_imports WHERE access_key = ? AND status <> ?`, accessKey, StatusCancelada).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar importação pela chave: %v", err)
+	}
+	return id, nil
+}
+
+// SupplierIDByCNPJTx retorna o fornecedor cadastrado com o CNPJ (0 se não houver)
+func (r *Repository) SupplierIDByCNPJTx(ctx context.Context, tx *sql.Tx, cnpj string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM suppliers WHERE cnpj = ?`, cnpj).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar fornecedor pelo CNPJ: %v", err)
+	}
+	return id, nil
+}
+
+// ProductBySupplierCodeTx retorna o produto vinculado ao código do fornecedor (0 se não houver)
+func (r *Repository) ProductBySupplierCodeTx(ctx context.Context, tx *sql.Tx, supplierID int, code string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx,
+		`SELECT product_id FROM product_suppliers WHERE supplier_id = ? AND supplier_code = ?
+		ORDER BY product_id LIMIT 1`, supplierID, code).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar produto pelo código do fornecedor: %v", err)
+	}
+	return id, nil
+}
+
+// ProductByEANTx retorna o produto com o código de barras (0 se não houver)
+func (r *Repository) ProductByEANTx(ctx context.Context, tx *sql.Tx, ean string) (int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM products WHERE ean = ? ORDER BY id LIMIT 1`, ean).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar produto pelo EAN: %v", err)
+	}
+	return id, nil
+}
+
+// ProductExistsTx informa se o produto existe
+func (r *Repository) ProductExistsTx(ctx context.Context, tx *sql.Tx, productID int) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM products WHERE id = ?`, productID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar produto: %v", err)
+	}
+	return n > 0, nil
+}
+
+// WarehouseActiveTx informa se o depósito existe e está ativo
+func (r *Repository) WarehouseActiveTx(ctx context.Context, tx *sql.Tx, warehouseID int) (bool, error) {
+	var active bool
+	err := tx.QueryRowContext(ctx, `SELECT active FROM warehouses WHERE id = ?`, warehouseID).Scan(&active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao buscar depósito: %v", err)
+	}
+	return active, nil
+}
+
+// CreateImportTx grava a importação com os itens e retorna o ID gerado
+func (r *Repository) CreateImportTx(ctx context.Context, tx *sql.Tx, imp *Import) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO nfe_imports (access_key, number, series, issued_at, supplier_cnpj, supplier_name, supplier_id, total, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		imp.AccessKey, imp.Number, imp.Series, imp.IssuedAt, imp.SupplierCNPJ, imp.SupplierName,
+		imp.SupplierID, imp.Total, StatusPendente)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao gravar importação da NF-e: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID da importação: %v", err)
+	}
+	for _, it := range imp.Items {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO nfe_import_items (import_id, line, supplier_code, ean, description, ncm, cfop, unit,
+				quantity, unit_cost, total, product_id, match)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, it.Line, it.SupplierCode, nullString(it.EAN), it.Description, it.NCM, it.CFOP, it.Unit,
+			it.Quantity, it.UnitCost, it.Total, it.ProductID, nullString(it.Match))
+		if err != nil {
+			return 0, fmt.Errorf("erro ao gravar item %d da NF-e: %v", it.Line, err)
+		}
+	}
+	return id, nil
+}
+
+// importColumns são as colunas lidas por scanImport (mesma ordem); unmatched conta os itens
+// sem produto e não ignorados
+const importColumns = `i.id, i.access_key, COALESCE(i.number, ''), COALESCE(i.series, ''), COALESCE(i.issued_at, ''),
+	COALESCE(i.supplier_cnpj, ''), COALESCE(i.supplier_name, ''), i.supplier_id, i.total, i.status, i.warehouse_id,
+	(SELECT COUNT(*) FROM nfe_import_items t WHERE t.import_id = i.id AND t.product_id IS NULL AND t.ignored = 0),
+	i.created_at, COALESCE(i.confirmed_at, '')`
+
+// scanImport lê uma importação de uma linha (Row ou Rows), sem os itens
+func scanImport(scan func(dest ...any) error) (Import, error) {
+	var imp Import
+	var supplierID, warehouseID sql.NullInt64
+	err := scan(&imp.ID, &imp.AccessKey, &imp.Number, &imp.Series, &imp.IssuedAt,
+		&imp.SupplierCNPJ, &imp.SupplierName, &supplierID, &imp.Total, &imp.Status, &warehouseID,
+		&imp.Unmatched, &imp.CreatedAt, &imp.ConfirmedAt)
+	if supplierID.Valid {
+		id := int(supplierID.Int64)
+		imp.SupplierID = &id
+	}
+	if warehouseID.Valid {
+		id := int(warehouseID.Int64)
+		imp.WarehouseID = &id
+	}
+	return imp, err
+}
+
+// GetImport busca uma importação pelo ID (nil, nil se não existir), sem os itens
+func (r *Repository) GetImport(ctx context.Context, q queryer, id int64) (*Import, error) {
+	imp, err := scanImport(q.QueryRowContext(ctx,
+		`SELECT `+importColumns+` FROM nfe_imports i WHERE i.id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // importação não encontrada
+		}
+		return nil, fmt.Errorf("erro ao escanear importação da NF-e: %v", err)
+	}
+	return &imp, nil
+}
+
+// ListImports retorna as importações (mais recentes primeiro), filtrando pelo status se informado
+func (r *Repository) ListImports(ctx context.Context, status string) ([]Import, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+importColumns+` FROM nfe_imports i WHERE ? = '' OR i.status = ? ORDER BY i.id DESC`,
+		status, status)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar importações de NF-e: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Import{}
+	for rows.Next() {
+		imp, err := scanImport(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear importação da NF-e: %v", err)
+		}
+		list = append(list, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das importações: %v", err)
+	}
+	return list, nil
+}
+
+// ListItems retorna os itens da importação na ordem da nota
+func (r *Repository) ListItems(ctx context.Context, q queryer, importID int64) ([]Item, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT t.line, COALESCE(t.supplier_code, ''), COALESCE(t.ean, ''), COALESCE(t.description, ''),
+			COALESCE(t.ncm, ''), COALESCE(t.cfop, ''), COALESCE(t.unit, ''), t.quantity, t.unit_cost, t.total,
+			t.product_id, COALESCE(p.name, ''), COALESCE(t.match, ''), t.ignored, t.movement_id
+		FROM nfe_import_items t
+		LEFT JOIN products p ON p.id = t.product_id
+		WHERE t.import_id = ?
+		ORDER BY t.line`, importID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar itens da NF-e: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Item
+	for rows.Next() {
+		var it Item
+		var productID, movementID sql.NullInt64
+		if err := rows.Scan(&it.Line, &it.SupplierCode, &it.EAN, &it.Description, &it.NCM, &it.CFOP, &it.Unit,
+			&it.Quantity, &it.UnitCost, &it.Total, &productID, &it.Product, &it.Match, &it.Ignored, &movementID); err != nil {
+			return nil, fmt.Errorf("erro ao escanear item da NF-e: %v", err)
+		}
+		if productID.Valid {
+			id := int(productID.Int64)
+			it.ProductID = &id
+		}
+		if movementID.Valid {
+			it.MovementID = &movementID.Int64
+		}
+		list = append(list, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos itens da NF-e: %v", err)
+	}
+	return list, nil
+}
+
+// MapItemTx grava o produto (ou o descarte) escolhido para um item; retorna false se o item
+// não existe na importação
+func (r *Repository) MapItemTx(ctx context.Context, tx *sql.Tx, importID int64, line int, productID *int, ignored bool) (bool, error) {
+	match := any(nil)
+	if productID != nil {
+		match = MatchManual
+	}
+	result, err := tx.ExecContext(ctx,
+		`UPDATE nfe_import_items SET product_id = ?, match = ?, ignored = ? WHERE import_id = ? AND line = ?`,
+		productID, match, ignored, importID, line)
+	if err != nil {
+		return false, fmt.Errorf("erro ao atualizar item da NF-e: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar item atualizado: %v", err)
+	}
+	return n > 0, nil
+}
+
+// SetItemMovementTx grava a Entrada postada para o item
+func (r *Repository) SetItemMovementTx(ctx context.Context, tx *sql.Tx, importID int64, line int, movementID int64) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE nfe_import_items SET movement_id = ? WHERE import_id = ? AND line = ?`, movementID, importID, line)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar movimento do item da NF-e: %v", err)
+	}
+	return nil
+}
+
+// LearnSupplierCodeTx grava o código do fornecedor e o custo da nota no vínculo produto-fornecedor,
+// para a próxima nota casar o item sozinha (o código já vinculado não é trocado)
+func (r *Repository) LearnSupplierCodeTx(ctx context.Context, tx *sql.Tx, supplierID, productID int, code string, unitCost float64) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO product_suppliers (supplier_id, product_id, supplier_code, last_cost, last_purchase_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (supplier_id, product_id) DO UPDATE SET
+			supplier_code = COALESCE(product_suppliers.supplier_code, excluded.supplier_code),
+			last_cost = excluded.last_cost,
+			last_purchase_at = excluded.last_purchase_at`,
+		supplierID, productID, nullString(code), unitCost)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar vínculo do produto com o fornecedor: %v", err)
+	}
+	return nil
+}
+
+// ConfirmTx marca a importação como confirmada no depósito
+func (r *Repository) ConfirmTx(ctx context.Context, tx *sql.Tx, id int64, warehouseID int) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE nfe_imports SET status = ?, warehouse_id = ?, confirmed_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		StatusConfirmada, warehouseID, id)
+	if err != nil {
+		return fmt.Errorf("erro ao confirmar importação da NF-e: %v", err)
+	}
+	return nil
+}
+
+// UpdateStatusTx muda o status da importação
+func (r *Repository) UpdateStatusTx(ctx context.Context, tx *sql.Tx, id int64, status string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE nfe_imports SET status = ? WHERE id = ?`, status, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status da importação: %v", err)
+	}
+	return nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,309 @@
+package nfe
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // transação compartilhada com o estoque
+	"fmt"          // para formatação de strings e erros
+	"strconv"      // linhas pendentes na mensagem de erro
+	"strings"      // para filtrar e montar mensagens
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// StockService é o que a importação precisa do estoque: postar as Entradas com custo, em lote,
+// na transação da confirmação
+type StockService interface {
+	// CreateBatchTx aplica os movimentos em ordem na transação do chamador; se uma linha falha
+	// nenhuma entra (Lines[i].MovementID é o movimento da linha i)
+	CreateBatchTx(ctx context.Context, tx *sql.Tx, moves []*stock.Movement) (*stock.BatchResult, error)
+}
+
+var (
+	// ErrJaImportada indica chave de acesso já importada (e não cancelada)
+	ErrJaImportada = apperr.Conflict("NF-e já importada")
+	// ErrNaoPendente indica alteração de importação já confirmada ou cancelada
+	ErrNaoPendente = apperr.Conflict("importação não está pendente")
+	// ErrItensSemProduto indica confirmação com itens ainda sem produto (nem ignorados)
+	ErrItensSemProduto = apperr.Conflict("há itens da NF-e sem produto")
+)
+
+// Service contém as regras de negócio da importação de NF-e
+type Service struct {
+	repo  *Repository  // dependencia do repositorio para persistencia
+	stock StockService // posta as Entradas na confirmação
+}
+
+// NewService cria uma nova instância do serviço de importação de NF-e
+func NewService(repo *Repository, stock StockService) *Service {
+	return &Service{
+		repo:  repo,
+		stock: stock,
+	}
+}
+
+// Import lê o XML da NF-e e grava a importação como PENDENTE, casando cada item com um produto:
+// primeiro pelo código do produto no fornecedor (se o emitente é um fornecedor cadastrado),
+// depois pelo EAN. Nada entra no estoque até a confirmação.
+func (s *Service) Import(ctx context.Context, data []byte) (*Import, error) {
+	imp, err := Parse(data)
+	if err != nil {
+		return nil, apperr.Invalid("%w", err) // XML recusado pelo parser
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := s.repo.ActiveImportIDTx(ctx, tx, imp.AccessKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing != 0 {
+		return nil, fmt.Errorf("%w (importação %d, chave %s)", ErrJaImportada, existing, imp.AccessKey)
+	}
+
+	supplierID, err := s.repo.SupplierIDByCNPJTx(ctx, tx, imp.SupplierCNPJ)
+	if err != nil {
+		return nil, err
+	}
+	if supplierID != 0 {
+		imp.SupplierID = &supplierID
+	}
+
+	for i := range imp.Items {
+		it := &imp.Items[i]
+		productID, match := 0, ""
+		if supplierID != 0 && it.SupplierCode != "" {
+			if productID, err = s.repo.ProductBySupplierCodeTx(ctx, tx, supplierID, it.SupplierCode); err != nil {
+				return nil, err
+			}
+			match = MatchCodigo
+		}
+		if productID == 0 && it.EAN != "" {
+			if productID, err = s.repo.ProductByEANTx(ctx, tx, it.EAN); err != nil {
+				return nil, err
+			}
+			match = MatchEAN
+		}
+		if productID != 0 {
+			it.ProductID, it.Match = &productID, match
+		}
+	}
+
+	id, err := s.repo.CreateImportTx(ctx, tx, imp)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// List retorna as importações (sem itens), filtrando pelo status se informado
+func (s *Service) List(ctx context.Context, status string) ([]Import, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "", StatusPendente, StatusConfirmada, StatusCancelada:
+	default:
+		return nil, apperr.Invalid("status inválido: %s", status)
+	}
+	return s.repo.ListImports(ctx, status)
+}
+
+// Get retorna a importação com os itens
+func (s *Service) Get(ctx context.Context, id int64) (*Import, error) {
+	imp, err := s.repo.GetImport(ctx, s.repo.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp == nil {
+		return nil, apperr.NotFound("importação com ID %d não encontrada", id)
+	}
+	if imp.Items, err = s.repo.ListItems(ctx, s.repo.DB, id); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// MapItem define manualmente o produto de um item da nota, ou marca o item como ignorado
+// (não entra no estoque). Só em importação pendente.
+func (s *Service) MapItem(ctx context.Context, id int64, line int, req MapItemRequest) (*Import, error) {
+	if req.Ignore {
+		req.ProductID = nil
+	} else if req.ProductID == nil {
+		return nil, apperr.Invalid("informe product_id ou ignore")
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.checkPending(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if req.ProductID != nil {
+		exists, err := s.repo.ProductExistsTx(ctx, tx, *req.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, apperr.Invalid("produto %d não existe", *req.ProductID)
+		}
+	}
+	ok, err := s.repo.MapItemTx(ctx, tx, id, line, req.ProductID, req.Ignore)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperr.NotFound("item %d da importação %d: item não encontrado", line, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// Confirm posta a nota no estoque: uma Entrada com custo por item não ignorado (motivo COMPRA,
+// documento "nfe:<chave>"), postadas pelo lote do estoque na mesma transação — ou a nota entra
+// inteira, ou nada.
+// Com o emitente cadastrado como fornecedor, o código e o custo de cada item ficam gravados
+// no vínculo produto-fornecedor (a próxima nota casa sozinha).
+func (s *Service) Confirm(ctx context.Context, id int64, warehouseID int) (*Import, error) {
+	if warehouseID == 0 {
+		warehouseID = DefaultWarehouseID
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	imp, err := s.repo.GetImport(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp == nil {
+		return nil, apperr.NotFound("importação com ID %d não encontrada", id)
+	}
+	if imp.Status != StatusPendente {
+		return nil, fmt.Errorf("%w (status %s)", ErrNaoPendente, imp.Status)
+	}
+	active, err := s.repo.WarehouseActiveTx(ctx, tx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, apperr.Invalid("depósito %d não encontrado ou inativo", warehouseID)
+	}
+
+	items, err := s.repo.ListItems(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, it := range items {
+		if it.ProductID == nil && !it.Ignored {
+			pending = append(pending, strconv.Itoa(it.Line))
+		}
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("%w: itens %s", ErrItensSemProduto, strings.Join(pending, ", "))
+	}
+
+	// a nota pode ter até 990 itens: as Entradas vão em lotes de stock.MaxBatchSize, na mesma transação
+	document := "nfe:" + imp.AccessKey
+	var posted []Item
+	var moves []*stock.Movement
+	for _, it := range items {
+		if it.Ignored {
+			continue
+		}
+		cost := it.UnitCost
+		posted = append(posted, it)
+		moves = append(moves, &stock.Movement{
+			ProductID:   *it.ProductID,
+			WarehouseID: warehouseID,
+			Type:        "Entrada",
+			Quantity:    it.Quantity,
+			UnitCost:    &cost,
+			Reason:      stock.MotivoCompra,
+			Document:    document,
+		})
+	}
+	for start := 0; start < len(moves); start += stock.MaxBatchSize {
+		end := min(start+stock.MaxBatchSize, len(moves))
+		result, err := s.stock.CreateBatchTx(ctx, tx, moves[start:end])
+		if err != nil {
+			for i, line := range result.Lines {
+				if line.Error != "" {
+					return nil, apperr.Invalid("item %d: %s", posted[start+i].Line, line.Error)
+				}
+			}
+			return nil, err
+		}
+		for i, line := range result.Lines {
+			posted[start+i].MovementID = line.MovementID
+		}
+	}
+
+	for _, it := range posted {
+		if err := s.repo.SetItemMovementTx(ctx, tx, id, it.Line, *it.MovementID); err != nil {
+			return nil, err
+		}
+		if imp.SupplierID != nil {
+			if err := s.repo.LearnSupplierCodeTx(ctx, tx, *imp.SupplierID, *it.ProductID, it.SupplierCode, it.UnitCost); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.repo.ConfirmTx(ctx, tx, id, warehouseID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// Cancel descarta uma importação pendente (libera a chave para ser importada de novo)
+func (s *Service) Cancel(ctx context.Context, id int64) error {
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.checkPending(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateStatusTx(ctx, tx, id, StatusCancelada); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return nil
+}
+
+// checkPending garante que a importação existe e está pendente
+func (s *Service) checkPending(ctx context.Context, tx *sql.Tx, id int64) error {
+	imp, err := s.repo.GetImport(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if imp == nil {
+		return apperr.NotFound("importação com ID %d não encontrada", id)
+	}
+	if imp.Status != StatusPendente {
+		return fmt.Errorf("%w (status %s)", ErrNaoPendente, imp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package pricing
+
+import (
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// Handler expõe os endpoints HTTP de tabelas e faixas de preço
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de preço no grupo Echo (ex.: /api/pricing)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	// tabelas de preço (varejo, atacado, construtora...)
+	g.POST("/tiers", h.CreateTier)
+	g.GET("/tiers", h.ListTiers)
+	g.GET("/tiers/:id", h.GetTier)
+	g.PUT("/tiers/:id", h.UpdateTier)
+	// faixas de preço por quantidade de um produto
+	g.GET("/products/:product_id/breaks", h.ProductBreaks)
+	g.PUT("/products/:product_id/breaks", h.SetProductBreaks)
+	// preço que vale para um cliente e uma quantidade
+	g.GET("/quote", h.Quote)
+}
+
+// BreaksRequest substitui as faixas de preço do produto.
+// Ex.: {"breaks": [{"min_quantity": 40, "price": 27.5}, {"tier_id": 3, "min_quantity": 1, "price": 28}]}
+// tier_id é opcional (sem ele a faixa vale para todos os clientes)
+type BreaksRequest struct {
+	Breaks []Break `json:"breaks"`
+}
+
+// CreateTier cria uma tabela de preço. Ex.: {"name": "construtora", "description": "construtoras cadastradas"}
+func (h *Handler) CreateTier(c echo.Context) error {
+	var req Tier
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	id, err := h.svc.CreateTier(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]int64{"id": id})
+}
+
+// ListTiers retorna as tabelas de preço (?ativas=true para só as ativas)
+func (h *Handler) ListTiers(c echo.Context) error {
+	list, err := h.svc.ListTiers(c.Request().Context(), c.QueryParam("ativas") == "true")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GetTier retorna uma tabela de preço por id
+func (h *Handler) GetTier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	t, err := h.svc.GetTier(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, t)
+}
+
+// UpdateTier atualiza a tabela de preço (inclusive ativa/inativa)
+func (h *Handler) UpdateTier(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req Tier
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	req.ID = id
+	if err := h.svc.UpdateTier(c.Request().Context(), &req); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "tabela de preço atualizada com sucesso"})
+}
+
+// ProductBreaks retorna as faixas de preço do produto
+func (h *Handler) ProductBreaks(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID do produto inválido"})
+	}
+	list, err := h.svc.ProductBreaks(c.Request().Context(), productID)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// SetProductBreaks substitui as faixas de preço do produto
+func (h *Handler) SetProductBreaks(c echo.Context) error {
+	productID, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID do produto inválido"})
+	}
+	var req BreaksRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	list, err := h.svc.SetProductBreaks(c.Request().Context(), productID, req.Breaks)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// Quote retorna o preço do produto para o cliente e a quantidade
+// (?product_id=1&customer_id=7&quantity=40; customer_id é opcional e quantity vale 1 se não vier)
+func (h *Handler) Quote(c echo.Context) error {
+	productID, err := strconv.Atoi(c.QueryParam("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "informe o product_id"})
+	}
+	customerID := 0
+	if v := c.QueryParam("customer_id"); v != "" {
+		if customerID, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "customer_id inválido"})
+		}
+	}
+	quantity := 1.0
+	if v := c.QueryParam("quantity"); v != "" {
+		if quantity, err = strconv.ParseFloat(v, 64); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "quantity inválida"})
+		}
+	}
+	q, err := h.svc.Quote(c.Request().Context(), productID, customerID, quantity)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, q)
+}
@@ -0,0 +1,37 @@
+package pricing
+
+// Tier é uma tabela de preço (varejo, atacado, construtora...). O cliente aponta para uma
+// tabela (customers.price_tier_id); cliente sem tabela paga o preço de cadastro do produto
+// ou as faixas por quantidade que valem para todos.
+type Tier struct {
+	ID          int    `json:"id"`   // id auto-incremental (PK)
+	Name        string `json:"name"` // ex.: "construtora" (único, sem diferenciar maiúsculas)
+	Description string `json:"description"`
+	Active      bool   `json:"active"` // tabela inativa deixa de dar preço aos clientes dela
+	CreatedAt   string `json:"created_at"`
+}
+
+// Break é uma faixa de preço do produto: a partir de MinQuantity unidades o preço é Price.
+// TierID 0 vale para todos os clientes (ex.: preço do palete); senão só para os clientes
+// da tabela.
+type Break struct {
+	ID          int     `json:"id"`
+	ProductID   int     `json:"product_id"`
+	TierID      int     `json:"tier_id"`
+	Tier        string  `json:"tier,omitempty"` // nome da tabela (vazio = todos os clientes)
+	MinQuantity float64 `json:"min_quantity"`
+	Price       float64 `json:"price"`
+}
+
+// Quote é o preço que vale para um cliente e uma quantidade (GET /api/pricing/quote).
+// Vale o menor preço entre o de cadastro e as faixas que se aplicam.
+type Quote struct {
+	ProductID  int     `json:"product_id"`
+	Product    string  `json:"product"`
+	CustomerID int     `json:"customer_id,omitempty"`
+	TierID     int     `json:"tier_id,omitempty"` // tabela do cliente
+	Quantity   float64 `json:"quantity"`
+	BasePrice  float64 `json:"base_price"` // preço de cadastro do produto
+	UnitPrice  float64 `json:"unit_price"` // preço aplicado
+	PriceList  string  `json:"price_list"` // tabela/faixa aplicada (vazio = preço de cadastro)
+}
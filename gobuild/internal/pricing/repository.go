@@ -0,0 +1,197 @@
+package pricing
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL de tabelas e faixas de preço
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de preços
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// tierColumns são as colunas lidas por scanTier (mesma ordem)
+const tierColumns = `id, name, COALESCE(description, ''), active, created_at`
+
+// scanTier lê uma tabela de preço de uma linha (Row ou Rows)
+func scanTier(scan func(dest ...any) error) (Tier, error) {
+	var t Tier
+	err := scan(&t.ID, &t.Name, &t.Description, &t.Active, &t.CreatedAt)
+	return t, err
+}
+
+// CreateTier insere uma tabela de preço e retorna o ID gerado
+func (r *Repository) CreateTier(ctx context.Context, t *Tier) (int64, error) {
+	result, err := r.DB.ExecContext(ctx,
+		`INSERT INTO price_tiers (name, description, active) VALUES (?, ?, ?)`,
+		t.Name, nullString(t.Description), t.Active)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir tabela de preço: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID da tabela de preço: %v", err)
+	}
+	return id, nil
+}
+
+// UpdateTier atualiza nome, descrição e ativo da tabela de preço
+func (r *Repository) UpdateTier(ctx context.Context, t *Tier) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE price_tiers SET name = ?, description = ?, active = ? WHERE id = ?`,
+		t.Name, nullString(t.Description), t.Active, t.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar tabela de preço: %v", err)
+	}
+	return nil
+}
+
+// GetTier busca uma tabela de preço pelo ID (nil, nil se não existir)
+func (r *Repository) GetTier(ctx context.Context, id int) (*Tier, error) {
+	t, err := scanTier(r.DB.QueryRowContext(ctx,
+		`SELECT `+tierColumns+` FROM price_tiers WHERE id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // tabela não encontrada
+		}
+		return nil, fmt.Errorf("erro ao escanear tabela de preço: %v", err)
+	}
+	return &t, nil
+}
+
+// ListTiers retorna as tabelas de preço por nome (só as ativas se activeOnly)
+func (r *Repository) ListTiers(ctx context.Context, activeOnly bool) ([]Tier, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+tierColumns+` FROM price_tiers WHERE (? = 0 OR active = 1) ORDER BY name`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar tabelas de preço: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Tier{}
+	for rows.Next() {
+		t, err := scanTier(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear tabela de preço: %v", err)
+		}
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das tabelas de preço: %v", err)
+	}
+	return list, nil
+}
+
+// TierIDByName retorna o ID da tabela com o nome, sem diferenciar maiúsculas (0 se não houver)
+func (r *Repository) TierIDByName(ctx context.Context, name string) (int, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, `SELECT id FROM price_tiers WHERE name = ?`, name).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar tabela de preço pelo nome: %v", err)
+	}
+	return id, nil
+}
+
+// breakColumns são as colunas lidas por scanBreak (mesma ordem; b = product_price_breaks, t = price_tiers)
+const breakColumns = `b.id, b.product_id, b.tier_id, COALESCE(t.name, ''), b.min_quantity, b.price`
+
+// scanBreak lê uma faixa de preço de uma linha (Row ou Rows)
+func scanBreak(scan func(dest ...any) error) (Break, error) {
+	var b Break
+	err := scan(&b.ID, &b.ProductID, &b.TierID, &b.Tier, &b.MinQuantity, &b.Price)
+	return b, err
+}
+
+// ListBreaks retorna as faixas de preço do produto (todos os clientes primeiro, depois por tabela e quantidade)
+func (r *Repository) ListBreaks(ctx context.Context, productID int) ([]Break, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+breakColumns+`
+		FROM product_price_breaks b LEFT JOIN price_tiers t ON t.id = b.tier_id
+		WHERE b.product_id = ?
+		ORDER BY b.tier_id, b.min_quantity`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar faixas de preço: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Break{}
+	for rows.Next() {
+		b, err := scanBreak(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear faixa de preço: %v", err)
+		}
+		list = append(list, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das faixas de preço: %v", err)
+	}
+	return list, nil
+}
+
+// ReplaceBreaksTx apaga as faixas do produto e grava a lista informada
+func (r *Repository) ReplaceBreaksTx(ctx context.Context, tx *sql.Tx, productID int, breaks []Break) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_price_breaks WHERE product_id = ?`, productID); err != nil {
+		return fmt.Errorf("erro ao remover faixas de preço: %v", err)
+	}
+	for _, b := range breaks {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO product_price_breaks (product_id, tier_id, min_quantity, price) VALUES (?, ?, ?, ?)`,
+			productID, b.TierID, b.MinQuantity, b.Price)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir faixa de preço: %v", err)
+		}
+	}
+	return nil
+}
+
+// CustomerTier retorna a tabela de preço do cliente (0 se não tiver tabela ou não existir)
+func (r *Repository) CustomerTier(ctx context.Context, customerID int) (int, error) {
+	var tierID int
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT COALESCE(price_tier_id, 0) FROM customers WHERE id = ?`, customerID).Scan(&tierID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar tabela de preço do cliente: %v", err)
+	}
+	return tierID, nil
+}
+
+// BestBreak retorna a faixa de menor preço que vale para a quantidade: as de todos os clientes
+// e as da tabela tierID, se ela estiver ativa (nil se nenhuma faixa se aplica)
+func (r *Repository) BestBreak(ctx context.Context, productID, tierID int, quantity float64) (*Break, error) {
+	b, err := scanBreak(r.DB.QueryRowContext(ctx,
+		`SELECT `+breakColumns+`
+		FROM product_price_breaks b LEFT JOIN price_tiers t ON t.id = b.tier_id
+		WHERE b.product_id = ? AND b.min_quantity <= ?
+		  AND (b.tier_id = 0 OR (b.tier_id = ? AND t.active = 1))
+		ORDER BY b.price, b.tier_id DESC, b.min_quantity
+		LIMIT 1`, productID, quantity, tierID).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar faixa de preço: %v", err)
+	}
+	return &b, nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,242 @@
+package pricing
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"fmt"     // para formatação de strings e erros
+	"strings" // para limpar os nomes
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/budget" // ProductLite (interface budget.ProductReader)
+)
+
+// ErrNomeDuplicado indica nome de tabela de preço já usado (o handler responde 409)
+var ErrNomeDuplicado = apperr.Conflict("já existe tabela de preço com este nome")
+
+// ProductReader é o que o preço precisa do produto: nome e preço de cadastro
+// (implementado por product.Service.GetByID; o pricing não depende do módulo product)
+type ProductReader interface {
+	GetByID(ctx context.Context, id int) (*budget.ProductLite, error)
+}
+
+// Service contém as regras de tabelas de preço e faixas por quantidade
+type Service struct {
+	repo     *Repository   // dependencia do repositorio para persistencia
+	products ProductReader // preço de cadastro do produto
+}
+
+// NewService cria uma nova instância do serviço de preços
+func NewService(r *Repository, products ProductReader) *Service {
+	return &Service{
+		repo:     r,
+		products: products,
+	}
+}
+
+// validateTier limpa e valida a tabela de preço antes de salvar
+func validateTier(t *Tier) error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Description = strings.TrimSpace(t.Description)
+	if t.Name == "" {
+		return apperr.Invalid("o nome da tabela de preço não pode ser vazio")
+	}
+	return nil
+}
+
+// checkName recusa nome já usado por outra tabela (exceptID é a própria, na atualização)
+func (s *Service) checkName(ctx context.Context, name string, exceptID int) error {
+	id, err := s.repo.TierIDByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if id != 0 && id != exceptID {
+		return fmt.Errorf("%w: %s", ErrNomeDuplicado, name)
+	}
+	return nil
+}
+
+// CreateTier cadastra uma tabela de preço (ativa)
+func (s *Service) CreateTier(ctx context.Context, t *Tier) (int64, error) {
+	if err := validateTier(t); err != nil {
+		return 0, err
+	}
+	if err := s.checkName(ctx, t.Name, 0); err != nil {
+		return 0, err
+	}
+	t.Active = true
+	return s.repo.CreateTier(ctx, t)
+}
+
+// ListTiers retorna as tabelas de preço (só as ativas se activeOnly)
+func (s *Service) ListTiers(ctx context.Context, activeOnly bool) ([]Tier, error) {
+	return s.repo.ListTiers(ctx, activeOnly)
+}
+
+// GetTier retorna uma tabela de preço por ID, ou erro se não encontrada
+func (s *Service) GetTier(ctx context.Context, id int) (*Tier, error) {
+	t, err := s.repo.GetTier(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, apperr.NotFound("tabela de preço com ID %d não encontrada", id)
+	}
+	return t, nil
+}
+
+// UpdateTier atualiza a tabela de preço (inclusive ativa/inativa)
+func (s *Service) UpdateTier(ctx context.Context, t *Tier) error {
+	if err := validateTier(t); err != nil {
+		return err
+	}
+	if _, err := s.GetTier(ctx, t.ID); err != nil {
+		return err
+	}
+	if err := s.checkName(ctx, t.Name, t.ID); err != nil {
+		return err
+	}
+	return s.repo.UpdateTier(ctx, t)
+}
+
+// product busca o produto, com erro se não existir
+func (s *Service) product(ctx context.Context, id int) (*budget.ProductLite, error) {
+	p, err := s.products.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, apperr.NotFound("produto com ID %d não encontrado", id)
+	}
+	return p, nil
+}
+
+// ProductBreaks retorna as faixas de preço do produto
+func (s *Service) ProductBreaks(ctx context.Context, productID int) ([]Break, error) {
+	if _, err := s.product(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListBreaks(ctx, productID)
+}
+
+// SetProductBreaks substitui as faixas de preço do produto pela lista informada
+// (lista vazia remove todas: o produto volta a ter só o preço de cadastro)
+func (s *Service) SetProductBreaks(ctx context.Context, productID int, breaks []Break) ([]Break, error) {
+	if _, err := s.product(ctx, productID); err != nil {
+		return nil, err
+	}
+	type key struct {
+		tierID int
+		min    float64
+	}
+	seen := map[key]bool{}
+	for i, b := range breaks {
+		if b.MinQuantity <= 0 {
+			return nil, apperr.Invalid("faixa %d: quantidade mínima deve ser maior que zero", i+1)
+		}
+		if b.Price <= 0 {
+			return nil, apperr.Invalid("faixa %d: preço deve ser maior que zero", i+1)
+		}
+		if b.TierID != 0 {
+			t, err := s.repo.GetTier(ctx, b.TierID)
+			if err != nil {
+				return nil, err
+			}
+			if t == nil {
+				return nil, apperr.Invalid("faixa %d: tabela de preço %d não existe", i+1, b.TierID)
+			}
+		}
+		k := key{b.TierID, b.MinQuantity}
+		if seen[k] {
+			return nil, apperr.Invalid("faixa %d: tabela %d já tem faixa a partir de %g", i+1, b.TierID, b.MinQuantity)
+		}
+		seen[k] = true
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.ReplaceBreaksTx(ctx, tx, productID, breaks); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.repo.ListBreaks(ctx, productID)
+}
+
+// priceList descreve a faixa aplicada. Ex.: "construtora", "atacado a partir de 40",
+// "a partir de 40" (faixa para todos os clientes)
+func priceList(b *Break) string {
+	switch {
+	case b.Tier == "":
+		return fmt.Sprintf("a partir de %g", b.MinQuantity)
+	case b.MinQuantity <= 1:
+		return b.Tier
+	}
+	return fmt.Sprintf("%s a partir de %g", b.Tier, b.MinQuantity)
+}
+
+// resolve escolhe o preço do produto para o cliente e a quantidade: o menor entre o preço de
+// cadastro, as faixas para todos os clientes e as da tabela do cliente
+func (s *Service) resolve(ctx context.Context, p *budget.ProductLite, customerID int, quantity float64) (*Quote, error) {
+	q := &Quote{
+		ProductID:  p.ID,
+		Product:    p.Name,
+		CustomerID: customerID,
+		Quantity:   quantity,
+		BasePrice:  p.Price,
+		UnitPrice:  p.Price,
+	}
+	if customerID != 0 {
+		tierID, err := s.repo.CustomerTier(ctx, customerID)
+		if err != nil {
+			return nil, err
+		}
+		q.TierID = tierID
+	}
+	best, err := s.repo.BestBreak(ctx, p.ID, q.TierID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if best != nil && best.Price < p.Price {
+		q.UnitPrice = best.Price
+		q.PriceList = priceList(best)
+	}
+	return q, nil
+}
+
+// Quote retorna o preço do produto para o cliente (opcional) e a quantidade
+func (s *Service) Quote(ctx context.Context, productID, customerID int, quantity float64) (*Quote, error) {
+	if quantity <= 0 {
+		return nil, apperr.Invalid("quantidade deve ser maior que zero")
+	}
+	p, err := s.product(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolve(ctx, p, customerID, quantity)
+}
+
+// GetPrice implementa a interface budget.ProductReader: o produto com o preço que vale para o
+// cliente e a quantidade (nil se o produto não existe)
+func (s *Service) GetPrice(ctx context.Context, productID, customerID int, quantity float64) (*budget.ProductLite, error) {
+	p, err := s.products.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	q, err := s.resolve(ctx, p, customerID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return &budget.ProductLite{
+		ID:        p.ID,
+		Name:      p.Name,
+		Price:     q.UnitPrice,
+		PriceList: q.PriceList,
+	}, nil
+}
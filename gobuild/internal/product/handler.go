@@ -1,6 +1,7 @@
 package product
 
 import (
+	"errors"   // para comparar erros do serviço
 	"net/http" // para constantes de status HTTP
 	"strconv"  // para conversão de string para int
 
@@ -81,6 +82,13 @@ func (h *Handler) Get(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, p)
 }
+// UpdateRequest é o corpo do PUT: o cadastro do produto sem o estoque.
+// Estoque é ponteiro só para saber se o campo veio no JSON (vindo, é recusado).
+type UpdateRequest struct {
+	Produto
+	Estoque *float64 `json:"estoque"`
+}
+
 // Update atualiza um produto por id.
 func (h *Handler) Update(c echo.Context) error {
 	// Lê parâmetro :id da URL
@@ -90,17 +98,21 @@ func (h *Handler) Update(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
 	}
 
-	// bind do JSON para struct Produto
-	var req Produto
+	// bind do JSON para o cadastro do produto (estoque à parte, para saber se veio)
+	var req UpdateRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
 	}
+	// estoque só muda por movimentação: o campo é recusado com qualquer valor (inclusive 0)
+	if req.Estoque != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": ErrEstoqueDireto.Error()})
+	}
 
 	//garante que o id do payload seja o mesmo do URL
 	req.ID = id
 
 	//chama o serviço para atualizar o produto
-	if err := h.svc.Update(c.Request().Context(), &req); err != nil {
+	if err := h.svc.Update(c.Request().Context(), &req.Produto); err != nil {
 		// se o erro indicar que o produto não foi encontrado, retorna 404
 		if err.Error() == "produto com ID "+strconv.Itoa(id)+" não encontrado" {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
@@ -112,7 +124,7 @@ func (h *Handler) Update(c echo.Context) error {
 }
 
 // Delete remove um produto por id.
-// Retorna 204 No Content em caso de sucesso (sem corpo) ou 400/404/500 em caso de erro.
+// Retorna 204 No Content em caso de sucesso (sem corpo) ou 400/404/409/500 em caso de erro.
 func (h *Handler) Delete(c echo.Context) error {
 	// Lê parâmetro :id da URL
 	idStr := c.Param("id")
@@ -127,6 +139,10 @@ func (h *Handler) Delete(c echo.Context) error {
 		if err.Error() == "produto com ID "+strconv.Itoa(id)+" não encontrado" {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
 		}
+		// produto com histórico de estoque: 409
+		if errors.Is(err, ErrProdutoEmUso) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "falha ao deletar produto: " + err.Error()})
 	}
 	//retorna 204 No Content em caso de sucesso
@@ -153,9 +169,6 @@ func (h *Handler) GetStock(c echo.Context) error {
 			"error" : err.Error(),
 		})
 	}
-	// Retorna apenas o que o cliente precisa
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"product_id": id,
-		"estoque": stock,
-	})
-}
\ No newline at end of file
+	// Retorna apenas o que o cliente precisa (estoque, reservado e disponível)
+	return c.JSON(http.StatusOK, stock)
+}
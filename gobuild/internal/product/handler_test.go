@@ -0,0 +1,111 @@
+package product_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/database"
+	"github.com/EtraudBits/golangProject/gobuild/internal/product"
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+	"github.com/labstack/echo/v4"
+)
+
+// produtoSetup abre um banco novo e registra as rotas de produto
+func produtoSetup(t *testing.T) (*echo.Echo, *product.Service, *product.Repository) {
+	t.Helper()
+	if err := database.ConnectPath(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("erro ao conectar: %v", err)
+	}
+	t.Cleanup(func() { _ = database.DB.Close() })
+
+	repo := product.NewRepository(database.DB)
+	stockSvc := stock.NewService(database.DB, stock.NewRepository(database.DB), repo.GetStockTx, repo.UpdateStockTx)
+	svc := product.NewService(repo, stockSvc)
+	e := echo.New()
+	product.NewHandler(svc).RegisterRoutes(e.Group("/api/products"))
+	return e, svc, repo
+}
+
+// criarProduto cadastra um produto com o estoque inicial informado
+func criarProduto(t *testing.T, svc *product.Service, estoque float64) int64 {
+	t.Helper()
+	id, err := svc.Create(context.Background(), &product.Produto{
+		Name: "Cimento CP-II 50kg", Preco: 25.5, Estoque: estoque, Unidade: "saco", Categoria: "Materiais",
+	})
+	if err != nil {
+		t.Fatalf("erro ao inserir produto: %v", err)
+	}
+	return id
+}
+
+// requisitar faz a chamada na API e devolve o status
+func requisitar(e *echo.Echo, method, path, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// TestUpdateRecusaEstoque: o PUT do produto não aceita "estoque" com nenhum valor (inclusive 0
+// e o próprio estoque atual); sem o campo, o cadastro é atualizado e o estoque fica como estava
+func TestUpdateRecusaEstoque(t *testing.T) {
+	e, svc, repo := produtoSetup(t)
+	id := criarProduto(t, svc, 10)
+
+	put := func(body string) int {
+		return requisitar(e, http.MethodPut, fmt.Sprintf("/api/products/%d", id), body)
+	}
+
+	const cadastro = `"name":"Cimento CP-II 50kg","preco":27,"unidade":"saco","categoria":"Materiais"`
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"estoque diferente", `{` + cadastro + `,"estoque":999}`, http.StatusBadRequest},
+		{"estoque zero", `{` + cadastro + `,"estoque":0}`, http.StatusBadRequest},
+		{"estoque igual ao atual", `{` + cadastro + `,"estoque":10}`, http.StatusBadRequest},
+		{"sem estoque", `{` + cadastro + `}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := put(tt.body); got != tt.want {
+			t.Errorf("%s: status %d, esperado %d", tt.name, got, tt.want)
+		}
+	}
+
+	p, err := repo.GetByID(context.Background(), int(id))
+	if err != nil {
+		t.Fatalf("erro ao ler produto: %v", err)
+	}
+	if p.Estoque != 10 || p.Preco != 27 {
+		t.Errorf("estoque/preço = %v/%v, esperado 10/27", p.Estoque, p.Preco)
+	}
+}
+
+// TestDeleteComHistorico: produto com movimento de estoque não é apagado (409) e continua
+// cadastrado; produto sem movimento, saldo nem reserva é apagado
+func TestDeleteComHistorico(t *testing.T) {
+	e, svc, repo := produtoSetup(t)
+	comEstoque := criarProduto(t, svc, 10)
+	semEstoque := criarProduto(t, svc, 0)
+
+	if got := requisitar(e, http.MethodDelete, fmt.Sprintf("/api/products/%d", comEstoque), ""); got != http.StatusConflict {
+		t.Errorf("produto com movimento: status %d, esperado 409", got)
+	}
+	if p, err := repo.GetByID(context.Background(), int(comEstoque)); err != nil || p == nil || p.Estoque != 10 {
+		t.Errorf("produto com movimento deveria continuar com estoque 10: %+v, %v", p, err)
+	}
+
+	if got := requisitar(e, http.MethodDelete, fmt.Sprintf("/api/products/%d", semEstoque), ""); got != http.StatusNoContent {
+		t.Errorf("produto sem movimento: status %d, esperado 204", got)
+	}
+	if got := requisitar(e, http.MethodDelete, fmt.Sprintf("/api/products/%d", semEstoque), ""); got != http.StatusNotFound {
+		t.Errorf("produto já apagado: status %d, esperado 404", got)
+	}
+}
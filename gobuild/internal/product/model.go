@@ -3,11 +3,39 @@ package product
 // Product representa um produto no sistema
 // cada campo tem tags `json` para mapear automaticamente entre JSON e struct
 type Produto struct {
-	ID int `json:"id"` // id auto-incremental (PK)
-	Name string `json:"name"` // nome do produto (ex.: "cimento cp-II 50kg")
-	Preco float64 `json:"preco"` // preço do produto (ex.: 25.50)
-	Estoque float64 `json:"estoque"` // quantidade em estoque (ex.: 100.0)
-	Unidade string `json:"unidade"` // unidade de medida (ex.: "kg", "m2", "un")
-	Categoria string `json:"categoria"` // categoria do produto (ex.: "materiais de construção")
-	DataCriacao string `json:"data_criacao"` // timestamp de criação do registro (ex.: "2024-06-01 12:00:00")
-}
\ No newline at end of file
+	ID          int     `json:"id"`           // id auto-incremental (PK)
+	Name        string  `json:"name"`         // nome do produto (ex.: "cimento cp-II 50kg")
+	Preco       float64 `json:"preco"`        // preço do produto (ex.: 25.50)
+	Estoque     float64 `json:"estoque"`      // quantidade em estoque (ex.: 100.0)
+	Unidade     string  `json:"unidade"`      // unidade de medida (ex.: "kg", "m2", "un")
+	Categoria   string  `json:"categoria"`    // categoria do produto (ex.: "materiais de construção")
+	DataCriacao string  `json:"data_criacao"` // timestamp de criação do registro (ex.: "2024-06-01 12:00:00")
+	CustoMedio  float64 `json:"custo_medio"`  // custo médio ponderado (calculado pelas entradas; somente leitura)
+	EAN         string  `json:"ean"`          // código de barras EAN/GTIN (opcional; casa os itens da NF-e)
+
+	// níveis de reposição (0 = não configurado): abaixo do ponto de pedido o produto
+	// aparece em GET /api/stock/alerts com a quantidade sugerida para chegar ao máximo
+	EstoqueMinimo float64 `json:"estoque_minimo"` // estoque de segurança
+	EstoqueMaximo float64 `json:"estoque_maximo"` // até onde repor
+	PontoPedido   float64 `json:"ponto_pedido"`   // estoque que dispara o alerta de reposição
+	PrazoEntrega  int     `json:"prazo_entrega"`  // prazo de entrega do fornecedor em dias (relatório de reposição)
+}
+
+// StockInfo é a resposta de GET /api/products/:id/stock
+// disponível = estoque em mãos - reservado (reservas ativas de orçamentos aprovados)
+type StockInfo struct {
+	ProductID  int              `json:"product_id"`
+	Estoque    float64          `json:"estoque"`    // estoque físico (products.stock)
+	Reservado  float64          `json:"reservado"`  // soma das reservas ativas
+	Disponivel float64          `json:"disponivel"` // estoque - reservado
+	Depositos  []WarehouseStock `json:"depositos"`  // mesmo cálculo, por depósito
+}
+
+// WarehouseStock é o estoque de um produto em um depósito
+type WarehouseStock struct {
+	WarehouseID int     `json:"warehouse_id"`
+	Warehouse   string  `json:"warehouse"`
+	Estoque     float64 `json:"estoque"`
+	Reservado   float64 `json:"reservado"`
+	Disponivel  float64 `json:"disponivel"`
+}
@@ -6,6 +6,10 @@ import (
 	"fmt"          // para formatação de strings e erros
 )
 
+// defaultWarehouseID é o depósito padrão criado pela migração 0005 (recebe o estoque
+// inicial informado no cadastro do produto)
+const defaultWarehouseID = 1
+
 type Repository struct {
 	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
 }
@@ -17,12 +21,15 @@ func NewRepository(db *sql.DB) *Repository {
 	}
 }
 
-// create insere um novo produto no banco de dados e retorna o ID inserido.
-func (r *Repository) Create(ctx context.Context, p *Produto) (int64, error) {
+// CreateTx insere um novo produto na transação do chamador e retorna o ID inserido.
+// O produto nasce com estoque zero (e saldo zero no depósito padrão): o estoque inicial
+// entra depois como movimento de Entrada, para o histórico (ledger) explicar o saldo.
+func (r *Repository) CreateTx(ctx context.Context, tx *sql.Tx, p *Produto) (int64, error) {
 	// Query INSERT com Placeholders (compativel com SQLite)
-	result, err := r.DB.ExecContext(ctx,
- `INSERT INTO products (name, price, stock, unit, category, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
-		p.Name, p.Preco, p.Estoque, p.Unidade, p.Categoria, &p.DataCriacao)
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO products (name, price, stock, unit, category, created_at, min_stock, max_stock, reorder_point, lead_time_days, ean)
+		VALUES (?, ?, 0, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.Name, p.Preco, p.Unidade, p.Categoria, &p.DataCriacao, p.EstoqueMinimo, p.EstoqueMaximo, p.PontoPedido, p.PrazoEntrega, nullString(p.EAN))
 	if err != nil {
 		return 0, fmt.Errorf("erro ao inserir produto: %v", err)
 	}
@@ -32,12 +39,22 @@ func (r *Repository) Create(ctx context.Context, p *Produto) (int64, error) {
 		return 0, fmt.Errorf("erro ao obter ID do produto inserido: %v", err)
 	}
 
+	// saldo (zerado) no depósito padrão
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO stock_balances (warehouse_id, product_id, quantity) VALUES (?, ?, 0)`,
+		defaultWarehouseID, id)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir saldo inicial do produto: %v", err)
+	}
 	return id, nil
 }
 
+// productColumns são as colunas lidas em GetAll/GetByID (mesma ordem do Scan)
+const productColumns = `id, name, price, stock, unit, category, created_at, average_cost, min_stock, max_stock, reorder_point, lead_time_days, COALESCE(ean, '')`
+
 func (r *Repository) GetAll(ctx context.Context) ([]Produto, error) {
 // executa a query SELECT para buscar todos os produtos
-rows, err := r.DB.QueryContext(ctx,  `SELECT id, name, price, stock, unit, category, created_at FROM products`)
+rows, err := r.DB.QueryContext(ctx,  `SELECT `+productColumns+` FROM products`)
 if err != nil {
 	return nil, fmt.Errorf("erro ao buscar produtos: %v", err)
 }
@@ -49,7 +66,8 @@ var produtos []Produto
 for rows.Next() {
 
 	var p Produto
-	if err := rows.Scan(&p.ID, &p.Name, &p.Preco, &p.Estoque, &p.Unidade, &p.Categoria, &p.DataCriacao); err != nil {
+	if err := rows.Scan(&p.ID, &p.Name, &p.Preco, &p.Estoque, &p.Unidade, &p.Categoria, &p.DataCriacao, &p.CustoMedio,
+		&p.EstoqueMinimo, &p.EstoqueMaximo, &p.PontoPedido, &p.PrazoEntrega, &p.EAN); err != nil {
 		return nil, fmt.Errorf("erro ao escanear produto: %v", err)
 	}
 	produtos = append(produtos, p)
@@ -63,10 +81,11 @@ return produtos, nil
 // GetByID busca um produto pelo seu ID (chave primária)
 func (r *Repository) GetByID(ctx context.Context, id int) (*Produto, error) {
 
-	row := r.DB.QueryRowContext(ctx, `SELECT id, name, price, stock, unit, category, created_at FROM products WHERE id = ?`, id)
+	row := r.DB.QueryRowContext(ctx, `SELECT `+productColumns+` FROM products WHERE id = ?`, id)
 
 	var p Produto
-	if err := row.Scan(&p.ID, &p.Name, &p.Preco, &p.Estoque, &p.Unidade, &p.Categoria, &p.DataCriacao); err != nil {
+	if err := row.Scan(&p.ID, &p.Name, &p.Preco, &p.Estoque, &p.Unidade, &p.Categoria, &p.DataCriacao, &p.CustoMedio,
+		&p.EstoqueMinimo, &p.EstoqueMaximo, &p.PontoPedido, &p.PrazoEntrega, &p.EAN); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // produto não encontrado
 		}
@@ -75,12 +94,14 @@ func (r *Repository) GetByID(ctx context.Context, id int) (*Produto, error) {
 	return &p, nil
 }
 
-// Update atualiza os dados de um produto existente no banco de dados (atualiza pelo ID)
+// Update atualiza os dados cadastrais de um produto existente (atualiza pelo ID).
+// O estoque não é alterado aqui: só muda por movimentações do módulo de estoque.
 func (r *Repository) Update(ctx context.Context, p *Produto) error {
-
 	_, err := r.DB.ExecContext(ctx,
-		`UPDATE products SET name = ?, price = ?, stock = ?, unit = ?, category = ? WHERE id = ?`,
-		p.Name, p.Preco, p.Estoque, p.Unidade, p.Categoria, p.ID)
+		`UPDATE products SET name = ?, price = ?, unit = ?, category = ?,
+			min_stock = ?, max_stock = ?, reorder_point = ?, lead_time_days = ?, ean = ? WHERE id = ?`,
+		p.Name, p.Preco, p.Unidade, p.Categoria, p.EstoqueMinimo, p.EstoqueMaximo, p.PontoPedido, p.PrazoEntrega,
+		nullString(p.EAN), p.ID)
 	if err != nil {
 		return fmt.Errorf("erro ao atualizar produto: %v", err)
 	}
@@ -88,11 +109,126 @@ func (r *Repository) Update(ctx context.Context, p *Produto) error {
 }
 
 func (r *Repository) Delete (ctx context.Context, id int) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	// produto com histórico de estoque não é apagado: o kardex, os lotes, as camadas de custo e
+	// as reservas ficariam apontando para um produto inexistente
+	var inUse bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM stock_movements WHERE product_id = ?)
+		     OR EXISTS (SELECT 1 FROM stock_balances WHERE product_id = ? AND quantity <> 0)
+		     OR EXISTS (SELECT 1 FROM stock_reservations WHERE product_id = ? AND status IN ('ATIVA', 'EXPIRADA'))`,
+		id, id, id).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar movimentos do produto: %v", err)
+	}
+	if inUse {
+		return ErrProdutoEmUso
+	}
+
 	// executa a query DELETE para remover o produto pelo ID
-	_, err := r.DB.ExecContext(ctx, `DELETE FROM products WHERE id = ?`, id	)	
+	_, err = tx.ExecContext(ctx, `DELETE FROM products WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("erro ao deletar produto: %v", err)
 	}
-	return nil	
+	// remove também os saldos por depósito
+	_, err = tx.ExecContext(ctx, `DELETE FROM stock_balances WHERE product_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao deletar saldos do produto: %v", err)
+	}
+	// e as faixas de preço
+	_, err = tx.ExecContext(ctx, `DELETE FROM product_price_breaks WHERE product_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao deletar faixas de preço do produto: %v", err)
+	}
+	// os vínculos com fornecedores e a política de estoque negativo do produto
+	_, err = tx.ExecContext(ctx, `DELETE FROM product_suppliers WHERE product_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao deletar fornecedores do produto: %v", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM stock_negative_policies WHERE scope = 'PRODUTO' AND ref = CAST(? AS TEXT)`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao deletar política de estoque do produto: %v", err)
+	}
+	return tx.Commit()
+}
+
+// GetStockTx lê o estoque atual de um produto dentro de uma transação (usado pelo módulo stock).
+// Retorna erro se o produto não existir, para não gerar movimento de produto inexistente.
+func (r *Repository) GetStockTx(ctx context.Context, tx *sql.Tx, id int) (float64, error) {
+	var stock float64
+	err := tx.QueryRowContext(ctx, `SELECT stock FROM products WHERE id = ?`, id).Scan(&stock)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("produto com ID %d não encontrado", id)
+		}
+		return 0, fmt.Errorf("erro ao ler estoque do produto: %v", err)
+	}
+	return stock, nil
+}
+
+// UpdateStockTx grava o novo estoque de um produto dentro de uma transação (usado pelo módulo stock)
+func (r *Repository) UpdateStockTx(ctx context.Context, tx *sql.Tx, id int, newStock float64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE products SET stock = ? WHERE id = ?`, newStock, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar estoque do produto: %v", err)
+	}
+	return nil
+}
+
+// GetReserved soma as reservas ATIVAS e não vencidas de um produto (tabela stock_reservations)
+func (r *Repository) GetReserved(ctx context.Context, id int) (float64, error) {
+	var reserved float64
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations
+		WHERE product_id = ? AND status = 'ATIVA'
+		  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`, id).Scan(&reserved)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao somar reservas do produto: %v", err)
+	}
+	return reserved, nil
 }
 
+// GetWarehouseStock retorna, para cada depósito com saldo do produto, o saldo e o reservado
+func (r *Repository) GetWarehouseStock(ctx context.Context, id int) ([]WarehouseStock, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT b.warehouse_id, w.name, b.quantity,
+			COALESCE((SELECT SUM(sr.quantity) FROM stock_reservations sr
+				WHERE sr.product_id = b.product_id AND sr.warehouse_id = b.warehouse_id
+				  AND sr.status = 'ATIVA'
+				  AND (sr.expires_at IS NULL OR sr.expires_at > CURRENT_TIMESTAMP)), 0)
+		FROM stock_balances b
+		JOIN warehouses w ON w.id = b.warehouse_id
+		WHERE b.product_id = ?
+		ORDER BY b.warehouse_id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar saldos por depósito: %v", err)
+	}
+	defer rows.Close()
+
+	var list []WarehouseStock
+	for rows.Next() {
+		var ws WarehouseStock
+		if err := rows.Scan(&ws.WarehouseID, &ws.Warehouse, &ws.Estoque, &ws.Reservado); err != nil {
+			return nil, fmt.Errorf("erro ao escanear saldo por depósito: %v", err)
+		}
+		ws.Disponivel = ws.Estoque - ws.Reservado
+		list = append(list, ws)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos saldos: %v", err)
+	}
+	return list, nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
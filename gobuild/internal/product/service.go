@@ -1,20 +1,36 @@
 package product
 
 import (
-	"context" // Para passar contexto em operações de banco de dados
-	"errors"  // para manipulação de erros
-	"fmt"     // para formatação de strings e erros
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // transação compartilhada com o estoque
+	"errors"       // para manipulação de erros
+	"fmt"          // para formatação de strings e erros
+	"strings"      // para limpar o EAN
 
 	"github.com/EtraudBits/golangProject/gobuild/internal/budget"
 )
 
+// StockService é o que o produto precisa do estoque: lançar o estoque inicial como Entrada
+// na mesma transação do cadastro (o produto não depende diretamente do módulo de estoque)
+type StockService interface {
+	EntradaTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, quantity float64, reason, document string) error
+}
+
+// ErrEstoqueDireto indica tentativa de mudar o estoque pelo cadastro do produto (PUT com "estoque")
+var ErrEstoqueDireto = errors.New("o estoque não pode ser alterado pelo cadastro do produto; use as movimentações de /api/stock")
+
+// ErrProdutoEmUso indica tentativa de apagar produto com movimentos, saldo ou reservas em aberto
+var ErrProdutoEmUso = errors.New("o produto tem movimentos de estoque, saldo ou reservas e não pode ser apagado")
+
 type Service struct {
-	repo *Repository // dependencia do repositorio para persistencia
+	repo  *Repository  // dependencia do repositorio para persistencia
+	stock StockService // lança o estoque inicial
 }
 // NewService cria uma nova instância do serviço de produtos
-func NewService(r *Repository) *Service {
+func NewService(r *Repository, stock StockService) *Service {
 	return &Service{
-		repo: r,
+		repo:  r,
+		stock: stock,
 	}		
 }
 // validateProduto realiza validações basicas no produto antes de salvar/atualizar.
@@ -39,6 +55,21 @@ func (s *Service) ValidateProduto(p *Produto) error {
 	if p.Categoria == "" {
 		return errors.New("a categoria do produto não pode ser vazia")
 	}
+	// níveis de reposição: nenhum negativo; com máximo, mínimo e ponto de pedido cabem nele
+	if p.EstoqueMinimo < 0 || p.EstoqueMaximo < 0 || p.PontoPedido < 0 {
+		return errors.New("estoque mínimo, máximo e ponto de pedido não podem ser negativos")
+	}
+	if p.EstoqueMaximo > 0 && (p.EstoqueMinimo > p.EstoqueMaximo || p.PontoPedido > p.EstoqueMaximo) {
+		return errors.New("estoque mínimo e ponto de pedido não podem passar do estoque máximo")
+	}
+	if p.PrazoEntrega < 0 {
+		return errors.New("o prazo de entrega não pode ser negativo")
+	}
+	// EAN/GTIN: só dígitos, com 8, 12, 13 ou 14 posições
+	p.EAN = strings.TrimSpace(p.EAN)
+	if p.EAN != "" && !validEAN(p.EAN) {
+		return fmt.Errorf("EAN inválido: %s", p.EAN)
+	}
 	return nil // todas as validações passaram
 
 }
@@ -58,8 +89,28 @@ func (s *Service) Create(ctx context.Context, p *Produto) (int64, error) {
 	if err := s.ValidateProduto(p); err != nil {
 		return 0, fmt.Errorf("validação do produto falhou: %v", err)
 	}
-	//cria via repo.
-	return s.repo.Create(ctx, p)
+	// cadastro e estoque inicial na mesma transação: ou entram os dois, ou nada
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	id, err := s.repo.CreateTx(ctx, tx, p)
+	if err != nil {
+		return 0, err
+	}
+	// o estoque inicial vira uma Entrada no depósito padrão (fica no histórico)
+	if p.Estoque > 0 {
+		if err := s.stock.EntradaTx(ctx, tx, defaultWarehouseID, int(id), p.Estoque, "", fmt.Sprintf("produto:%d", id)); err != nil {
+			return 0, fmt.Errorf("erro ao lançar estoque inicial: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return id, nil
 }
 
 // Get retorna produto por ID, ou erro se não encontrado
@@ -92,7 +143,8 @@ existing, err := s.repo.GetByID(ctx, p.ID)
 		return fmt.Errorf("produto com ID %d não encontrado", p.ID)
 	}
 
-	//atualiza apenas se o produto existir
+	//atualiza apenas se o produto existir (p.Estoque é ignorado: estoque só muda por
+	// movimentação e o handler recusa o campo com ErrEstoqueDireto)
 	return s.repo.Update(ctx, p)
 }
 
@@ -111,23 +163,38 @@ func (s *Service) Delete(ctx context.Context, id int) error {
 	return s.repo.Delete(ctx, id)
 }
 // --- Função para pesquisar o estoque de cada produto --
-// GetStock retorna apenas o estoque atual de um produto
-func (s *Service) GetStock(ctx context.Context, id int) (float64, error) {
+// GetStock retorna o estoque de um produto: em mãos, reservado e disponível
+func (s *Service) GetStock(ctx context.Context, id int) (*StockInfo, error) {
 	// Busca o produto pelo ID usando o repositório
 	p, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return 0, nil
+		return nil, fmt.Errorf("erro ao obter produto: %v", err)
 	}
 	// Se o produto não existir, retorna erro
 	if p == nil {
-		return 0, errors.New("Produto não encontrado")
+		return nil, errors.New("Produto não encontrado")
+	}
+	// soma o que está reservado para orçamentos aprovados
+	reserved, err := s.repo.GetReserved(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	// Retorna apenas o estoque
-	return p.Estoque, nil
+	// detalhe por depósito
+	byWarehouse, err := s.repo.GetWarehouseStock(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &StockInfo{
+		ProductID:  p.ID,
+		Estoque:    p.Estoque,
+		Reservado:  reserved,
+		Disponivel: p.Estoque - reserved,
+		Depositos:  byWarehouse,
+	}, nil
 }
 
-// GetByID implementa a interface budget.ProductReader
-// Ele adapta o Produto completo para um ProductLite (usado pelo budget)
+// GetByID implementa a interface pricing.ProductReader
+// Ele adapta o Produto completo para um ProductLite (preço de cadastro; o pricing resolve o preço do budget)
 func (s *Service) GetByID(ctx context.Context, id int) (*budget.ProductLite, error) {
 	// reutiza a logica que já existe
 	p, err := s.Get(ctx, id)
@@ -143,4 +210,26 @@ func (s *Service) GetByID(ctx context.Context, id int) (*budget.ProductLite, err
 		Name: p.Name,
 		Price: p.Preco,
 	}, nil
-}
\ No newline at end of file
+}
+// validEAN confere o formato do código de barras (GTIN-8, 12, 13 ou 14) e o dígito verificador
+func validEAN(v string) bool {
+	switch len(v) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	sum := 0
+	for i := len(v) - 2; i >= 0; i-- {
+		d := int(v[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		// pesos 3 e 1 alternados, começando por 3 no dígito ao lado do verificador
+		if (len(v)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	check := int(v[len(v)-1] - '0')
+	return check >= 0 && check <= 9 && (10-sum%10)%10 == check
+}
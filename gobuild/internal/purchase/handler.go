@@ -0,0 +1,136 @@
+package purchase
+
+import (
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// Handler expõe os endpoints HTTP de pedidos de compra
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de pedido de compra no grupo Echo (ex.: /api/purchase-orders)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("", h.Create)
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.POST("/:id/receive", h.Receive)
+	g.POST("/:id/cancel", h.Cancel)
+}
+
+// CreateOrderRequest abre um pedido de compra.
+// Ex.: {"supplier_id": 1, "expected_at": "2026-11-05", "items": [{"product_id": 1, "quantity": 200, "unit_cost": 28.9}]}
+// warehouse_id é opcional (padrão: depósito 1); unit_cost é opcional se o produto já foi
+// comprado do fornecedor (usa o último custo)
+type CreateOrderRequest struct {
+	SupplierID  int         `json:"supplier_id"`
+	WarehouseID int         `json:"warehouse_id"`
+	ExpectedAt  string      `json:"expected_at"`
+	Notes       string      `json:"notes"`
+	Items       []OrderLine `json:"items"`
+}
+
+// OrderLine é um item pedido
+type OrderLine struct {
+	ProductID    int      `json:"product_id"`
+	Quantity     float64  `json:"quantity"`
+	UnitCost     *float64 `json:"unit_cost"`
+	SupplierCode string   `json:"supplier_code"`
+}
+
+// ReceiveRequest registra um recebimento.
+// Ex.: {"invoice": "NF 4512", "items": [{"product_id": 1, "quantity": 120}]}
+// Sem items recebe tudo o que falta; warehouse_id (opcional) troca o depósito que recebe
+type ReceiveRequest struct {
+	WarehouseID int           `json:"warehouse_id"`
+	Invoice     string        `json:"invoice"`
+	Items       []ReceiveLine `json:"items"`
+}
+
+// ReceiveLine é a quantidade recebida de um item (unit_cost opcional: padrão é o custo do pedido)
+type ReceiveLine struct {
+	ProductID int      `json:"product_id"`
+	Quantity  float64  `json:"quantity"`
+	UnitCost  *float64 `json:"unit_cost"`
+}
+
+// Create abre um pedido de compra
+func (h *Handler) Create(c echo.Context) error {
+	var req CreateOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	o, err := h.svc.Create(c.Request().Context(), req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, o)
+}
+
+// List retorna os pedidos. Query: status e supplier_id
+func (h *Handler) List(c echo.Context) error {
+	supplierID := 0
+	if v := c.QueryParam("supplier_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "supplier_id inválido"})
+		}
+		supplierID = id
+	}
+	list, err := h.svc.List(c.Request().Context(), c.QueryParam("status"), supplierID)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// Get retorna o pedido com os itens e os recebimentos
+func (h *Handler) Get(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	o, err := h.svc.Get(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, o)
+}
+
+// Receive registra o recebimento (total ou parcial) e posta as Entradas no estoque
+func (h *Handler) Receive(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req ReceiveRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	o, err := h.svc.Receive(c.Request().Context(), id, req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, o)
+}
+
+// Cancel cancela o que falta receber do pedido
+func (h *Handler) Cancel(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	if err := h.svc.Cancel(c.Request().Context(), id); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "pedido de compra cancelado"})
+}
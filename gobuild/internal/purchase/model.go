@@ -0,0 +1,55 @@
+package purchase
+
+// Status de um pedido de compra
+//
+//	ABERTO -> PARCIALMENTE_RECEBIDO -> RECEBIDO
+//	ABERTO | PARCIALMENTE_RECEBIDO -> CANCELADO (o que já foi recebido continua no estoque)
+const (
+	StatusAberto    = "ABERTO"
+	StatusParcial   = "PARCIALMENTE_RECEBIDO"
+	StatusRecebido  = "RECEBIDO"
+	StatusCancelado = "CANCELADO"
+)
+
+// DefaultWarehouseID é o depósito que recebe o pedido quando ele não informa um
+// (mesmo id do depósito padrão criado pela migração 0005)
+const DefaultWarehouseID = 1
+
+// Order é um pedido de compra a um fornecedor
+type Order struct {
+	ID          int64     `json:"id"`
+	SupplierID  int       `json:"supplier_id"`
+	Supplier    string    `json:"supplier"`
+	WarehouseID int       `json:"warehouse_id"` // depósito que recebe a mercadoria
+	Status      string    `json:"status"`
+	ExpectedAt  string    `json:"expected_at,omitempty"` // previsão de entrega (AAAA-MM-DD)
+	Notes       string    `json:"notes,omitempty"`
+	Total       float64   `json:"total"` // soma de quantidade × custo dos itens
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+	Items       []Item    `json:"items,omitempty"`
+	Receipts    []Receipt `json:"receipts,omitempty"`
+}
+
+// Item é uma linha do pedido
+type Item struct {
+	ProductID    int     `json:"product_id"`
+	Product      string  `json:"product"`
+	SupplierCode string  `json:"supplier_code,omitempty"`
+	Quantity     float64 `json:"quantity"` // pedida
+	Received     float64 `json:"received"` // já recebida
+	Pending      float64 `json:"pending"`  // pedida - recebida
+	UnitCost     float64 `json:"unit_cost"`
+}
+
+// Receipt é um recebimento (total ou parcial) de um item, com a Entrada postada no estoque
+type Receipt struct {
+	ID          int64   `json:"id"`
+	ProductID   int     `json:"product_id"`
+	WarehouseID int     `json:"warehouse_id"`
+	Quantity    float64 `json:"quantity"`
+	UnitCost    float64 `json:"unit_cost"`
+	MovementID  int64   `json:"movement_id"`
+	Invoice     string  `json:"invoice,omitempty"` // nota fiscal do fornecedor
+	CreatedAt   string  `json:"created_at"`
+}
@@ -0,0 +1,277 @@
+package purchase
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL dos pedidos de compra
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de pedidos de compra
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// queryer é o que *sql.DB e *sql.Tx têm em comum para leitura
+// (permite ler o pedido dentro ou fora de uma transação)
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// supplierInfo é o que o pedido precisa do fornecedor
+type supplierInfo struct {
+	Name         string
+	Active       bool
+	LeadTimeDays int
+}
+
+// SupplierTx busca o fornecedor do pedido (nil, nil se não existir)
+func (r *Repository) SupplierTx(ctx context.Context, tx *sql.Tx, id int) (*supplierInfo, error) {
+	var s supplierInfo
+	err := tx.QueryRowContext(ctx,
+		`SELECT name, active, lead_time_days FROM suppliers WHERE id = ?`, id).Scan(&s.Name, &s.Active, &s.LeadTimeDays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar fornecedor: %v", err)
+	}
+	return &s, nil
+}
+
+// WarehouseActiveTx informa se o depósito existe e está ativo
+func (r *Repository) WarehouseActiveTx(ctx context.Context, tx *sql.Tx, warehouseID int) (bool, error) {
+	var active bool
+	err := tx.QueryRowContext(ctx, `SELECT active FROM warehouses WHERE id = ?`, warehouseID).Scan(&active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao buscar depósito: %v", err)
+	}
+	return active, nil
+}
+
+// ProductExistsTx informa se o produto existe
+func (r *Repository) ProductExistsTx(ctx context.Context, tx *sql.Tx, productID int) (bool, error) {
+	var n int
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM products WHERE id = ?`, productID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar produto: %v", err)
+	}
+	return n > 0, nil
+}
+
+// SupplierProductTx retorna o código do produto no fornecedor e o último custo pago
+// (vazio e nil se o produto não está vinculado ao fornecedor)
+func (r *Repository) SupplierProductTx(ctx context.Context, tx *sql.Tx, supplierID, productID int) (string, *float64, error) {
+	var code string
+	var lastCost sql.NullFloat64
+	err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(supplier_code, ''), last_cost FROM product_suppliers
+		WHERE supplier_id = ? AND product_id = ?`, supplierID, productID).Scan(&code, &lastCost)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("erro ao buscar produto do fornecedor: %v", err)
+	}
+	if !lastCost.Valid {
+		return code, nil, nil
+	}
+	return code, &lastCost.Float64, nil
+}
+
+// CreateOrderTx insere o pedido com os itens e retorna o ID gerado
+func (r *Repository) CreateOrderTx(ctx context.Context, tx *sql.Tx, o *Order) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO purchase_orders (supplier_id, warehouse_id, status, expected_at, notes)
+		VALUES (?, ?, ?, ?, ?)`,
+		o.SupplierID, o.WarehouseID, StatusAberto, nullString(o.ExpectedAt), nullString(o.Notes))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir pedido de compra: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do pedido de compra: %v", err)
+	}
+	for _, it := range o.Items {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO purchase_order_items (order_id, product_id, supplier_code, quantity, unit_cost)
+			VALUES (?, ?, ?, ?, ?)`,
+			id, it.ProductID, nullString(it.SupplierCode), it.Quantity, it.UnitCost)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao inserir item do pedido de compra: %v", err)
+		}
+	}
+	return id, nil
+}
+
+// orderColumns são as colunas lidas por scanOrder (mesma ordem); o total vem dos itens
+const orderColumns = `o.id, o.supplier_id, COALESCE(s.name, ''), o.warehouse_id, o.status,
+	COALESCE(o.expected_at, ''), COALESCE(o.notes, ''),
+	(SELECT COALESCE(SUM(i.quantity * i.unit_cost), 0) FROM purchase_order_items i WHERE i.order_id = o.id),
+	o.created_at, o.updated_at`
+
+// scanOrder lê um pedido de uma linha (Row ou Rows), sem os itens
+func scanOrder(scan func(dest ...any) error) (Order, error) {
+	var o Order
+	err := scan(&o.ID, &o.SupplierID, &o.Supplier, &o.WarehouseID, &o.Status,
+		&o.ExpectedAt, &o.Notes, &o.Total, &o.CreatedAt, &o.UpdatedAt)
+	return o, err
+}
+
+// GetOrder busca um pedido pelo ID (nil, nil se não existir), sem os itens
+func (r *Repository) GetOrder(ctx context.Context, q queryer, id int64) (*Order, error) {
+	o, err := scanOrder(q.QueryRowContext(ctx,
+		`SELECT `+orderColumns+` FROM purchase_orders o
+		LEFT JOIN suppliers s ON s.id = o.supplier_id
+		WHERE o.id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // pedido não encontrado
+		}
+		return nil, fmt.Errorf("erro ao escanear pedido de compra: %v", err)
+	}
+	return &o, nil
+}
+
+// ListOrders retorna os pedidos (mais recentes primeiro), filtrando por status e fornecedor
+// quando informados
+func (r *Repository) ListOrders(ctx context.Context, status string, supplierID int) ([]Order, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+orderColumns+` FROM purchase_orders o
+		LEFT JOIN suppliers s ON s.id = o.supplier_id
+		WHERE (? = '' OR o.status = ?) AND (? = 0 OR o.supplier_id = ?)
+		ORDER BY o.id DESC`, status, status, supplierID, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar pedidos de compra: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Order{}
+	for rows.Next() {
+		o, err := scanOrder(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear pedido de compra: %v", err)
+		}
+		list = append(list, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos pedidos de compra: %v", err)
+	}
+	return list, nil
+}
+
+// ListItems retorna os itens do pedido
+func (r *Repository) ListItems(ctx context.Context, q queryer, orderID int64) ([]Item, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT i.product_id, COALESCE(p.name, ''), COALESCE(i.supplier_code, ''), i.quantity, i.received, i.unit_cost
+		FROM purchase_order_items i
+		LEFT JOIN products p ON p.id = i.product_id
+		WHERE i.order_id = ?
+		ORDER BY i.rowid`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar itens do pedido de compra: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.ProductID, &it.Product, &it.SupplierCode, &it.Quantity, &it.Received, &it.UnitCost); err != nil {
+			return nil, fmt.Errorf("erro ao escanear item do pedido de compra: %v", err)
+		}
+		it.Pending = max(it.Quantity-it.Received, 0)
+		list = append(list, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos itens do pedido de compra: %v", err)
+	}
+	return list, nil
+}
+
+// ListReceipts retorna os recebimentos do pedido na ordem em que aconteceram
+func (r *Repository) ListReceipts(ctx context.Context, orderID int64) ([]Receipt, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, product_id, warehouse_id, quantity, unit_cost, movement_id, COALESCE(invoice, ''), created_at
+		FROM purchase_order_receipts WHERE order_id = ? ORDER BY id`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar recebimentos do pedido de compra: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Receipt
+	for rows.Next() {
+		var rc Receipt
+		if err := rows.Scan(&rc.ID, &rc.ProductID, &rc.WarehouseID, &rc.Quantity, &rc.UnitCost,
+			&rc.MovementID, &rc.Invoice, &rc.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear recebimento: %v", err)
+		}
+		list = append(list, rc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos recebimentos: %v", err)
+	}
+	return list, nil
+}
+
+// InsertReceiptTx grava o recebimento de um item e soma a quantidade em received
+func (r *Repository) InsertReceiptTx(ctx context.Context, tx *sql.Tx, orderID int64, rc *Receipt) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO purchase_order_receipts (order_id, product_id, warehouse_id, quantity, unit_cost, movement_id, invoice)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		orderID, rc.ProductID, rc.WarehouseID, rc.Quantity, rc.UnitCost, rc.MovementID, nullString(rc.Invoice))
+	if err != nil {
+		return fmt.Errorf("erro ao gravar recebimento: %v", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE purchase_order_items SET received = received + ? WHERE order_id = ? AND product_id = ?`,
+		rc.Quantity, orderID, rc.ProductID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar quantidade recebida: %v", err)
+	}
+	return nil
+}
+
+// UpdateSupplierCostTx grava o custo pago como último custo do produto no fornecedor
+// (cria o vínculo produto-fornecedor se ainda não existir)
+func (r *Repository) UpdateSupplierCostTx(ctx context.Context, tx *sql.Tx, supplierID, productID int, supplierCode string, unitCost float64) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO product_suppliers (supplier_id, product_id, supplier_code, last_cost, last_purchase_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (supplier_id, product_id) DO UPDATE SET
+			supplier_code = COALESCE(product_suppliers.supplier_code, excluded.supplier_code),
+			last_cost = excluded.last_cost,
+			last_purchase_at = excluded.last_purchase_at`,
+		supplierID, productID, nullString(supplierCode), unitCost)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar último custo do fornecedor: %v", err)
+	}
+	return nil
+}
+
+// UpdateStatusTx muda o status do pedido
+func (r *Repository) UpdateStatusTx(ctx context.Context, tx *sql.Tx, id int64, status string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE purchase_orders SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, status, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status do pedido de compra: %v", err)
+	}
+	return nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
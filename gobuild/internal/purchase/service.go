@@ -0,0 +1,318 @@
+package purchase
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // transação compartilhada com o estoque
+	"fmt"          // para formatação de strings e erros
+	"strings"      // para limpar os campos de texto
+	"time"         // previsão de entrega pelo prazo do fornecedor
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// StockService é o que o pedido de compra precisa do estoque: postar as Entradas com custo, em
+// lote, na transação do recebimento
+type StockService interface {
+	// CreateBatchTx aplica os movimentos em ordem na transação do chamador; se uma linha falha
+	// nenhuma entra (Lines[i].MovementID é o movimento da linha i)
+	CreateBatchTx(ctx context.Context, tx *sql.Tx, moves []*stock.Movement) (*stock.BatchResult, error)
+}
+
+// receiveEpsilon absorve erro de arredondamento ao comparar recebido x pedido
+const receiveEpsilon = 1e-9
+
+// ErrNaoAberto indica recebimento ou cancelamento de pedido já recebido ou cancelado
+var ErrNaoAberto = apperr.Conflict("pedido de compra não está aberto")
+
+// Service contém as regras de negócio dos pedidos de compra
+type Service struct {
+	repo  *Repository  // dependencia do repositorio para persistencia
+	stock StockService // posta as Entradas no recebimento
+}
+
+// NewService cria uma nova instância do serviço de pedidos de compra
+func NewService(repo *Repository, stock StockService) *Service {
+	return &Service{
+		repo:  repo,
+		stock: stock,
+	}
+}
+
+// Create abre um pedido de compra. Sem custo informado, o item usa o último custo pago ao
+// fornecedor; sem previsão de entrega, ela é hoje + prazo de entrega do fornecedor.
+func (s *Service) Create(ctx context.Context, req CreateOrderRequest) (*Order, error) {
+	if len(req.Items) == 0 {
+		return nil, apperr.Invalid("informe ao menos um item")
+	}
+	o := &Order{
+		SupplierID:  req.SupplierID,
+		WarehouseID: req.WarehouseID,
+		ExpectedAt:  strings.TrimSpace(req.ExpectedAt),
+		Notes:       req.Notes,
+	}
+	if o.WarehouseID == 0 {
+		o.WarehouseID = DefaultWarehouseID
+	}
+	if o.ExpectedAt != "" {
+		if _, err := time.Parse(time.DateOnly, o.ExpectedAt); err != nil {
+			return nil, apperr.Invalid("previsão de entrega inválida (%s): use AAAA-MM-DD", o.ExpectedAt)
+		}
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	sup, err := s.repo.SupplierTx(ctx, tx, o.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	if sup == nil {
+		return nil, apperr.NotFound("fornecedor com ID %d não encontrado", o.SupplierID)
+	}
+	if !sup.Active {
+		return nil, apperr.Invalid("fornecedor %d está inativo", o.SupplierID)
+	}
+	active, err := s.repo.WarehouseActiveTx(ctx, tx, o.WarehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, apperr.Invalid("depósito %d não encontrado ou inativo", o.WarehouseID)
+	}
+	if o.ExpectedAt == "" && sup.LeadTimeDays > 0 {
+		o.ExpectedAt = time.Now().AddDate(0, 0, sup.LeadTimeDays).Format(time.DateOnly)
+	}
+
+	seen := map[int]bool{}
+	for i, line := range req.Items {
+		if line.Quantity <= 0 {
+			return nil, apperr.Invalid("item %d: a quantidade deve ser maior que zero", i+1)
+		}
+		if seen[line.ProductID] {
+			return nil, apperr.Invalid("item %d: produto %d repetido no pedido", i+1, line.ProductID)
+		}
+		seen[line.ProductID] = true
+		exists, err := s.repo.ProductExistsTx(ctx, tx, line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, apperr.Invalid("item %d: produto %d não existe", i+1, line.ProductID)
+		}
+
+		code, lastCost, err := s.repo.SupplierProductTx(ctx, tx, o.SupplierID, line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		it := Item{ProductID: line.ProductID, Quantity: line.Quantity, SupplierCode: strings.TrimSpace(line.SupplierCode)}
+		if it.SupplierCode == "" {
+			it.SupplierCode = code
+		}
+		switch {
+		case line.UnitCost != nil:
+			it.UnitCost = *line.UnitCost
+		case lastCost != nil:
+			it.UnitCost = *lastCost
+		default:
+			return nil, apperr.Invalid("item %d: informe o custo unitário (o produto %d ainda não foi comprado deste fornecedor)", i+1, line.ProductID)
+		}
+		if it.UnitCost < 0 {
+			return nil, apperr.Invalid("item %d: o custo unitário não pode ser negativo", i+1)
+		}
+		o.Items = append(o.Items, it)
+	}
+
+	id, err := s.repo.CreateOrderTx(ctx, tx, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// List retorna os pedidos (sem itens), filtrando por status e fornecedor quando informados
+func (s *Service) List(ctx context.Context, status string, supplierID int) ([]Order, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "", StatusAberto, StatusParcial, StatusRecebido, StatusCancelado:
+	default:
+		return nil, apperr.Invalid("status inválido: %s", status)
+	}
+	return s.repo.ListOrders(ctx, status, supplierID)
+}
+
+// Get retorna o pedido com os itens e os recebimentos
+func (s *Service) Get(ctx context.Context, id int64) (*Order, error) {
+	o, err := s.repo.GetOrder(ctx, s.repo.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, apperr.NotFound("pedido de compra com ID %d não encontrado", id)
+	}
+	if o.Items, err = s.repo.ListItems(ctx, s.repo.DB, id); err != nil {
+		return nil, err
+	}
+	if o.Receipts, err = s.repo.ListReceipts(ctx, id); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Receive registra o recebimento (total ou parcial) do pedido: cada linha vira uma Entrada
+// com custo no estoque (motivo COMPRA, documento "pedido:<id>"), o último custo do produto no
+// fornecedor é atualizado e o status passa a PARCIALMENTE_RECEBIDO ou RECEBIDO. Sem itens,
+// recebe tudo o que falta. Tudo na mesma transação: ou o recebimento entra inteiro, ou nada.
+func (s *Service) Receive(ctx context.Context, id int64, req ReceiveRequest) (*Order, error) {
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	o, err := s.repo.GetOrder(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, apperr.NotFound("pedido de compra com ID %d não encontrado", id)
+	}
+	if o.Status != StatusAberto && o.Status != StatusParcial {
+		return nil, fmt.Errorf("%w (status %s)", ErrNaoAberto, o.Status)
+	}
+	items, err := s.repo.ListItems(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := map[int]*Item{}
+	for i := range items {
+		byProduct[items[i].ProductID] = &items[i]
+	}
+
+	lines := req.Items
+	if len(lines) == 0 {
+		for _, it := range items {
+			if it.Pending > receiveEpsilon {
+				lines = append(lines, ReceiveLine{ProductID: it.ProductID, Quantity: it.Pending})
+			}
+		}
+	}
+	warehouseID := req.WarehouseID
+	if warehouseID == 0 {
+		warehouseID = o.WarehouseID
+	}
+	active, err := s.repo.WarehouseActiveTx(ctx, tx, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, apperr.Invalid("depósito %d não encontrado ou inativo", warehouseID)
+	}
+	document := fmt.Sprintf("pedido:%d", id)
+
+	// confere as linhas e monta as Entradas; o estoque recebe todas de uma vez, pelo lote
+	receipts := make([]*Receipt, len(lines))
+	received := make([]*Item, len(lines))
+	moves := make([]*stock.Movement, len(lines))
+	for i, line := range lines {
+		it, ok := byProduct[line.ProductID]
+		if !ok {
+			return nil, apperr.Invalid("linha %d: produto %d não faz parte do pedido", i+1, line.ProductID)
+		}
+		if line.Quantity <= 0 {
+			return nil, apperr.Invalid("linha %d: a quantidade deve ser maior que zero", i+1)
+		}
+		if line.Quantity > it.Quantity-it.Received+receiveEpsilon {
+			return nil, apperr.Invalid("linha %d: produto %d recebe no máximo %v (pedido %v, já recebido %v)",
+				i+1, line.ProductID, it.Quantity-it.Received, it.Quantity, it.Received)
+		}
+		cost := it.UnitCost
+		if line.UnitCost != nil {
+			if *line.UnitCost < 0 {
+				return nil, apperr.Invalid("linha %d: o custo unitário não pode ser negativo", i+1)
+			}
+			cost = *line.UnitCost
+		}
+
+		moves[i] = &stock.Movement{
+			ProductID:   line.ProductID,
+			WarehouseID: warehouseID,
+			Type:        "Entrada",
+			Quantity:    line.Quantity,
+			UnitCost:    &cost,
+			Reason:      stock.MotivoCompra,
+			Document:    document,
+		}
+		receipts[i] = &Receipt{
+			ProductID:   line.ProductID,
+			WarehouseID: warehouseID,
+			Quantity:    line.Quantity,
+			UnitCost:    cost,
+			Invoice:     strings.TrimSpace(req.Invoice),
+		}
+		received[i] = it
+		it.Received += line.Quantity
+	}
+
+	result, err := s.stock.CreateBatchTx(ctx, tx, moves)
+	if err != nil {
+		return nil, err
+	}
+	for i, rc := range receipts {
+		rc.MovementID = *result.Lines[i].MovementID
+		if err := s.repo.InsertReceiptTx(ctx, tx, id, rc); err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateSupplierCostTx(ctx, tx, o.SupplierID, rc.ProductID, received[i].SupplierCode, rc.UnitCost); err != nil {
+			return nil, err
+		}
+	}
+
+	status := StatusRecebido
+	for _, it := range items {
+		if it.Received < it.Quantity-receiveEpsilon {
+			status = StatusParcial
+			break
+		}
+	}
+	if err := s.repo.UpdateStatusTx(ctx, tx, id, status); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.Get(ctx, id)
+}
+
+// Cancel cancela o que falta receber do pedido (o que já entrou continua no estoque)
+func (s *Service) Cancel(ctx context.Context, id int64) error {
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	o, err := s.repo.GetOrder(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		return apperr.NotFound("pedido de compra com ID %d não encontrado", id)
+	}
+	if o.Status != StatusAberto && o.Status != StatusParcial {
+		return fmt.Errorf("%w (status %s)", ErrNaoAberto, o.Status)
+	}
+	if err := s.repo.UpdateStatusTx(ctx, tx, id, StatusCancelado); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package rental
+
+import (
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// Handler expõe os endpoints HTTP de locação de equipamentos
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de locação no grupo Echo (ex.: /api/rentals)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	// equipamentos
+	g.POST("/assets", h.CreateAsset)
+	g.GET("/assets", h.ListAssets)
+	g.GET("/assets/:id", h.GetAsset)
+	g.PUT("/assets/:id", h.UpdateAsset)
+	// contratos
+	g.POST("/contracts", h.CreateContract)
+	g.GET("/contracts", h.ListContracts)
+	g.GET("/contracts/:id", h.GetContract)
+	g.POST("/contracts/:id/checkout", h.CheckOut)
+	g.POST("/contracts/:id/checkin", h.CheckIn)
+	g.POST("/contracts/:id/cancel", h.Cancel)
+}
+
+// CreateContractRequest abre um contrato de locação.
+// Ex.: {"customer": "João", "start_date": "2026-11-02", "expected_return": "2026-11-16",
+// "late_fee_percent": 10, "items": [{"asset_id": 1, "quantity": 2, "period": "SEMANAL"}]}
+// start_date é opcional (padrão: hoje); period é opcional (padrão: DIARIA) e rate (opcional)
+// substitui o preço do equipamento. Com budget_id, customer, items e expected_return podem
+// vir do orçamento aprovado.
+type CreateContractRequest struct {
+	Customer       string         `json:"customer"`
+	BudgetID       *int64         `json:"budget_id"`
+	StartDate      string         `json:"start_date"`
+	ExpectedReturn string         `json:"expected_return"`
+	LateFeePercent float64        `json:"late_fee_percent"`
+	Notes          string         `json:"notes"`
+	Items          []ContractLine `json:"items"`
+}
+
+// ContractLine é um equipamento do contrato
+type ContractLine struct {
+	AssetID  int      `json:"asset_id"`
+	Quantity int      `json:"quantity"`
+	Period   string   `json:"period"`
+	Rate     *float64 `json:"rate"`
+}
+
+// CheckOutRequest registra a retirada. Ex.: {"date": "2026-11-02"} (opcional; padrão: hoje)
+type CheckOutRequest struct {
+	Date string `json:"date"`
+}
+
+// CheckInRequest registra uma devolução.
+// Ex.: {"date": "2026-11-18", "items": [{"asset_id": 1, "quantity": 1}], "notes": "sem avarias"}
+// Sem items devolve tudo o que falta; date é opcional (padrão: hoje)
+type CheckInRequest struct {
+	Date  string       `json:"date"`
+	Notes string       `json:"notes"`
+	Items []ReturnLine `json:"items"`
+}
+
+// ReturnLine é a quantidade devolvida de um equipamento
+type ReturnLine struct {
+	AssetID  int `json:"asset_id"`
+	Quantity int `json:"quantity"`
+}
+
+// CreateAsset cadastra um equipamento.
+// Ex.: {"name": "Betoneira 400L", "code": "BET-01", "quantity": 3, "daily_rate": 60,
+// "weekly_rate": 300, "monthly_rate": 900}
+func (h *Handler) CreateAsset(c echo.Context) error {
+	var req Asset
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	id, err := h.svc.CreateAsset(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]int64{"id": id})
+}
+
+// ListAssets retorna os equipamentos com a disponibilidade (?ativos=true para só os ativos)
+func (h *Handler) ListAssets(c echo.Context) error {
+	list, err := h.svc.ListAssets(c.Request().Context(), c.QueryParam("ativos") == "true")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GetAsset retorna um equipamento por id, com alugado, reservado e disponível
+func (h *Handler) GetAsset(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	a, err := h.svc.GetAsset(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, a)
+}
+
+// UpdateAsset atualiza o cadastro do equipamento (inclusive ativo/inativo)
+func (h *Handler) UpdateAsset(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req Asset
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	req.ID = id
+	if err := h.svc.UpdateAsset(c.Request().Context(), &req); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "equipamento atualizado com sucesso"})
+}
+
+// CreateContract abre um contrato de locação (RESERVADO)
+func (h *Handler) CreateContract(c echo.Context) error {
+	var req CreateContractRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	ct, err := h.svc.CreateContract(c.Request().Context(), req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, ct)
+}
+
+// ListContracts retorna os contratos. Query: status e atrasados=true
+func (h *Handler) ListContracts(c echo.Context) error {
+	list, err := h.svc.ListContracts(c.Request().Context(), c.QueryParam("status"), c.QueryParam("atrasados") == "true")
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GetContract retorna o contrato com itens, devoluções e a previsão do que falta cobrar
+func (h *Handler) GetContract(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	ct, err := h.svc.GetContract(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, ct)
+}
+
+// CheckOut registra a retirada dos equipamentos
+func (h *Handler) CheckOut(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req CheckOutRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	ct, err := h.svc.CheckOut(c.Request().Context(), id, req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, ct)
+}
+
+// CheckIn registra a devolução (total ou parcial) dos equipamentos
+func (h *Handler) CheckIn(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req CheckInRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	ct, err := h.svc.CheckIn(c.Request().Context(), id, req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, ct)
+}
+
+// Cancel cancela um contrato reservado
+func (h *Handler) Cancel(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	if err := h.svc.Cancel(c.Request().Context(), id); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "contrato cancelado"})
+}
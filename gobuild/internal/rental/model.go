@@ -0,0 +1,84 @@
+package rental
+
+// Status de um contrato de locação
+//
+//	RESERVADO -> EM_ANDAMENTO (retirada) -> DEVOLVIDO (tudo devolvido)
+//	RESERVADO -> CANCELADO
+const (
+	StatusReservado   = "RESERVADO"
+	StatusEmAndamento = "EM_ANDAMENTO"
+	StatusDevolvido   = "DEVOLVIDO"
+	StatusCancelado   = "CANCELADO"
+)
+
+// Períodos de cobrança da locação
+const (
+	PeriodoDiaria  = "DIARIA"
+	PeriodoSemanal = "SEMANAL"
+	PeriodoMensal  = "MENSAL"
+)
+
+// Asset é um equipamento para locação. Quantity é o total de unidades do patrimônio
+// (ex.: 3 betoneiras, 120 painéis de andaime)
+type Asset struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Code        string   `json:"code"` // código do patrimônio (opcional, único)
+	Category    string   `json:"category"`
+	Quantity    int      `json:"quantity"`
+	DailyRate   float64  `json:"daily_rate"`
+	WeeklyRate  *float64 `json:"weekly_rate"`  // nulo = não aluga por semana
+	MonthlyRate *float64 `json:"monthly_rate"` // nulo = não aluga por mês
+	Notes       string   `json:"notes"`
+	Active      bool     `json:"active"`
+	Rented      int      `json:"rented"`    // unidades com cliente (contratos em andamento)
+	Reserved    int      `json:"reserved"`  // unidades em contratos reservados (ainda não retirados)
+	Available   int      `json:"available"` // quantity - rented
+	CreatedAt   string   `json:"created_at"`
+}
+
+// Contract é um contrato de locação
+type Contract struct {
+	ID             int64    `json:"id"`
+	Customer       string   `json:"customer"`
+	BudgetID       *int64   `json:"budget_id,omitempty"` // orçamento de origem (opcional)
+	Status         string   `json:"status"`
+	StartDate      string   `json:"start_date"`      // início da cobrança (AAAA-MM-DD); na retirada vira a data da retirada
+	ExpectedReturn string   `json:"expected_return"` // devolução prevista (AAAA-MM-DD)
+	LateFeePercent float64  `json:"late_fee_percent"`
+	Notes          string   `json:"notes,omitempty"`
+	Overdue        bool     `json:"overdue"`     // em andamento e passou da devolução prevista
+	Charged        float64  `json:"charged"`     // soma das devoluções (locação + atraso)
+	Outstanding    float64  `json:"outstanding"` // previsão para o que falta devolver, se devolvido hoje
+	CheckedOutAt   string   `json:"checked_out_at,omitempty"`
+	ClosedAt       string   `json:"closed_at,omitempty"`
+	CreatedAt      string   `json:"created_at"`
+	Items          []Item   `json:"items,omitempty"`
+	Returns        []Return `json:"returns,omitempty"`
+}
+
+// Item é um equipamento do contrato
+type Item struct {
+	AssetID   int     `json:"asset_id"`
+	Asset     string  `json:"asset"`
+	Quantity  int     `json:"quantity"`
+	Returned  int     `json:"returned"`
+	Pending   int     `json:"pending"` // quantity - returned
+	Period    string  `json:"period"`
+	Rate      float64 `json:"rate"`       // preço do período por unidade
+	DailyRate float64 `json:"daily_rate"` // diária por unidade (base do atraso)
+}
+
+// Return é uma devolução (total ou parcial) de um equipamento, com o valor cobrado
+type Return struct {
+	ID           int64   `json:"id"`
+	AssetID      int     `json:"asset_id"`
+	Quantity     int     `json:"quantity"`
+	ReturnedAt   string  `json:"returned_at"`
+	Days         int     `json:"days"`      // dias cobrados pela locação (até a devolução prevista)
+	LateDays     int     `json:"late_days"` // dias depois da devolução prevista
+	RentalAmount float64 `json:"rental_amount"`
+	LateFee      float64 `json:"late_fee"`
+	Notes        string  `json:"notes,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+}
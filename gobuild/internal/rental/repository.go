@@ -0,0 +1,388 @@
+package rental
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL de equipamentos e contratos de locação
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de locação
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// queryer é o que *sql.DB e *sql.Tx têm em comum para leitura
+// (permite ler equipamento e contrato dentro ou fora de uma transação)
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// assetColumns são as colunas lidas por scanAsset (mesma ordem). Alugado e reservado vêm
+// dos contratos: em andamento (o que ainda não voltou) e reservados (ainda não retirados)
+const assetColumns = `a.id, a.name, COALESCE(a.code, ''), COALESCE(a.category, ''), a.quantity,
+	a.daily_rate, a.weekly_rate, a.monthly_rate, COALESCE(a.notes, ''), a.active,
+	(SELECT COALESCE(SUM(i.quantity - i.returned), 0) FROM rental_contract_items i
+		JOIN rental_contracts c ON c.id = i.contract_id
+		WHERE i.asset_id = a.id AND c.status = 'EM_ANDAMENTO'),
+	(SELECT COALESCE(SUM(i.quantity), 0) FROM rental_contract_items i
+		JOIN rental_contracts c ON c.id = i.contract_id
+		WHERE i.asset_id = a.id AND c.status = 'RESERVADO'),
+	a.created_at`
+
+// scanAsset lê um equipamento de uma linha (Row ou Rows) e calcula o disponível
+func scanAsset(scan func(dest ...any) error) (Asset, error) {
+	var a Asset
+	var weekly, monthly sql.NullFloat64
+	err := scan(&a.ID, &a.Name, &a.Code, &a.Category, &a.Quantity,
+		&a.DailyRate, &weekly, &monthly, &a.Notes, &a.Active,
+		&a.Rented, &a.Reserved, &a.CreatedAt)
+	if weekly.Valid {
+		a.WeeklyRate = &weekly.Float64
+	}
+	if monthly.Valid {
+		a.MonthlyRate = &monthly.Float64
+	}
+	a.Available = a.Quantity - a.Rented
+	return a, err
+}
+
+// CreateAsset insere um equipamento e retorna o ID gerado
+func (r *Repository) CreateAsset(ctx context.Context, a *Asset) (int64, error) {
+	result, err := r.DB.ExecContext(ctx,
+		`INSERT INTO rental_assets (name, code, category, quantity, daily_rate, weekly_rate, monthly_rate, notes, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.Name, nullString(a.Code), nullString(a.Category), a.Quantity, a.DailyRate,
+		a.WeeklyRate, a.MonthlyRate, nullString(a.Notes), a.Active)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir equipamento: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do equipamento: %v", err)
+	}
+	return id, nil
+}
+
+// UpdateAsset atualiza o cadastro do equipamento
+func (r *Repository) UpdateAsset(ctx context.Context, a *Asset) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE rental_assets SET name = ?, code = ?, category = ?, quantity = ?, daily_rate = ?,
+			weekly_rate = ?, monthly_rate = ?, notes = ?, active = ?
+		WHERE id = ?`,
+		a.Name, nullString(a.Code), nullString(a.Category), a.Quantity, a.DailyRate,
+		a.WeeklyRate, a.MonthlyRate, nullString(a.Notes), a.Active, a.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar equipamento: %v", err)
+	}
+	return nil
+}
+
+// GetAsset busca um equipamento pelo ID (nil, nil se não existir)
+func (r *Repository) GetAsset(ctx context.Context, q queryer, id int) (*Asset, error) {
+	a, err := scanAsset(q.QueryRowContext(ctx,
+		`SELECT `+assetColumns+` FROM rental_assets a WHERE a.id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // equipamento não encontrado
+		}
+		return nil, fmt.Errorf("erro ao escanear equipamento: %v", err)
+	}
+	return &a, nil
+}
+
+// ListAssets retorna os equipamentos por nome (só os ativos se activeOnly)
+func (r *Repository) ListAssets(ctx context.Context, activeOnly bool) ([]Asset, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+assetColumns+` FROM rental_assets a
+		WHERE (? = 0 OR a.active = 1)
+		ORDER BY a.name, a.id`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar equipamentos: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Asset{}
+	for rows.Next() {
+		a, err := scanAsset(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear equipamento: %v", err)
+		}
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos equipamentos: %v", err)
+	}
+	return list, nil
+}
+
+// AssetIDByCode retorna o ID do equipamento com o código de patrimônio (0 se não houver)
+func (r *Repository) AssetIDByCode(ctx context.Context, code string) (int, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, `SELECT id FROM rental_assets WHERE code = ?`, code).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar código do equipamento: %v", err)
+	}
+	return id, nil
+}
+
+// budgetInfo é o que o contrato precisa do orçamento de origem
+type budgetInfo struct {
+	Customer string
+	Status   string
+}
+
+// budgetLine é uma linha de locação do orçamento
+type budgetLine struct {
+	AssetID  int
+	Quantity int
+	Period   string
+	Periods  int
+	Rate     float64
+}
+
+// BudgetTx busca o orçamento de origem do contrato (nil, nil se não existir)
+func (r *Repository) BudgetTx(ctx context.Context, tx *sql.Tx, id int64) (*budgetInfo, error) {
+	var b budgetInfo
+	err := tx.QueryRowContext(ctx, `SELECT customer, status FROM budgets WHERE id = ?`, id).Scan(&b.Customer, &b.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao buscar orçamento: %v", err)
+	}
+	return &b, nil
+}
+
+// BudgetLinesTx retorna as linhas de locação do orçamento
+func (r *Repository) BudgetLinesTx(ctx context.Context, tx *sql.Tx, budgetID int64) ([]budgetLine, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT asset_id, quantity, period, periods, rate FROM budget_rentals
+		WHERE budget_id = ? ORDER BY id`, budgetID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar locações do orçamento: %v", err)
+	}
+	defer rows.Close()
+
+	var list []budgetLine
+	for rows.Next() {
+		var l budgetLine
+		if err := rows.Scan(&l.AssetID, &l.Quantity, &l.Period, &l.Periods, &l.Rate); err != nil {
+			return nil, fmt.Errorf("erro ao escanear locação do orçamento: %v", err)
+		}
+		list = append(list, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das locações do orçamento: %v", err)
+	}
+	return list, nil
+}
+
+// ContractByBudgetTx retorna o contrato (não cancelado) aberto a partir do orçamento (0 se não houver)
+func (r *Repository) ContractByBudgetTx(ctx context.Context, tx *sql.Tx, budgetID int64) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM rental_contracts WHERE budget_id = ? AND status <> 'CANCELADO' LIMIT 1`, budgetID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar contrato do orçamento: %v", err)
+	}
+	return id, nil
+}
+
+// CreateContractTx insere o contrato com os itens e retorna o ID gerado
+func (r *Repository) CreateContractTx(ctx context.Context, tx *sql.Tx, c *Contract) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO rental_contracts (customer, budget_id, status, start_date, expected_return, late_fee_percent, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		c.Customer, c.BudgetID, StatusReservado, c.StartDate, c.ExpectedReturn, c.LateFeePercent, nullString(c.Notes))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir contrato de locação: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do contrato de locação: %v", err)
+	}
+	for _, it := range c.Items {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO rental_contract_items (contract_id, asset_id, quantity, period, rate, daily_rate)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			id, it.AssetID, it.Quantity, it.Period, it.Rate, it.DailyRate)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao inserir item do contrato de locação: %v", err)
+		}
+	}
+	return id, nil
+}
+
+// contractColumns são as colunas lidas por scanContract (mesma ordem). As datas passam por
+// date() para voltarem como AAAA-MM-DD; o cobrado é a soma das devoluções
+const contractColumns = `c.id, c.customer, c.budget_id, c.status, date(c.start_date), date(c.expected_return),
+	c.late_fee_percent, COALESCE(c.notes, ''),
+	(SELECT COALESCE(SUM(rt.rental_amount + rt.late_fee), 0) FROM rental_returns rt WHERE rt.contract_id = c.id),
+	COALESCE(c.checked_out_at, ''), COALESCE(c.closed_at, ''), c.created_at`
+
+// scanContract lê um contrato de uma linha (Row ou Rows), sem os itens
+func scanContract(scan func(dest ...any) error) (Contract, error) {
+	var c Contract
+	var budgetID sql.NullInt64
+	err := scan(&c.ID, &c.Customer, &budgetID, &c.Status, &c.StartDate, &c.ExpectedReturn,
+		&c.LateFeePercent, &c.Notes, &c.Charged, &c.CheckedOutAt, &c.ClosedAt, &c.CreatedAt)
+	if budgetID.Valid {
+		c.BudgetID = &budgetID.Int64
+	}
+	return c, err
+}
+
+// GetContract busca um contrato pelo ID (nil, nil se não existir), sem os itens
+func (r *Repository) GetContract(ctx context.Context, q queryer, id int64) (*Contract, error) {
+	c, err := scanContract(q.QueryRowContext(ctx,
+		`SELECT `+contractColumns+` FROM rental_contracts c WHERE c.id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // contrato não encontrado
+		}
+		return nil, fmt.Errorf("erro ao escanear contrato de locação: %v", err)
+	}
+	return &c, nil
+}
+
+// ListContracts retorna os contratos (mais recentes primeiro), filtrando por status quando
+// informado; overdueBefore (AAAA-MM-DD) traz só os em andamento com devolução prevista antes da data
+func (r *Repository) ListContracts(ctx context.Context, status, overdueBefore string) ([]Contract, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+contractColumns+` FROM rental_contracts c
+		WHERE (? = '' OR c.status = ?)
+		  AND (? = '' OR (c.status = 'EM_ANDAMENTO' AND date(c.expected_return) < ?))
+		ORDER BY c.id DESC`, status, status, overdueBefore, overdueBefore)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contratos de locação: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Contract{}
+	for rows.Next() {
+		c, err := scanContract(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear contrato de locação: %v", err)
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos contratos de locação: %v", err)
+	}
+	return list, nil
+}
+
+// ListItems retorna os equipamentos do contrato
+func (r *Repository) ListItems(ctx context.Context, q queryer, contractID int64) ([]Item, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT i.asset_id, COALESCE(a.name, ''), i.quantity, i.returned, i.period, i.rate, i.daily_rate
+		FROM rental_contract_items i
+		LEFT JOIN rental_assets a ON a.id = i.asset_id
+		WHERE i.contract_id = ?
+		ORDER BY i.rowid`, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar itens do contrato de locação: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.AssetID, &it.Asset, &it.Quantity, &it.Returned, &it.Period, &it.Rate, &it.DailyRate); err != nil {
+			return nil, fmt.Errorf("erro ao escanear item do contrato de locação: %v", err)
+		}
+		it.Pending = it.Quantity - it.Returned
+		list = append(list, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos itens do contrato de locação: %v", err)
+	}
+	return list, nil
+}
+
+// ListReturns retorna as devoluções do contrato na ordem em que aconteceram
+func (r *Repository) ListReturns(ctx context.Context, contractID int64) ([]Return, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, asset_id, quantity, date(returned_at), days, late_days, rental_amount, late_fee,
+			COALESCE(notes, ''), created_at
+		FROM rental_returns WHERE contract_id = ? ORDER BY id`, contractID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar devoluções do contrato: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Return
+	for rows.Next() {
+		var rt Return
+		if err := rows.Scan(&rt.ID, &rt.AssetID, &rt.Quantity, &rt.ReturnedAt, &rt.Days, &rt.LateDays,
+			&rt.RentalAmount, &rt.LateFee, &rt.Notes, &rt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear devolução: %v", err)
+		}
+		list = append(list, rt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das devoluções: %v", err)
+	}
+	return list, nil
+}
+
+// CheckOutTx marca a retirada: contrato em andamento, cobrança a partir de startDate
+func (r *Repository) CheckOutTx(ctx context.Context, tx *sql.Tx, id int64, startDate string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE rental_contracts SET status = ?, start_date = ?, checked_out_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		StatusEmAndamento, startDate, id)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar retirada: %v", err)
+	}
+	return nil
+}
+
+// InsertReturnTx grava a devolução de um equipamento e soma a quantidade em returned
+func (r *Repository) InsertReturnTx(ctx context.Context, tx *sql.Tx, contractID int64, rt *Return) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO rental_returns (contract_id, asset_id, quantity, returned_at, days, late_days, rental_amount, late_fee, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		contractID, rt.AssetID, rt.Quantity, rt.ReturnedAt, rt.Days, rt.LateDays, rt.RentalAmount, rt.LateFee, nullString(rt.Notes))
+	if err != nil {
+		return fmt.Errorf("erro ao gravar devolução: %v", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE rental_contract_items SET returned = returned + ? WHERE contract_id = ? AND asset_id = ?`,
+		rt.Quantity, contractID, rt.AssetID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar quantidade devolvida: %v", err)
+	}
+	return nil
+}
+
+// CloseTx encerra o contrato com o status final (DEVOLVIDO ou CANCELADO)
+func (r *Repository) CloseTx(ctx context.Context, tx *sql.Tx, id int64, status string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE rental_contracts SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?`, status, id)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar status do contrato de locação: %v", err)
+	}
+	return nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,611 @@
+package rental
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // transação da retirada/devolução
+	"fmt"          // para formatação de strings e erros
+	"math"         // arredondamento dos valores cobrados
+	"strings"      // para limpar os campos de texto
+	"time"         // datas do contrato e dias de locação/atraso
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/budget" // RentalLite (interface budget.RentalReader)
+)
+
+var (
+	// ErrCodigoDuplicado indica código de patrimônio já usado em outro equipamento
+	ErrCodigoDuplicado = apperr.Conflict("já existe equipamento com este código")
+	// ErrIndisponivel indica retirada de mais unidades do que as disponíveis
+	ErrIndisponivel = apperr.Conflict("equipamento indisponível")
+	// ErrStatusContrato indica operação não permitida no status atual do contrato
+	ErrStatusContrato = apperr.Conflict("operação não permitida no status do contrato")
+	// ErrOrcamentoJaLocado indica orçamento que já tem contrato de locação (não cancelado)
+	ErrOrcamentoJaLocado = apperr.Conflict("orçamento já tem contrato de locação")
+)
+
+// Service contém as regras de negócio da locação de equipamentos
+type Service struct {
+	repo *Repository // dependencia do repositorio para persistencia
+}
+
+// NewService cria uma nova instância do serviço de locação
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// today é a data de hoje (horário local) sem hora, comparável com as datas do contrato
+func (s *Service) today() time.Time {
+	t := time.Now()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// periodDays é quantos dias um período cobre (0 se o período não existe)
+func periodDays(period string) int {
+	switch period {
+	case PeriodoDiaria:
+		return 1
+	case PeriodoSemanal:
+		return 7
+	case PeriodoMensal:
+		return 30
+	}
+	return 0
+}
+
+// normalizePeriod limpa o período pedido (vazio = DIARIA) e confere se existe
+func normalizePeriod(period string) (string, error) {
+	period = strings.ToUpper(strings.TrimSpace(period))
+	if period == "" {
+		period = PeriodoDiaria
+	}
+	if periodDays(period) == 0 {
+		return "", apperr.Invalid("período inválido: %s (use DIARIA, SEMANAL ou MENSAL)", period)
+	}
+	return period, nil
+}
+
+// rateFor retorna o preço do equipamento no período
+func rateFor(a *Asset, period string) (float64, error) {
+	var rate *float64
+	switch period {
+	case PeriodoDiaria:
+		return a.DailyRate, nil
+	case PeriodoSemanal:
+		rate = a.WeeklyRate
+	case PeriodoMensal:
+		rate = a.MonthlyRate
+	}
+	if rate == nil {
+		return 0, apperr.Invalid("equipamento %s não tem preço para o período %s", a.Name, period)
+	}
+	return *rate, nil
+}
+
+// parseDate lê uma data AAAA-MM-DD; vazia usa def
+func parseDate(v string, def time.Time, field string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, apperr.Invalid("%s inválida (%s): use AAAA-MM-DD", field, v)
+	}
+	return t, nil
+}
+
+// daysBetween conta os dias corridos de a até b (datas sem hora)
+func daysBetween(a, b time.Time) int {
+	return int(math.Round(b.Sub(a).Hours() / 24))
+}
+
+// round2 arredonda valores em reais para 2 casas
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// charge calcula a devolução de quantity unidades do item em returnedAt:
+//   - locação: dias de start até a devolução (limitados à devolução prevista, mínimo 1),
+//     arredondados para cima em períodos do item (ex.: 10 dias em SEMANAL = 2 semanas);
+//   - atraso: dias depois da devolução prevista, cobrados pela diária do item com o
+//     acréscimo de lateFeePercent.
+func charge(it Item, quantity int, start, expected, returnedAt time.Time, lateFeePercent float64) Return {
+	end := returnedAt
+	if end.After(expected) {
+		end = expected
+	}
+	days := max(daysBetween(start, end), 1)
+	pd := periodDays(it.Period)
+	periods := (days + pd - 1) / pd
+	rt := Return{
+		AssetID:      it.AssetID,
+		Quantity:     quantity,
+		ReturnedAt:   returnedAt.Format(time.DateOnly),
+		Days:         days,
+		RentalAmount: round2(float64(periods*quantity) * it.Rate),
+	}
+	if late := daysBetween(expected, returnedAt); late > 0 {
+		rt.LateDays = late
+		rt.LateFee = round2(float64(late*quantity) * it.DailyRate * (1 + lateFeePercent/100))
+	}
+	return rt
+}
+
+// validateAsset limpa e valida o cadastro do equipamento antes de salvar
+func validateAsset(a *Asset) error {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Code = strings.TrimSpace(a.Code)
+	a.Category = strings.TrimSpace(a.Category)
+	if a.Name == "" {
+		return apperr.Invalid("o nome do equipamento não pode ser vazio")
+	}
+	if a.Quantity <= 0 {
+		return apperr.Invalid("a quantidade de unidades deve ser maior que zero")
+	}
+	if a.DailyRate <= 0 {
+		return apperr.Invalid("a diária deve ser maior que zero")
+	}
+	if a.WeeklyRate != nil && *a.WeeklyRate <= 0 {
+		return apperr.Invalid("o preço semanal deve ser maior que zero (ou nulo)")
+	}
+	if a.MonthlyRate != nil && *a.MonthlyRate <= 0 {
+		return apperr.Invalid("o preço mensal deve ser maior que zero (ou nulo)")
+	}
+	return nil
+}
+
+// checkCode recusa código de patrimônio já usado por outro equipamento (exceptID é o próprio)
+func (s *Service) checkCode(ctx context.Context, code string, exceptID int) error {
+	if code == "" {
+		return nil
+	}
+	id, err := s.repo.AssetIDByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if id != 0 && id != exceptID {
+		return fmt.Errorf("%w (equipamento %d)", ErrCodigoDuplicado, id)
+	}
+	return nil
+}
+
+// CreateAsset cadastra um equipamento para locação (nasce ativo)
+func (s *Service) CreateAsset(ctx context.Context, a *Asset) (int64, error) {
+	if err := validateAsset(a); err != nil {
+		return 0, fmt.Errorf("validação do equipamento falhou: %w", err)
+	}
+	if err := s.checkCode(ctx, a.Code, 0); err != nil {
+		return 0, err
+	}
+	a.Active = true
+	return s.repo.CreateAsset(ctx, a)
+}
+
+// UpdateAsset atualiza o cadastro do equipamento. A quantidade não pode ficar abaixo das
+// unidades que estão com clientes.
+func (s *Service) UpdateAsset(ctx context.Context, a *Asset) error {
+	if err := validateAsset(a); err != nil {
+		return fmt.Errorf("validação do equipamento falhou: %w", err)
+	}
+	current, err := s.GetAsset(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if a.Quantity < current.Rented {
+		return apperr.Invalid("a quantidade (%d) não pode ser menor que as unidades alugadas (%d)", a.Quantity, current.Rented)
+	}
+	if err := s.checkCode(ctx, a.Code, a.ID); err != nil {
+		return err
+	}
+	return s.repo.UpdateAsset(ctx, a)
+}
+
+// ListAssets retorna os equipamentos com a disponibilidade (só os ativos se activeOnly)
+func (s *Service) ListAssets(ctx context.Context, activeOnly bool) ([]Asset, error) {
+	return s.repo.ListAssets(ctx, activeOnly)
+}
+
+// GetAsset retorna um equipamento por ID, ou erro se não encontrado
+func (s *Service) GetAsset(ctx context.Context, id int) (*Asset, error) {
+	a, err := s.repo.GetAsset(ctx, s.repo.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, apperr.NotFound("equipamento com ID %d não encontrado", id)
+	}
+	return a, nil
+}
+
+// GetRate implementa a interface budget.RentalReader: o equipamento com o preço do período
+// (sem período, DIARIA). Retorna nil se o equipamento não existe.
+func (s *Service) GetRate(ctx context.Context, assetID int, period string) (*budget.RentalLite, error) {
+	period, err := normalizePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.repo.GetAsset(ctx, s.repo.DB, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, nil
+	}
+	if !a.Active {
+		return nil, apperr.Invalid("equipamento %s está inativo", a.Name)
+	}
+	rate, err := rateFor(a, period)
+	if err != nil {
+		return nil, err
+	}
+	return &budget.RentalLite{
+		ID:     a.ID,
+		Name:   a.Name,
+		Period: period,
+		Rate:   rate,
+	}, nil
+}
+
+// CreateContract abre um contrato de locação como RESERVADO (os equipamentos só saem na
+// retirada). Com budget_id, o contrato nasce do orçamento aprovado: sem itens, usa as linhas
+// de locação do orçamento, e sem devolução prevista ela é o início + o maior prazo cotado.
+func (s *Service) CreateContract(ctx context.Context, req CreateContractRequest) (*Contract, error) {
+	today := s.today()
+	start, err := parseDate(req.StartDate, today, "data de início")
+	if err != nil {
+		return nil, err
+	}
+	if req.LateFeePercent < 0 {
+		return nil, apperr.Invalid("o percentual de multa por atraso não pode ser negativo")
+	}
+	c := &Contract{
+		Customer:       strings.TrimSpace(req.Customer),
+		BudgetID:       req.BudgetID,
+		StartDate:      start.Format(time.DateOnly),
+		LateFeePercent: req.LateFeePercent,
+		Notes:          req.Notes,
+	}
+	lines := req.Items
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	// prazo cotado no orçamento (dias), usado para a devolução prevista
+	quotedDays := 0
+	if req.BudgetID != nil {
+		b, err := s.repo.BudgetTx(ctx, tx, *req.BudgetID)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			return nil, apperr.NotFound("orçamento com ID %d não encontrado", *req.BudgetID)
+		}
+		if b.Status != budget.StatusAprovado && b.Status != budget.StatusConvertido {
+			return nil, apperr.Invalid("orçamento %d não está aprovado (status %s)", *req.BudgetID, b.Status)
+		}
+		existing, err := s.repo.ContractByBudgetTx(ctx, tx, *req.BudgetID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != 0 {
+			return nil, fmt.Errorf("%w (contrato %d)", ErrOrcamentoJaLocado, existing)
+		}
+		if c.Customer == "" {
+			c.Customer = b.Customer
+		}
+		if len(lines) == 0 {
+			budgetLines, err := s.repo.BudgetLinesTx(ctx, tx, *req.BudgetID)
+			if err != nil {
+				return nil, err
+			}
+			if len(budgetLines) == 0 {
+				return nil, apperr.Invalid("orçamento %d não tem linhas de locação", *req.BudgetID)
+			}
+			for _, l := range budgetLines {
+				rate := l.Rate
+				lines = append(lines, ContractLine{AssetID: l.AssetID, Quantity: l.Quantity, Period: l.Period, Rate: &rate})
+				quotedDays = max(quotedDays, l.Periods*periodDays(l.Period))
+			}
+		}
+	}
+	if c.Customer == "" {
+		return nil, apperr.Invalid("cliente é obrigatório")
+	}
+	if len(lines) == 0 {
+		return nil, apperr.Invalid("informe ao menos um equipamento")
+	}
+
+	def := time.Time{}
+	if quotedDays > 0 {
+		def = start.AddDate(0, 0, quotedDays)
+	}
+	expected, err := parseDate(req.ExpectedReturn, def, "devolução prevista")
+	if err != nil {
+		return nil, err
+	}
+	if expected.IsZero() {
+		return nil, apperr.Invalid("informe a devolução prevista (expected_return)")
+	}
+	if expected.Before(start) {
+		return nil, apperr.Invalid("a devolução prevista não pode ser antes do início")
+	}
+	c.ExpectedReturn = expected.Format(time.DateOnly)
+
+	seen := map[int]bool{}
+	for i, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, apperr.Invalid("item %d: a quantidade deve ser maior que zero", i+1)
+		}
+		if seen[line.AssetID] {
+			return nil, apperr.Invalid("item %d: equipamento %d repetido no contrato", i+1, line.AssetID)
+		}
+		seen[line.AssetID] = true
+		period, err := normalizePeriod(line.Period)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, err)
+		}
+		a, err := s.repo.GetAsset(ctx, tx, line.AssetID)
+		if err != nil {
+			return nil, err
+		}
+		if a == nil {
+			return nil, apperr.Invalid("item %d: equipamento %d não existe", i+1, line.AssetID)
+		}
+		if !a.Active {
+			return nil, apperr.Invalid("item %d: equipamento %s está inativo", i+1, a.Name)
+		}
+		if line.Quantity > a.Quantity {
+			return nil, apperr.Invalid("item %d: %s tem só %d unidades", i+1, a.Name, a.Quantity)
+		}
+		it := Item{AssetID: a.ID, Quantity: line.Quantity, Period: period, DailyRate: a.DailyRate}
+		if line.Rate != nil {
+			it.Rate = *line.Rate
+		} else if it.Rate, err = rateFor(a, period); err != nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, err)
+		}
+		if it.Rate < 0 {
+			return nil, apperr.Invalid("item %d: o preço não pode ser negativo", i+1)
+		}
+		c.Items = append(c.Items, it)
+	}
+
+	id, err := s.repo.CreateContractTx(ctx, tx, c)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.GetContract(ctx, id)
+}
+
+// ListContracts retorna os contratos (sem itens), filtrando pelo status; overdue traz só os
+// em andamento que passaram da devolução prevista
+func (s *Service) ListContracts(ctx context.Context, status string, overdue bool) ([]Contract, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "", StatusReservado, StatusEmAndamento, StatusDevolvido, StatusCancelado:
+	default:
+		return nil, apperr.Invalid("status inválido: %s", status)
+	}
+	today := s.today().Format(time.DateOnly)
+	before := ""
+	if overdue {
+		before = today
+	}
+	list, err := s.repo.ListContracts(ctx, status, before)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Overdue = list[i].Status == StatusEmAndamento && list[i].ExpectedReturn < today
+	}
+	return list, nil
+}
+
+// GetContract retorna o contrato com os itens, as devoluções e a previsão do que falta cobrar
+func (s *Service) GetContract(ctx context.Context, id int64) (*Contract, error) {
+	c, err := s.repo.GetContract(ctx, s.repo.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, apperr.NotFound("contrato de locação com ID %d não encontrado", id)
+	}
+	if c.Items, err = s.repo.ListItems(ctx, s.repo.DB, id); err != nil {
+		return nil, err
+	}
+	if c.Returns, err = s.repo.ListReturns(ctx, id); err != nil {
+		return nil, err
+	}
+	today := s.today()
+	c.Overdue = c.Status == StatusEmAndamento && c.ExpectedReturn < today.Format(time.DateOnly)
+
+	// previsão: em andamento, como se o que falta voltasse hoje; reservado, o período contratado
+	start, _ := time.Parse(time.DateOnly, c.StartDate)
+	expected, _ := time.Parse(time.DateOnly, c.ExpectedReturn)
+	ref := today
+	switch c.Status {
+	case StatusReservado:
+		ref = expected
+	case StatusEmAndamento:
+		if start.After(today) {
+			ref = start
+		}
+	default:
+		return c, nil
+	}
+	for _, it := range c.Items {
+		if it.Pending > 0 {
+			rt := charge(it, it.Pending, start, expected, ref, c.LateFeePercent)
+			c.Outstanding += rt.RentalAmount + rt.LateFee
+		}
+	}
+	c.Outstanding = round2(c.Outstanding)
+	return c, nil
+}
+
+// CheckOut registra a retirada dos equipamentos: confere a disponibilidade de cada um, a
+// cobrança passa a contar da data da retirada e o contrato fica EM_ANDAMENTO
+func (s *Service) CheckOut(ctx context.Context, id int64, req CheckOutRequest) (*Contract, error) {
+	date, err := parseDate(req.Date, s.today(), "data da retirada")
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	c, err := s.contractInStatus(ctx, tx, id, StatusReservado)
+	if err != nil {
+		return nil, err
+	}
+	if date.Format(time.DateOnly) > c.ExpectedReturn {
+		return nil, apperr.Invalid("a retirada (%s) não pode ser depois da devolução prevista (%s)", date.Format(time.DateOnly), c.ExpectedReturn)
+	}
+	items, err := s.repo.ListItems(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range items {
+		a, err := s.repo.GetAsset(ctx, tx, it.AssetID)
+		if err != nil {
+			return nil, err
+		}
+		if a == nil {
+			return nil, apperr.Invalid("equipamento %d não existe", it.AssetID)
+		}
+		if !a.Active {
+			return nil, apperr.Invalid("equipamento %s está inativo", a.Name)
+		}
+		if it.Quantity > a.Available {
+			return nil, fmt.Errorf("%w: %s (disponível %d, pedido %d)", ErrIndisponivel, a.Name, a.Available, it.Quantity)
+		}
+	}
+
+	if err := s.repo.CheckOutTx(ctx, tx, id, date.Format(time.DateOnly)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.GetContract(ctx, id)
+}
+
+// CheckIn registra a devolução (total ou parcial) dos equipamentos e calcula o valor de cada
+// linha (ver charge). Sem itens, devolve tudo o que falta. Quando tudo voltou, o contrato
+// fica DEVOLVIDO.
+func (s *Service) CheckIn(ctx context.Context, id int64, req CheckInRequest) (*Contract, error) {
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	c, err := s.contractInStatus(ctx, tx, id, StatusEmAndamento)
+	if err != nil {
+		return nil, err
+	}
+	start, _ := time.Parse(time.DateOnly, c.StartDate)
+	expected, _ := time.Parse(time.DateOnly, c.ExpectedReturn)
+	date, err := parseDate(req.Date, s.today(), "data da devolução")
+	if err != nil {
+		return nil, err
+	}
+	if date.Before(start) {
+		return nil, apperr.Invalid("a devolução (%s) não pode ser antes da retirada (%s)", date.Format(time.DateOnly), c.StartDate)
+	}
+
+	items, err := s.repo.ListItems(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	byAsset := map[int]*Item{}
+	for i := range items {
+		byAsset[items[i].AssetID] = &items[i]
+	}
+
+	lines := req.Items
+	if len(lines) == 0 {
+		for _, it := range items {
+			if it.Pending > 0 {
+				lines = append(lines, ReturnLine{AssetID: it.AssetID, Quantity: it.Pending})
+			}
+		}
+	}
+	for i, line := range lines {
+		it, ok := byAsset[line.AssetID]
+		if !ok {
+			return nil, apperr.Invalid("item %d: equipamento %d não está no contrato", i+1, line.AssetID)
+		}
+		if line.Quantity <= 0 {
+			return nil, apperr.Invalid("item %d: a quantidade deve ser maior que zero", i+1)
+		}
+		if line.Quantity > it.Pending {
+			return nil, apperr.Invalid("item %d: devolução de %d unidades de %s, mas só %d estão com o cliente", i+1, line.Quantity, it.Asset, it.Pending)
+		}
+		rt := charge(*it, line.Quantity, start, expected, date, c.LateFeePercent)
+		rt.Notes = req.Notes
+		if err := s.repo.InsertReturnTx(ctx, tx, id, &rt); err != nil {
+			return nil, err
+		}
+		it.Pending -= line.Quantity
+	}
+
+	done := true
+	for _, it := range items {
+		done = done && it.Pending == 0
+	}
+	if done {
+		if err := s.repo.CloseTx(ctx, tx, id, StatusDevolvido); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.GetContract(ctx, id)
+}
+
+// Cancel cancela um contrato reservado (equipamentos ainda não retirados)
+func (s *Service) Cancel(ctx context.Context, id int64) error {
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.contractInStatus(ctx, tx, id, StatusReservado); err != nil {
+		return err
+	}
+	if err := s.repo.CloseTx(ctx, tx, id, StatusCancelado); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return nil
+}
+
+// contractInStatus lê o contrato na transação e garante que ele está no status esperado
+func (s *Service) contractInStatus(ctx context.Context, tx *sql.Tx, id int64, status string) (*Contract, error) {
+	c, err := s.repo.GetContract(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, apperr.NotFound("contrato de locação com ID %d não encontrado", id)
+	}
+	if c.Status != status {
+		return nil, fmt.Errorf("%w: contrato %d está %s (esperado %s)", ErrStatusContrato, id, c.Status, status)
+	}
+	return c, nil
+}
@@ -0,0 +1,126 @@
+package sale
+
+import (
+	"errors"   // para identificar os erros de conflito (409)
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/EtraudBits/golangProject/gobuild/internal/budget" // erros do orçamento de origem
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// Handler expõe os endpoints HTTP de vendas
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de venda no grupo Echo (ex.: /api/sales)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("", h.Create)
+	g.GET("", h.List)
+	g.GET("/:id", h.GetByID)
+	g.POST("/:id/cancel", h.Cancel)
+}
+
+// CreateSaleRequest converte um orçamento aprovado em venda.
+// Ex.: {"budget_id": 7, "payment_method": "boleto", "installments": 3, "first_due_date": "2026-11-10"}
+// installments vale 1 se não vier; interval_days (dias entre parcelas) vale 30
+type CreateSaleRequest struct {
+	BudgetID      int64  `json:"budget_id"`
+	PaymentMethod string `json:"payment_method"` // dinheiro, pix, cartao, boleto, crediario
+	Installments  int    `json:"installments"`
+	FirstDueDate  string `json:"first_due_date"` // AAAA-MM-DD (opcional; não vale para dinheiro/PIX)
+	IntervalDays  int    `json:"interval_days"`
+	Notes         string `json:"notes"`
+}
+
+// CancelRequest cancela a venda. Ex.: {"reason": "cliente desistiu"}
+type CancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Create cria a venda a partir do orçamento aprovado
+func (h *Handler) Create(c echo.Context) error {
+	var req CreateSaleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	sale, err := h.svc.Create(c.Request().Context(), req)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusCreated, sale)
+}
+
+// List retorna as vendas (?status=CONCLUIDA, ?customer_id=7, ?budget_id=3)
+func (h *Handler) List(c echo.Context) error {
+	customerID := 0
+	if v := c.QueryParam("customer_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "customer_id inválido"})
+		}
+		customerID = id
+	}
+	var budgetID int64
+	if v := c.QueryParam("budget_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "budget_id inválido"})
+		}
+		budgetID = id
+	}
+	list, err := h.svc.List(c.Request().Context(), c.QueryParam("status"), customerID, budgetID)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// GetByID retorna a venda com itens e parcelas
+func (h *Handler) GetByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	sale, err := h.svc.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, sale)
+}
+
+// Cancel cancela a venda e devolve a mercadoria ao estoque
+func (h *Handler) Cancel(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req CancelRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	sale, err := h.svc.Cancel(c.Request().Context(), id, req.Reason)
+	if err != nil {
+		return errorJSON(c, err)
+	}
+	return c.JSON(http.StatusOK, sale)
+}
+
+// errorJSON responde o erro com o status de apperr.Status; falta de estoque leva o disponível
+func errorJSON(c echo.Context, err error) error {
+	var shortage budget.StockShortage
+	if errors.As(err, &shortage) { // venda sem estoque (política BLOQUEAR)
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error":     err.Error(),
+			"available": shortage.AvailableQuantity(),
+		})
+	}
+	return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+}
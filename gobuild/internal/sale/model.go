@@ -0,0 +1,63 @@
+package sale
+
+// Status da venda
+const (
+	StatusConcluida = "CONCLUIDA" // mercadoria baixada do estoque (Saida)
+	StatusCancelada = "CANCELADA" // cancelada: a mercadoria voltou ao estoque
+)
+
+// Formas de pagamento
+const (
+	PagamentoDinheiro  = "DINHEIRO"
+	PagamentoPix       = "PIX"
+	PagamentoCartao    = "CARTAO"
+	PagamentoBoleto    = "BOLETO"
+	PagamentoCrediario = "CREDIARIO" // crediário da loja
+)
+
+// MaxInstallments é o número máximo de parcelas de uma venda
+const MaxInstallments = 12
+
+// Sale é uma venda criada a partir de um orçamento aprovado. Itens e valores são uma cópia do
+// orçamento no momento da venda; as locações do orçamento são cobradas pelo contrato de
+// locação e não entram na venda.
+//
+// Composição do total: Gross - ItemsDiscount - Discount + Freight + OtherCharges = Total
+type Sale struct {
+	ID            int64         `json:"id"`
+	BudgetID      int64         `json:"budget_id"` // orçamento de origem
+	CustomerID    *int          `json:"customer_id"`
+	Customer      string        `json:"customer"` // nome do cliente na época da venda
+	WarehouseID   int           `json:"warehouse_id"`
+	Gross         float64       `json:"gross"`          // itens (quantidade x preço)
+	ItemsDiscount float64       `json:"items_discount"` // soma dos descontos dos itens
+	Discount      float64       `json:"discount"`       // parte do desconto geral do orçamento que cabe aos itens
+	Freight       float64       `json:"freight"`        // parte do frete do orçamento que cabe aos itens
+	OtherCharges  float64       `json:"other_charges"`  // parte das outras despesas que cabe aos itens
+	Total         float64       `json:"total"`
+	PaymentMethod string        `json:"payment_method"` // DINHEIRO, PIX, CARTAO, BOLETO, CREDIARIO
+	Status        string        `json:"status"`         // CONCLUIDA ou CANCELADA
+	Notes         string        `json:"notes"`
+	CancelReason  string        `json:"cancel_reason,omitempty"`
+	CreatedAt     string        `json:"created_at"`
+	CancelledAt   string        `json:"cancelled_at,omitempty"`
+	Items         []Item        `json:"items,omitempty"`
+	Installments  []Installment `json:"installments,omitempty"`
+}
+
+// Item é um produto vendido (cópia do item do orçamento)
+type Item struct {
+	ProductID int     `json:"product_id"`
+	Product   string  `json:"product"`
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Discount  float64 `json:"discount"`
+	Subtotal  float64 `json:"subtotal"` // quantidade x preço - desconto
+}
+
+// Installment é uma parcela do pagamento
+type Installment struct {
+	Number  int     `json:"number"`   // 1, 2, 3...
+	DueDate string  `json:"due_date"` // vencimento (AAAA-MM-DD)
+	Amount  float64 `json:"amount"`
+}
@@ -0,0 +1,199 @@
+package sale
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL de vendas, itens e parcelas
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de vendas
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// queryer é o que *sql.DB e *sql.Tx têm em comum para leitura
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// saleColumns são as colunas lidas por scanSale (mesma ordem)
+const saleColumns = `id, budget_id, customer_id, customer, warehouse_id, gross, items_discount, discount,
+	freight, other_charges, total, payment_method, status, COALESCE(notes, ''), COALESCE(cancel_reason, ''),
+	created_at, COALESCE(cancelled_at, '')`
+
+// scanSale lê uma venda de uma linha (Row ou Rows), sem itens e parcelas
+func scanSale(scan func(dest ...any) error) (Sale, error) {
+	var s Sale
+	err := scan(&s.ID, &s.BudgetID, &s.CustomerID, &s.Customer, &s.WarehouseID, &s.Gross, &s.ItemsDiscount,
+		&s.Discount, &s.Freight, &s.OtherCharges, &s.Total, &s.PaymentMethod, &s.Status, &s.Notes,
+		&s.CancelReason, &s.CreatedAt, &s.CancelledAt)
+	return s, err
+}
+
+// SaleIDByBudgetTx retorna o ID da venda do orçamento (0 se não houver)
+func (r *Repository) SaleIDByBudgetTx(ctx context.Context, tx *sql.Tx, budgetID int64) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM sales WHERE budget_id = ?`, budgetID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar venda do orçamento: %v", err)
+	}
+	return id, nil
+}
+
+// CreateTx insere a venda com itens e parcelas e retorna o ID gerado
+func (r *Repository) CreateTx(ctx context.Context, tx *sql.Tx, s *Sale) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO sales (budget_id, customer_id, customer, warehouse_id, gross, items_discount, discount,
+			freight, other_charges, total, payment_method, status, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.BudgetID, s.CustomerID, s.Customer, s.WarehouseID, s.Gross, s.ItemsDiscount, s.Discount,
+		s.Freight, s.OtherCharges, s.Total, s.PaymentMethod, s.Status, nullString(s.Notes))
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir venda: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID da venda: %v", err)
+	}
+	for _, it := range s.Items {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO sale_items (sale_id, product_id, product, quantity, unit_price, discount, subtotal)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, it.ProductID, it.Product, it.Quantity, it.UnitPrice, it.Discount, it.Subtotal)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao inserir item da venda: %v", err)
+		}
+	}
+	for _, in := range s.Installments {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO sale_installments (sale_id, number, due_date, amount) VALUES (?, ?, ?, ?)`,
+			id, in.Number, in.DueDate, in.Amount)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao inserir parcela da venda: %v", err)
+		}
+	}
+	return id, nil
+}
+
+// GetByID busca uma venda pelo ID com itens e parcelas (nil, nil se não existir)
+func (r *Repository) GetByID(ctx context.Context, q queryer, id int64) (*Sale, error) {
+	s, err := scanSale(q.QueryRowContext(ctx, `SELECT `+saleColumns+` FROM sales WHERE id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // venda não encontrada
+		}
+		return nil, fmt.Errorf("erro ao escanear venda: %v", err)
+	}
+	if s.Items, err = r.listItems(ctx, q, id); err != nil {
+		return nil, err
+	}
+	if s.Installments, err = r.listInstallments(ctx, q, id); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// List retorna as vendas (sem itens e parcelas), mais recentes primeiro.
+// status, customerID e budgetID filtram quando informados ("" / 0 = todos).
+func (r *Repository) List(ctx context.Context, status string, customerID int, budgetID int64) ([]Sale, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+saleColumns+` FROM sales
+		WHERE (? = '' OR status = ?)
+		  AND (? = 0 OR customer_id = ?)
+		  AND (? = 0 OR budget_id = ?)
+		ORDER BY id DESC`, status, status, customerID, customerID, budgetID, budgetID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar vendas: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Sale{}
+	for rows.Next() {
+		s, err := scanSale(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear venda: %v", err)
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das vendas: %v", err)
+	}
+	return list, nil
+}
+
+// listItems retorna os itens da venda na ordem do orçamento
+func (r *Repository) listItems(ctx context.Context, q queryer, saleID int64) ([]Item, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT product_id, product, quantity, unit_price, discount, subtotal
+		FROM sale_items WHERE sale_id = ? ORDER BY id`, saleID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar itens da venda: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.ProductID, &it.Product, &it.Quantity, &it.UnitPrice, &it.Discount, &it.Subtotal); err != nil {
+			return nil, fmt.Errorf("erro ao escanear item da venda: %v", err)
+		}
+		list = append(list, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos itens da venda: %v", err)
+	}
+	return list, nil
+}
+
+// listInstallments retorna as parcelas da venda em ordem
+func (r *Repository) listInstallments(ctx context.Context, q queryer, saleID int64) ([]Installment, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT number, date(due_date), amount FROM sale_installments WHERE sale_id = ? ORDER BY number`, saleID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar parcelas da venda: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Installment
+	for rows.Next() {
+		var in Installment
+		if err := rows.Scan(&in.Number, &in.DueDate, &in.Amount); err != nil {
+			return nil, fmt.Errorf("erro ao escanear parcela da venda: %v", err)
+		}
+		list = append(list, in)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das parcelas da venda: %v", err)
+	}
+	return list, nil
+}
+
+// CancelTx marca a venda como CANCELADA com o motivo
+func (r *Repository) CancelTx(ctx context.Context, tx *sql.Tx, id int64, reason string) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE sales SET status = ?, cancel_reason = ?, cancelled_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		StatusCancelada, nullString(reason), id)
+	if err != nil {
+		return fmt.Errorf("erro ao cancelar venda: %v", err)
+	}
+	return nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,280 @@
+package sale
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // transação compartilhada com o orçamento
+	"fmt"          // para formatação de strings e erros
+	"math"         // arredondamento em centavos
+	"strings"      // para normalizar forma de pagamento e textos
+	"time"         // vencimento das parcelas
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/budget" // orçamento de origem da venda
+)
+
+// Erros de conflito (o handler responde 409)
+var (
+	ErrOrcamentoJaVendido = apperr.Conflict("orçamento já tem venda")
+	ErrVendaCancelada     = apperr.Conflict("venda já está cancelada")
+)
+
+// BudgetService é o que a venda precisa do orçamento: converter e desfazer a conversão na
+// mesma transação da venda (implementado por budget.Service)
+type BudgetService interface {
+	// ConvertTx converte o orçamento APROVADO (reservas viram Saida) e o retorna com os itens
+	ConvertTx(ctx context.Context, tx *sql.Tx, budgetID int64) (*budget.Budget, error)
+	// CancelConvertedTx devolve ao estoque o que foi baixado e cancela o orçamento
+	CancelConvertedTx(ctx context.Context, tx *sql.Tx, budgetID int64) error
+}
+
+// Service contém as regras de venda
+type Service struct {
+	repo    *Repository   // dependencia do repositorio para persistencia
+	budgets BudgetService // orçamento de origem (estoque passa por ele)
+}
+
+// NewService cria uma nova instância do serviço de vendas
+func NewService(r *Repository, budgets BudgetService) *Service {
+	return &Service{
+		repo:    r,
+		budgets: budgets,
+	}
+}
+
+// accents troca as letras acentuadas que aparecem nas formas de pagamento ("cartão", "crediário")
+var accents = strings.NewReplacer("Á", "A", "Ã", "A", "Â", "A", "É", "E", "Ê", "E", "Í", "I", "Ó", "O", "Õ", "O", "Ú", "U", "Ç", "C")
+
+// paymentMethod normaliza a forma de pagamento ("cartão" -> CARTAO) e valida
+func paymentMethod(v string) (string, error) {
+	m := accents.Replace(strings.ToUpper(strings.TrimSpace(v)))
+	switch m {
+	case PagamentoDinheiro, PagamentoPix, PagamentoCartao, PagamentoBoleto, PagamentoCrediario:
+		return m, nil
+	case "":
+		return "", apperr.Invalid("informe a forma de pagamento (dinheiro, pix, cartao, boleto ou crediario)")
+	}
+	return "", apperr.Invalid("forma de pagamento inválida: %s (use dinheiro, pix, cartao, boleto ou crediario)", v)
+}
+
+// today é a data de hoje sem hora, em UTC (mesmo relógio da validade do orçamento)
+func today() time.Time {
+	t := time.Now().UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// round2 arredonda para centavos
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// installments divide o total em parcelas conforme a forma de pagamento:
+// - dinheiro e PIX: à vista, uma parcela vencendo hoje;
+// - cartão, boleto e crediário: até MaxInstallments parcelas, a primeira em firstDue (padrão:
+// hoje + intervalo) e as demais a cada intervalDays dias (padrão 30).
+// Os centavos que sobram da divisão vão para a primeira parcela. today é a data da venda.
+func installments(method string, total float64, req CreateSaleRequest, today time.Time) ([]Installment, error) {
+	count := req.Installments
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > MaxInstallments {
+		return nil, apperr.Invalid("número de parcelas deve ser entre 1 e %d", MaxInstallments)
+	}
+	if method == PagamentoDinheiro || method == PagamentoPix {
+		if count != 1 {
+			return nil, apperr.Invalid("pagamento em %s é à vista (uma parcela)", strings.ToLower(method))
+		}
+		return []Installment{{Number: 1, DueDate: today.Format(time.DateOnly), Amount: round2(total)}}, nil
+	}
+
+	interval := req.IntervalDays
+	if interval == 0 {
+		interval = 30
+	}
+	if interval < 1 || interval > 365 {
+		return nil, apperr.Invalid("intervalo entre parcelas deve ser entre 1 e 365 dias")
+	}
+	first := today.AddDate(0, 0, interval)
+	if req.FirstDueDate != "" {
+		t, err := time.Parse(time.DateOnly, req.FirstDueDate)
+		if err != nil {
+			return nil, apperr.Invalid("first_due_date inválida (use AAAA-MM-DD)")
+		}
+		if t.Before(today) {
+			return nil, apperr.Invalid("o primeiro vencimento não pode ser no passado")
+		}
+		first = t
+	}
+
+	// divide em centavos para a soma das parcelas fechar com o total
+	cents := int64(math.Round(total * 100))
+	part := cents / int64(count)
+	list := make([]Installment, count)
+	for i := range list {
+		amount := part
+		if i == 0 {
+			amount += cents - part*int64(count)
+		}
+		list[i] = Installment{
+			Number:  i + 1,
+			DueDate: first.AddDate(0, 0, i*interval).Format(time.DateOnly),
+			Amount:  float64(amount) / 100,
+		}
+	}
+	return list, nil
+}
+
+// fromBudget monta a venda com a cópia dos itens e valores do orçamento convertido.
+// As locações não entram (são cobradas pelo contrato de locação): desconto geral, frete e outras
+// despesas do orçamento são rateados pelo valor e a venda fica só com a parte dos produtos.
+func fromBudget(b *budget.Budget) (*Sale, error) {
+	if len(b.Items) == 0 {
+		return nil, apperr.Invalid("orçamento não tem produtos para vender (locação é cobrada pelo contrato)")
+	}
+	s := &Sale{
+		BudgetID:    b.ID,
+		CustomerID:  b.CustomerID,
+		Customer:    b.Customer,
+		WarehouseID: b.WarehouseID,
+		Status:      StatusConcluida,
+	}
+	var net float64
+	for _, it := range b.Items {
+		s.Items = append(s.Items, Item{
+			ProductID: it.ProductID,
+			Product:   it.Product,
+			Quantity:  it.Quantity,
+			UnitPrice: it.UnitPrice,
+			Discount:  it.Discount,
+			Subtotal:  it.Subtotal,
+		})
+		s.Gross += it.Quantity * it.UnitPrice
+		s.ItemsDiscount += it.Discount
+		net += it.Subtotal
+	}
+	s.Gross = round2(s.Gross)
+	s.ItemsDiscount = round2(s.ItemsDiscount)
+	net = round2(net)
+
+	// parte dos produtos no orçamento (1 quando não há locações)
+	var rentals float64
+	for _, r := range b.Rentals {
+		rentals += r.Subtotal
+	}
+	share := 1.0
+	if rentals > 0 && net > 0 {
+		share = net / (net + rentals)
+	}
+	s.Discount = math.Min(round2(b.Discount*share), net)
+	s.Freight = round2(b.Freight * share)
+	s.OtherCharges = round2(b.OtherCharges * share)
+	s.Total = round2(net - s.Discount + s.Freight + s.OtherCharges)
+	return s, nil
+}
+
+// Create converte o orçamento aprovado em venda: na mesma transação o orçamento vira
+// CONVERTIDO (as reservas viram Saida definitiva), os itens são copiados e as parcelas geradas.
+func (s *Service) Create(ctx context.Context, req CreateSaleRequest) (*Sale, error) {
+	if req.BudgetID <= 0 {
+		return nil, apperr.Invalid("informe o budget_id do orçamento aprovado")
+	}
+	method, err := paymentMethod(req.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	saleID, err := s.repo.SaleIDByBudgetTx(ctx, tx, req.BudgetID)
+	if err != nil {
+		return nil, err
+	}
+	if saleID != 0 {
+		return nil, fmt.Errorf("%w: orçamento %d está na venda %d", ErrOrcamentoJaVendido, req.BudgetID, saleID)
+	}
+
+	b, err := s.budgets.ConvertTx(ctx, tx, req.BudgetID)
+	if err != nil {
+		return nil, err
+	}
+	sale, err := fromBudget(b)
+	if err != nil {
+		return nil, err
+	}
+	sale.PaymentMethod = method
+	sale.Notes = strings.TrimSpace(req.Notes)
+	if sale.Installments, err = installments(method, sale.Total, req, today()); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.CreateTx(ctx, tx, sale)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.GetByID(ctx, id)
+}
+
+// GetByID retorna a venda com itens e parcelas, ou erro se não encontrada
+func (s *Service) GetByID(ctx context.Context, id int64) (*Sale, error) {
+	sale, err := s.repo.GetByID(ctx, s.repo.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if sale == nil {
+		return nil, apperr.NotFound("venda com ID %d não encontrada", id)
+	}
+	return sale, nil
+}
+
+// List retorna as vendas, com filtros opcionais de status, cliente e orçamento
+func (s *Service) List(ctx context.Context, status string, customerID int, budgetID int64) ([]Sale, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	if status != "" && status != StatusConcluida && status != StatusCancelada {
+		return nil, apperr.Invalid("status inválido: %s", status)
+	}
+	return s.repo.List(ctx, status, customerID, budgetID)
+}
+
+// Cancel cancela a venda: na mesma transação a mercadoria volta ao estoque (Entrada de
+// devolução) e o orçamento de origem fica CANCELADO
+func (s *Service) Cancel(ctx context.Context, id int64, reason string) (*Sale, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, apperr.Invalid("informe o motivo do cancelamento")
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	sale, err := s.repo.GetByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sale == nil {
+		return nil, apperr.NotFound("venda com ID %d não encontrada", id)
+	}
+	if sale.Status == StatusCancelada {
+		return nil, fmt.Errorf("%w: venda %d", ErrVendaCancelada, id)
+	}
+
+	if err := s.budgets.CancelConvertedTx(ctx, tx, sale.BudgetID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CancelTx(ctx, tx, id, reason); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return s.GetByID(ctx, id)
+}
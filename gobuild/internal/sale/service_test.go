@@ -0,0 +1,159 @@
+package sale
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/budget"
+)
+
+// TestInstallments confere a divisão em parcelas: a soma fecha com o total (os centavos que
+// sobram ficam na primeira) e os vencimentos seguem o intervalo
+func TestInstallments(t *testing.T) {
+	hoje := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		method  string
+		total   float64
+		req     CreateSaleRequest
+		amounts []float64
+		dues    []string
+	}{
+		{
+			name:    "pix à vista vence hoje",
+			method:  PagamentoPix,
+			total:   89.9,
+			amounts: []float64{89.9},
+			dues:    []string{"2026-10-18"},
+		},
+		{
+			name:    "boleto em 3 com sobra de centavos",
+			method:  PagamentoBoleto,
+			total:   100,
+			req:     CreateSaleRequest{Installments: 3},
+			amounts: []float64{33.34, 33.33, 33.33},
+			dues:    []string{"2026-11-17", "2026-12-17", "2027-01-16"},
+		},
+		{
+			name:    "cartão em 2 com primeiro vencimento e intervalo",
+			method:  PagamentoCartao,
+			total:   89,
+			req:     CreateSaleRequest{Installments: 2, FirstDueDate: "2026-11-02", IntervalDays: 15},
+			amounts: []float64{44.5, 44.5},
+			dues:    []string{"2026-11-02", "2026-11-17"},
+		},
+		{
+			name:    "crediário em 12",
+			method:  PagamentoCrediario,
+			total:   1000.01,
+			req:     CreateSaleRequest{Installments: 12, FirstDueDate: "2026-10-18"},
+			amounts: []float64{83.38, 83.33, 83.33, 83.33, 83.33, 83.33, 83.33, 83.33, 83.33, 83.33, 83.33, 83.33},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := installments(tt.method, tt.total, tt.req, hoje)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if len(list) != len(tt.amounts) {
+				t.Fatalf("parcelas = %d, esperado %d", len(list), len(tt.amounts))
+			}
+			var sum float64
+			for i, in := range list {
+				if in.Number != i+1 {
+					t.Errorf("parcela %d: número %d", i+1, in.Number)
+				}
+				if in.Amount != tt.amounts[i] {
+					t.Errorf("parcela %d: valor %v, esperado %v", i+1, in.Amount, tt.amounts[i])
+				}
+				if tt.dues != nil && in.DueDate != tt.dues[i] {
+					t.Errorf("parcela %d: vencimento %s, esperado %s", i+1, in.DueDate, tt.dues[i])
+				}
+				sum += in.Amount
+			}
+			if math.Round(sum*100) != math.Round(tt.total*100) {
+				t.Errorf("soma das parcelas = %v, esperado %v", sum, tt.total)
+			}
+		})
+	}
+}
+
+// TestInstallmentsInvalidas confere as combinações recusadas
+func TestInstallmentsInvalidas(t *testing.T) {
+	hoje := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		method string
+		req    CreateSaleRequest
+	}{
+		{"dinheiro parcelado", PagamentoDinheiro, CreateSaleRequest{Installments: 2}},
+		{"pix parcelado", PagamentoPix, CreateSaleRequest{Installments: 3}},
+		{"acima do máximo", PagamentoBoleto, CreateSaleRequest{Installments: MaxInstallments + 1}},
+		{"parcelas negativas", PagamentoBoleto, CreateSaleRequest{Installments: -1}},
+		{"intervalo grande", PagamentoCrediario, CreateSaleRequest{Installments: 2, IntervalDays: 366}},
+		{"vencimento no passado", PagamentoBoleto, CreateSaleRequest{FirstDueDate: "2026-10-17"}},
+		{"vencimento inválido", PagamentoBoleto, CreateSaleRequest{FirstDueDate: "18/10/2026"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := installments(tt.method, 100, tt.req, hoje); err == nil {
+				t.Error("esperado erro")
+			}
+		})
+	}
+}
+
+// TestFromBudget confere a cópia do orçamento: sem locações os valores vêm inteiros; com
+// locações desconto geral, frete e outras despesas são rateados e o total não inclui a locação
+func TestFromBudget(t *testing.T) {
+	items := []budget.BudgetItem{
+		{ProductID: 1, Product: "Cimento", Quantity: 10, UnitPrice: 30, Discount: 20, Subtotal: 280},
+		{ProductID: 2, Product: "Areia", Quantity: 1, UnitPrice: 20, Subtotal: 20},
+	}
+	tests := []struct {
+		name                                    string
+		budget                                  budget.Budget
+		discount, freight, otherCharges, totals float64
+	}{
+		{
+			name: "só produtos",
+			budget: budget.Budget{Items: items, Gross: 320, ItemsDiscount: 20, Discount: 30, Freight: 20, OtherCharges: 8,
+				Total: 298},
+			discount: 30, freight: 20, otherCharges: 8, totals: 298,
+		},
+		{
+			name: "produtos e locação",
+			budget: budget.Budget{Items: items, Rentals: []budget.BudgetRental{{AssetID: 1, Quantity: 1, Periods: 2, Rate: 50, Subtotal: 100}},
+				Gross: 420, ItemsDiscount: 20, Discount: 40, Freight: 20, OtherCharges: 8, Total: 388},
+			// produtos = 300 de 400 (75%)
+			discount: 30, freight: 15, otherCharges: 6, totals: 291,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := fromBudget(&tt.budget)
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if s.Gross != 320 || s.ItemsDiscount != 20 || len(s.Items) != 2 {
+				t.Errorf("bruto/desconto dos itens/itens = %v/%v/%d, esperado 320/20/2", s.Gross, s.ItemsDiscount, len(s.Items))
+			}
+			if s.Discount != tt.discount || s.Freight != tt.freight || s.OtherCharges != tt.otherCharges || s.Total != tt.totals {
+				t.Errorf("desconto/frete/outras/total = %v/%v/%v/%v, esperado %v/%v/%v/%v",
+					s.Discount, s.Freight, s.OtherCharges, s.Total, tt.discount, tt.freight, tt.otherCharges, tt.totals)
+			}
+			if got := s.Gross - s.ItemsDiscount - s.Discount + s.Freight + s.OtherCharges; math.Abs(got-s.Total) > 0.001 {
+				t.Errorf("composição = %v, total %v", got, s.Total)
+			}
+		})
+	}
+
+	// orçamento só de locação não vira venda
+	if _, err := fromBudget(&budget.Budget{Rentals: []budget.BudgetRental{{Subtotal: 100}}}); err == nil {
+		t.Error("esperado erro para orçamento sem produtos")
+	}
+}
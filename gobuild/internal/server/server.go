@@ -2,15 +2,30 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/EtraudBits/golangProject/gobuild/internal/budget"
+	"github.com/EtraudBits/golangProject/gobuild/internal/customer"
 	"github.com/EtraudBits/golangProject/gobuild/internal/database"
 	dbhandler "github.com/EtraudBits/golangProject/gobuild/internal/handler" // handler de /db-test
+	"github.com/EtraudBits/golangProject/gobuild/internal/inventory"
+	"github.com/EtraudBits/golangProject/gobuild/internal/nfe"
+	"github.com/EtraudBits/golangProject/gobuild/internal/pricing"
 	"github.com/EtraudBits/golangProject/gobuild/internal/product"
+	"github.com/EtraudBits/golangProject/gobuild/internal/purchase"
+	"github.com/EtraudBits/golangProject/gobuild/internal/rental"
+	"github.com/EtraudBits/golangProject/gobuild/internal/sale"
 	stockpkg "github.com/EtraudBits/golangProject/gobuild/internal/stock"
+	"github.com/EtraudBits/golangProject/gobuild/internal/supplier"
+	"github.com/EtraudBits/golangProject/gobuild/internal/warehouse"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -18,14 +33,22 @@ import (
 // Server é o wrapper do Echo usado para organizar o app
 type Server struct {
 	Echo *echo.Echo
+
+	ctx    context.Context    // vida do servidor: cancelado no Shutdown, para as rotinas em segundo plano
+	cancel context.CancelFunc // cancela ctx
+	jobs   sync.WaitGroup     // rotinas em segundo plano ainda rodando
 }
 
+// shutdownTimeout é quanto o Shutdown espera as requisições em andamento terminarem
+const shutdownTimeout = 10 * time.Second
+
 // New cria o servidor com middlewares básicos
 func New() *Server {
 	e := echo.New()
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	return &Server{Echo: e}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{Echo: e, ctx: ctx, cancel: cancel}
 }
 
 // RegisterRoutes registra todas as rotas da aplicação
@@ -38,47 +61,70 @@ func (s *Server) RegisterRoutes() {
 	// rota de teste do banco
 	s.Echo.GET("/db-test", dbhandler.TestDBHandler)
 
-	// --- produtos (já existentes) ---
+	// --- estoque (criado antes de produto e budget para injeção de dependência) ---
 	repo := product.NewRepository(database.DB)
-	svc := product.NewService(repo)
+	stockRepo := stockpkg.NewRepository(database.DB)
+
+	// Funções injetadas para ler/atualizar o estoque do produto — usam o produto repo
+	// e recebem a transação aberta pelo stock.Service (leitura e escrita no mesmo commit).
+	stockSvc := stockpkg.NewService(database.DB, stockRepo, repo.GetStockTx, repo.UpdateStockTx)
+	// alerta de reposição: por enquanto vai para o log (os eventos ficam em GET /api/stock/alerts/events)
+	stockSvc.SetAlertNotifier(func(ev stockpkg.AlertEvent) {
+		log.Printf("⚠️  produto %d chegou ao ponto de pedido (estoque %v, ponto %v): sugerido comprar %v",
+			ev.ProductID, ev.Stock, ev.ReorderPoint, ev.Suggested)
+	})
+
+	// --- produtos (estoque inicial entra como Entrada pelo stockSvc) ---
+	svc := product.NewService(repo, stockSvc)
 	h := product.NewHandler(svc)
 	gp := s.Echo.Group("/api/products")
 	h.RegisterRoutes(gp)
 
-	// --- estoque (criado antes do budget para injeção de dependência) ---
-	stockRepo := stockpkg.NewRepository(database.DB)
-
-	// Função injetada para ler produto (ProductLite) — usa o produto repo/serviço já existente.
-	getProduct := func(ctx context.Context, id int) (float64, error) {
-		// reutilizamos repository product para buscar apenas stock (podia ser otimizada)
-		p, err := repo.GetByID(ctx, id)
-		if err != nil {
-			return 0, err
-		}
-		if p == nil {
-			return 0, nil
-		}
-		return p.Estoque, nil
-	}
+	// --- depósitos (loja, pátio...) ---
+	warehouseHandler := warehouse.NewHandler(warehouse.NewService(warehouse.NewRepository(database.DB)))
+	warehouseHandler.RegisterRoutes(s.Echo.Group("/api/warehouses"))
 
-	// Função injetada para atualizar estoque do produto
-	updateStock := func(ctx context.Context, id int, newStock float64) error {
-		// Faz update direto na tabela products
-		_, err := database.DB.ExecContext(ctx, `UPDATE products SET stock = ? WHERE id = ?`, newStock, id)
-		return err
-	}
-
-	stockSvc := stockpkg.NewService(database.DB, stockRepo, getProduct, updateStock)
 	stockHandler := stockpkg.NewHandler(stockSvc)
 	gs := s.Echo.Group("/api/stock")
 	stockHandler.RegisterRoutes(gs)
+	// rotas administrativas (reconciliação do estoque com o histórico)
+	admin := s.Echo.Group("/api/admin")
+	stockHandler.RegisterAdminRoutes(admin)
+
+	// --- inventário (contagem física; posta Ajustes pelo stockSvc no fechamento) ---
+	inventoryHandler := inventory.NewHandler(inventory.NewService(inventory.NewRepository(database.DB), stockSvc))
+	inventoryHandler.RegisterRoutes(s.Echo.Group("/api/inventory"))
+
+	// --- fornecedores e pedidos de compra (o recebimento posta Entradas com custo pelo stockSvc) ---
+	supplierHandler := supplier.NewHandler(supplier.NewService(supplier.NewRepository(database.DB)))
+	supplierHandler.RegisterRoutes(s.Echo.Group("/api/suppliers"))
+	purchaseHandler := purchase.NewHandler(purchase.NewService(purchase.NewRepository(database.DB), stockSvc))
+	purchaseHandler.RegisterRoutes(s.Echo.Group("/api/purchase-orders"))
+	// importação do XML da NF-e do fornecedor (a confirmação posta as Entradas pelo stockSvc)
+	nfeHandler := nfe.NewHandler(nfe.NewService(nfe.NewRepository(database.DB), stockSvc))
+	nfeHandler.RegisterRoutes(s.Echo.Group("/api/nfe"))
+
+	// --- locação de equipamentos (betoneiras, andaimes...; o budget cota as locações por ele) ---
+	rentalSvc := rental.NewService(rental.NewRepository(database.DB))
+	rentalHandler := rental.NewHandler(rentalSvc)
+	rentalHandler.RegisterRoutes(s.Echo.Group("/api/rentals"))
+
+	// --- clientes (o budget referencia o cliente pelo customer_id) ---
+	customerSvc := customer.NewService(customer.NewRepository(database.DB))
+	customerHandler := customer.NewHandler(customerSvc)
+	customerHandler.RegisterRoutes(s.Echo.Group("/api/customers"))
+
+	// --- tabelas de preço e faixas por quantidade (o budget busca o preço do item por elas) ---
+	pricingSvc := pricing.NewService(pricing.NewRepository(database.DB), svc)
+	pricingHandler := pricing.NewHandler(pricingSvc)
+	pricingHandler.RegisterRoutes(s.Echo.Group("/api/pricing"))
 
 	// -- Modulo budget (depois do stock, pois depende dele)
 	// cria o repositório de budget -> fala com o banco
 	budgetRepo := budget.NewRepository(database.DB)
 
-	//cria o service de budget (injetando stockSvc)
-	budgetSvc := budget.NewService(budgetRepo, svc, stockSvc)
+	//cria o service de budget (injetando pricingSvc, stockSvc, rentalSvc e customerSvc)
+	budgetSvc := budget.NewService(budgetRepo, pricingSvc, stockSvc, rentalSvc, customerSvc)
 
 	// cria o handler HTTP do budget
 	budgetHandler := budget.NewHandler(budgetSvc)
@@ -86,14 +132,62 @@ func (s *Server) RegisterRoutes() {
 	// cria um grupo de Rotas /api/budgets
 	gb := s.Echo.Group("/api/budgets")
 	budgetHandler.RegisterRoutes(gb)
+	// configuração dos orçamentos (limite de desconto, validade) e expiração manual
+	budgetHandler.RegisterAdminRoutes(admin)
+
+	// rotina de validade: de hora em hora expira os orçamentos vencidos (liberando o estoque)
+	s.goJob(func(ctx context.Context) {
+		budgetSvc.RunExpiryJob(ctx, budget.DefaultExpiryInterval, func(expired []int64, err error) {
+			if len(expired) > 0 {
+				log.Printf("⏰ orçamentos expirados por validade: %v", expired)
+			}
+			if err != nil {
+				log.Printf("erro ao expirar orçamentos vencidos: %v", err)
+			}
+		})
+	})
 
+	// --- vendas (criadas a partir do orçamento aprovado; o estoque passa pelo budget) ---
+	saleSvc := sale.NewService(sale.NewRepository(database.DB), budgetSvc)
+	saleHandler := sale.NewHandler(saleSvc)
+	saleHandler.RegisterRoutes(s.Echo.Group("/api/sales"))
+
+}
 
+// goJob roda uma rotina em segundo plano com o contexto do servidor (parada no Shutdown)
+func (s *Server) goJob(job func(ctx context.Context)) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		job(s.ctx)
+	}()
 }
 
-// Start inicia o servidor
+// Start inicia o servidor e espera SIGINT/SIGTERM para desligar (ver Shutdown)
 func (s *Server) Start() {
 	fmt.Println("🔥 Servidor iniciado em http://localhost:8080")
-	if err := s.Echo.Start(":8080"); err != nil {
-		log.Fatalf("Erro ao iniciar servidor: %v", err)
+	go func() {
+		if err := s.Echo.Start(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Erro ao iniciar servidor: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+	s.Shutdown()
+}
+
+// Shutdown para as rotinas em segundo plano (esperando a rodada em andamento) e depois o
+// servidor HTTP, dando às requisições em andamento até shutdownTimeout para terminar
+func (s *Server) Shutdown() {
+	s.cancel()
+	s.jobs.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.Echo.Shutdown(ctx); err != nil {
+		log.Printf("erro ao desligar o servidor: %v", err)
 	}
+	fmt.Println("👋 Servidor desligado")
 }
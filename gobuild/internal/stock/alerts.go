@@ -0,0 +1,93 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+)
+
+// SetAlertNotifier registra quem é avisado quando uma Saida leva um produto ao ponto de pedido
+// (ex.: log, e-mail). É chamado depois do commit nas operações em que o próprio serviço abre
+// a transação; nas feitas dentro da transação de outro módulo (SaidaTx) o evento fica só em
+// stock_alert_events (GET /api/stock/alerts/events).
+func (s *Service) SetAlertNotifier(fn func(AlertEvent)) {
+	s.alertNotifier = fn
+}
+
+// notifyAlerts avisa o notificador dos alertas gerados pelos movimentos (já commitados)
+func (s *Service) notifyAlerts(moves ...*Movement) {
+	if s.alertNotifier == nil {
+		return
+	}
+	for _, m := range moves {
+		if m.Alert != nil {
+			s.alertNotifier(*m.Alert)
+		}
+	}
+}
+
+// checkReorder grava um evento de alerta quando a Saida cruza o ponto de pedido do produto
+// (estava acima e ficou no ponto ou abaixo). Saídas seguintes, já abaixo, não repetem o alerta.
+func (s *Service) checkReorder(ctx context.Context, tx *sql.Tx, m *Movement, movementID int64, before, after float64) error {
+	maxStock, reorderPoint, err := s.repo.GetStockLevelsTx(ctx, tx, m.ProductID)
+	if err != nil {
+		return err
+	}
+	if reorderPoint <= 0 || before <= reorderPoint || after > reorderPoint {
+		return nil
+	}
+	ev := &AlertEvent{
+		ProductID:     m.ProductID,
+		MovementID:    movementID,
+		PreviousStock: before,
+		Stock:         after,
+		ReorderPoint:  reorderPoint,
+		Suggested:     suggestOrder(after, reorderPoint, maxStock),
+	}
+	if ev.ID, err = s.repo.InsertAlertEventTx(ctx, tx, ev); err != nil {
+		return err
+	}
+	m.Alert = ev
+	return nil
+}
+
+// suggestOrder é quanto comprar para repor o produto: até o estoque máximo; sem máximo
+// configurado, até o dobro do ponto de pedido
+func suggestOrder(stock, reorderPoint, maxStock float64) float64 {
+	target := maxStock
+	if target <= 0 {
+		target = 2 * reorderPoint
+	}
+	return max(target-stock, 0)
+}
+
+// Alerts retorna os produtos no ponto de pedido ou abaixo, com a quantidade sugerida de compra
+func (s *Service) Alerts(ctx context.Context) ([]StockAlert, error) {
+	list, err := s.repo.ListBelowReorderPoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		a := &list[i]
+		a.BelowMin = a.MinStock > 0 && a.Stock < a.MinStock
+		a.Suggested = suggestOrder(a.Stock, a.ReorderPoint, a.MaxStock)
+	}
+	if list == nil {
+		list = []StockAlert{}
+	}
+	return list, nil
+}
+
+// AlertEvents retorna os eventos de alerta depois do ID informado (para acompanhar por polling)
+func (s *Service) AlertEvents(ctx context.Context, afterID int64, limit int) ([]AlertEvent, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	list, err := s.repo.ListAlertEvents(ctx, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []AlertEvent{}
+	}
+	return list, nil
+}
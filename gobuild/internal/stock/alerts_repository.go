@@ -0,0 +1,92 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// GetStockLevelsTx lê o estoque máximo e o ponto de pedido do produto
+func (r *Repository) GetStockLevelsTx(ctx context.Context, tx *sql.Tx, productID int) (maxStock, reorderPoint float64, err error) {
+	err = tx.QueryRowContext(ctx,
+		`SELECT max_stock, reorder_point FROM products WHERE id = ?`, productID).Scan(&maxStock, &reorderPoint)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao buscar níveis de estoque do produto: %v", err)
+	}
+	return maxStock, reorderPoint, nil
+}
+
+// ListBelowReorderPoint retorna os produtos com ponto de pedido configurado e estoque no
+// ponto de pedido ou abaixo (os mais críticos primeiro: menor estoque relativo ao ponto)
+func (r *Repository) ListBelowReorderPoint(ctx context.Context) ([]StockAlert, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, name, COALESCE(category, ''), COALESCE(unit, ''), stock, min_stock, max_stock, reorder_point
+		FROM products
+		WHERE reorder_point > 0 AND stock <= reorder_point
+		ORDER BY stock / reorder_point, name`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar produtos no ponto de pedido: %v", err)
+	}
+	defer rows.Close()
+
+	var list []StockAlert
+	for rows.Next() {
+		var a StockAlert
+		if err := rows.Scan(&a.ProductID, &a.Product, &a.Category, &a.Unit, &a.Stock,
+			&a.MinStock, &a.MaxStock, &a.ReorderPoint); err != nil {
+			return nil, fmt.Errorf("erro ao escanear produto no ponto de pedido: %v", err)
+		}
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos alertas: %v", err)
+	}
+	return list, nil
+}
+
+// InsertAlertEventTx grava o evento de alerta na transação do movimento
+func (r *Repository) InsertAlertEventTx(ctx context.Context, tx *sql.Tx, ev *AlertEvent) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_alert_events (product_id, movement_id, previous_stock, stock, reorder_point, suggested_quantity)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		ev.ProductID, ev.MovementID, ev.PreviousStock, ev.Stock, ev.ReorderPoint, ev.Suggested)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao gravar alerta de estoque: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do alerta: %v", err)
+	}
+	return id, nil
+}
+
+// ListAlertEvents retorna os eventos de alerta com ID maior que afterID (mais antigos primeiro),
+// no máximo `limit`
+func (r *Repository) ListAlertEvents(ctx context.Context, afterID int64, limit int) ([]AlertEvent, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT e.id, e.product_id, COALESCE(p.name, ''), e.movement_id, e.previous_stock, e.stock,
+			e.reorder_point, e.suggested_quantity, e.created_at
+		FROM stock_alert_events e
+		LEFT JOIN products p ON p.id = e.product_id
+		WHERE e.id > ?
+		ORDER BY e.id
+		LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar eventos de alerta: %v", err)
+	}
+	defer rows.Close()
+
+	var list []AlertEvent
+	for rows.Next() {
+		var ev AlertEvent
+		if err := rows.Scan(&ev.ID, &ev.ProductID, &ev.Product, &ev.MovementID, &ev.PreviousStock, &ev.Stock,
+			&ev.ReorderPoint, &ev.Suggested, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear evento de alerta: %v", err)
+		}
+		list = append(list, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos eventos de alerta: %v", err)
+	}
+	return list, nil
+}
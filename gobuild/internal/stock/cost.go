@@ -0,0 +1,117 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"errors"       // para erros específicos
+	"sort"         // a camada da entrada estornada é consumida primeiro
+	"time"         // data da valorização
+)
+
+// applyCost calcula o custo do movimento pelos dois métodos e atualiza o custo médio e as
+// camadas FIFO do produto. delta é a variação do total do produto:
+//   - delta > 0 (Entrada, Ajuste para cima): custo = UnitCost informado (sem ele, o custo médio
+//     atual); o custo médio é recalculado e a camada nova é aberta depois do insert;
+//   - delta < 0 (Saida, Ajuste para baixo): CMV pelo custo médio e pelas camadas mais antigas
+//     (o que as camadas não cobrirem sai pelo custo médio); o custo médio não muda.
+//     No estorno de uma entrada sai o custo da própria entrada (a média volta ao que era)
+//     e a camada dela é consumida primeiro.
+func (s *Service) applyCost(ctx context.Context, tx *sql.Tx, m *Movement, currentStock, delta float64) ([]costConsumption, error) {
+	avg, err := s.repo.GetAverageCostTx(ctx, tx, m.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case delta > 0:
+		unit := avg
+		if m.UnitCost != nil {
+			unit = *m.UnitCost
+		}
+		total := delta * unit
+		newAvg := unit
+		if currentStock > 0 {
+			newAvg = (currentStock*avg + total) / (currentStock + delta)
+		}
+		if err := s.repo.SetAverageCostTx(ctx, tx, m.ProductID, newAvg); err != nil {
+			return nil, err
+		}
+		m.UnitCost, m.CostAverage, m.CostFIFO, m.AverageCost = &unit, &total, &total, &newAvg
+		return nil, nil
+
+	case delta < 0:
+		qty := -delta
+		layers, err := s.repo.ListOpenCostLayersTx(ctx, tx, m.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		unit, newAvg := avg, avg
+		if m.reverseCost != nil {
+			unit = *m.reverseCost
+			if rest := currentStock - qty; rest > 0 {
+				newAvg = max((currentStock*avg-qty*unit)/rest, 0)
+				if err := s.repo.SetAverageCostTx(ctx, tx, m.ProductID, newAvg); err != nil {
+					return nil, err
+				}
+			}
+			origin := *m.ReversalOf
+			sort.SliceStable(layers, func(i, j int) bool {
+				return layers[i].MovementID == origin && layers[j].MovementID != origin
+			})
+		}
+		var consumptions []costConsumption
+		fifo, remaining := 0.0, qty
+		for _, l := range layers {
+			if remaining <= 0 {
+				break
+			}
+			take := min(remaining, l.Remaining)
+			if err := s.repo.ConsumeCostLayerTx(ctx, tx, l.ID, take); err != nil {
+				return nil, err
+			}
+			consumptions = append(consumptions, costConsumption{LayerID: l.ID, Quantity: take})
+			fifo += take * l.UnitCost
+			remaining -= take
+		}
+		fifo += remaining * unit // estoque negativo: sem camada para consumir
+		costAvg := qty * unit
+		m.UnitCost, m.CostAverage, m.CostFIFO, m.AverageCost = &unit, &costAvg, &fifo, &newAvg
+		return consumptions, nil
+	}
+
+	// ajuste sem diferença: só registra o custo médio vigente
+	m.AverageCost = &avg
+	return nil, nil
+}
+
+// Valuation retorna o valor do estoque no fim do dia `date` (YYYY-MM-DD; vazio = hoje),
+// por categoria, pelo custo médio e pelas camadas FIFO
+func (s *Service) Valuation(ctx context.Context, date string) (*Valuation, error) {
+	if date == "" {
+		date = time.Now().UTC().Format(time.DateOnly)
+	}
+	if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return nil, errors.New("data inválida: use o formato AAAA-MM-DD")
+	}
+
+	products, err := s.repo.ValuationLayers(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Valuation{Date: date, Categories: []CategoryValuation{}}
+	for _, p := range products {
+		// a consulta vem ordenada por categoria
+		if n := len(v.Categories); n == 0 || v.Categories[n-1].Category != p.Category {
+			v.Categories = append(v.Categories, CategoryValuation{Category: p.Category})
+		}
+		c := &v.Categories[len(v.Categories)-1]
+		c.Quantity += p.Quantity
+		c.ValueAverage += p.ValueAverage
+		c.ValueFIFO += p.ValueFIFO
+		c.Products = append(c.Products, p)
+		v.TotalAverage += p.ValueAverage
+		v.TotalFIFO += p.ValueFIFO
+	}
+	return v, nil
+}
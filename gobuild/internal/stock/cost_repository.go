@@ -0,0 +1,135 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// GetAverageCostTx lê o custo médio atual do produto
+func (r *Repository) GetAverageCostTx(ctx context.Context, tx *sql.Tx, productID int) (float64, error) {
+	var cost float64
+	err := tx.QueryRowContext(ctx, `SELECT average_cost FROM products WHERE id = ?`, productID).Scan(&cost)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao ler custo médio do produto: %v", err)
+	}
+	return cost, nil
+}
+
+// SetAverageCostTx grava o novo custo médio do produto
+func (r *Repository) SetAverageCostTx(ctx context.Context, tx *sql.Tx, productID int, cost float64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE products SET average_cost = ? WHERE id = ?`, cost, productID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar custo médio do produto: %v", err)
+	}
+	return nil
+}
+
+// costLayer é uma camada FIFO com saldo
+type costLayer struct {
+	ID         int64
+	MovementID int64 // movimento que abriu a camada (0 = estoque anterior à migração 0008)
+	UnitCost   float64
+	Remaining  float64
+}
+
+// ListOpenCostLayersTx retorna as camadas com saldo do produto, da mais antiga para a mais nova
+func (r *Repository) ListOpenCostLayersTx(ctx context.Context, tx *sql.Tx, productID int) ([]costLayer, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, COALESCE(movement_id, 0), unit_cost, remaining FROM stock_cost_layers
+		WHERE product_id = ? AND remaining > 0 ORDER BY id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar camadas de custo: %v", err)
+	}
+	defer rows.Close()
+
+	var list []costLayer
+	for rows.Next() {
+		var l costLayer
+		if err := rows.Scan(&l.ID, &l.MovementID, &l.UnitCost, &l.Remaining); err != nil {
+			return nil, fmt.Errorf("erro ao escanear camada de custo: %v", err)
+		}
+		list = append(list, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das camadas de custo: %v", err)
+	}
+	return list, nil
+}
+
+// ConsumeCostLayerTx baixa `qty` do saldo da camada
+func (r *Repository) ConsumeCostLayerTx(ctx context.Context, tx *sql.Tx, layerID int64, qty float64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE stock_cost_layers SET remaining = remaining - ? WHERE id = ?`, qty, layerID)
+	if err != nil {
+		return fmt.Errorf("erro ao consumir camada de custo: %v", err)
+	}
+	return nil
+}
+
+// InsertCostLayerTx abre uma camada FIFO para a entrada do movimento
+func (r *Repository) InsertCostLayerTx(ctx context.Context, tx *sql.Tx, productID int, movementID int64, unitCost, qty float64) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_cost_layers (product_id, movement_id, unit_cost, quantity, remaining) VALUES (?, ?, ?, ?, ?)`,
+		productID, movementID, unitCost, qty, qty)
+	if err != nil {
+		return fmt.Errorf("erro ao inserir camada de custo: %v", err)
+	}
+	return nil
+}
+
+// InsertCostConsumptionsTx grava quanto de cada camada o movimento consumiu
+func (r *Repository) InsertCostConsumptionsTx(ctx context.Context, tx *sql.Tx, movementID int64, list []costConsumption) error {
+	for _, c := range list {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO stock_cost_consumptions (movement_id, layer_id, quantity) VALUES (?, ?, ?)`,
+			movementID, c.LayerID, c.Quantity)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar consumo de camada de custo: %v", err)
+		}
+	}
+	return nil
+}
+
+// ValuationLayers retorna, para cada produto, quantidade e valor FIFO no fim do dia `date`
+// (YYYY-MM-DD): camadas abertas até a data menos o que foi consumido até a data.
+// Também traz o custo médio vigente na data (do último movimento com custo até ela).
+func (r *Repository) ValuationLayers(ctx context.Context, date string) ([]ProductValuation, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`WITH remaining AS (
+			SELECT l.product_id, l.unit_cost,
+				l.quantity - COALESCE((
+					SELECT SUM(c.quantity) FROM stock_cost_consumptions c
+					JOIN stock_movements m ON m.id = c.movement_id
+					WHERE c.layer_id = l.id AND date(m.created_at) <= ?), 0) AS qty
+			FROM stock_cost_layers l
+			WHERE date(l.created_at) <= ?
+		)
+		SELECT p.id, p.name, COALESCE(p.category, ''),
+			SUM(rm.qty), SUM(rm.qty * rm.unit_cost),
+			COALESCE((SELECT m.average_cost FROM stock_movements m
+				WHERE m.product_id = p.id AND m.average_cost IS NOT NULL AND date(m.created_at) <= ?
+				ORDER BY m.id DESC LIMIT 1), 0)
+		FROM remaining rm
+		JOIN products p ON p.id = rm.product_id
+		GROUP BY p.id
+		HAVING SUM(rm.qty) > 0
+		ORDER BY p.category, p.name`, date, date, date)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular valor do estoque: %v", err)
+	}
+	defer rows.Close()
+
+	var list []ProductValuation
+	for rows.Next() {
+		var v ProductValuation
+		if err := rows.Scan(&v.ProductID, &v.Product, &v.Category, &v.Quantity, &v.ValueFIFO, &v.AverageCost); err != nil {
+			return nil, fmt.Errorf("erro ao escanear valor do estoque: %v", err)
+		}
+		v.ValueAverage = v.Quantity * v.AverageCost
+		list = append(list, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração do valor do estoque: %v", err)
+	}
+	return list, nil
+}
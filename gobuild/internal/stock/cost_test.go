@@ -0,0 +1,42 @@
+package stock_test
+
+import (
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// TestCustoFIFOeMedio: duas Entradas com custos diferentes recalculam o custo médio; a Saida
+// custa pelo médio (sem mudá-lo) e pelas camadas FIFO, esgotando a mais antiga antes da seguinte
+func TestCustoFIFOeMedio(t *testing.T) {
+	svc, _, productID := reservaSetup(t, 0)
+
+	e1, _ := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Entrada", Quantity: 10, UnitCost: custo(20)})
+	if *e1.AverageCost != 20 {
+		t.Errorf("custo médio após a 1ª entrada = %v, esperado 20", *e1.AverageCost)
+	}
+	e2, _ := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Entrada", Quantity: 30, UnitCost: custo(30)})
+	if *e2.AverageCost != 27.5 { // (10x20 + 30x30) / 40
+		t.Errorf("custo médio após a 2ª entrada = %v, esperado 27.5", *e2.AverageCost)
+	}
+
+	s1, _ := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Saida", Quantity: 15})
+	if *s1.CostAverage != 412.5 || *s1.CostFIFO != 350 { // médio 15x27.5; FIFO 10x20 + 5x30
+		t.Errorf("CMV médio/FIFO = %v/%v, esperado 412.5/350", *s1.CostAverage, *s1.CostFIFO)
+	}
+	if *s1.AverageCost != 27.5 {
+		t.Errorf("custo médio após a saída = %v, esperado 27.5", *s1.AverageCost)
+	}
+
+	// a camada de 20 acabou: a próxima saída custa só pela de 30
+	s2, _ := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Saida", Quantity: 5})
+	if *s2.CostFIFO != 150 {
+		t.Errorf("CMV FIFO da 2ª saída = %v, esperado 150", *s2.CostFIFO)
+	}
+
+	// nova entrada recalcula a média sobre o saldo que sobrou: (20x27.5 + 20x35) / 40
+	e3, _ := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Entrada", Quantity: 20, UnitCost: custo(35)})
+	if *e3.AverageCost != 31.25 {
+		t.Errorf("custo médio após a 3ª entrada = %v, esperado 31.25", *e3.AverageCost)
+	}
+}
@@ -1,8 +1,12 @@
 package stock
 
 import (
-	"net/http" // para constantes de status HTTP
-	"strconv"  // para conversão de strings
+	"encoding/csv" // exportação do kardex/posição em CSV
+	"errors"       // identifica os erros de estorno (409)
+	"fmt"          // nome do arquivo CSV
+	"net/http"     // para constantes de status HTTP
+	"strconv"      // para conversão de strings
+	"strings"      // identifica erro de "não encontrado"
 
 	"github.com/labstack/echo/v4" // framework web Echo
 	"golang.org/x/net/context"    // para contexto em handlers
@@ -15,23 +19,82 @@ type Handler struct {
 }
 
 // NwHandler cria um handler com o serviço injetado
-func NewHandler(svc *Service) *Handler { 
+func NewHandler(svc *Service) *Handler {
 	return &Handler{svc: svc} // inicializa o handler com o serviço
 }
 
-//RegistreRoutes registra as rotas de estoque num grupo Echo,
-//ex.: g := e.Group("/api/stock"); h.RegisterRoutes(g).
+// RegistreRoutes registra as rotas de estoque num grupo Echo,
+// ex.: g := e.Group("/api/stock"); h.RegisterRoutes(g).
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.POST("/entrada", h.Entrada)
 	g.POST("/saida", h.Saida)
 	g.POST("/ajuste", h.Ajuste)
+	g.POST("/transferencia", h.Transferencia)
+	g.POST("/movements/batch", h.Lote)
+	g.POST("/movements/:id/reverse", h.Estornar)
 	g.GET("/historico/:product_id", h.Historico)
+	g.GET("/reservas/:product_id", h.Reservas)
+	g.POST("/reservas/expirar", h.ExpirarReservas)
+	g.GET("/lotes/vencendo", h.LotesVencendo)
+	g.GET("/lotes/:product_id", h.Lotes)
+	g.GET("/valorizacao", h.Valorizacao)
+	g.GET("/kardex/:product_id", h.Kardex)
+	g.GET("/posicao", h.Posicao)
+	g.GET("/alerts", h.Alertas)
+	g.GET("/alerts/events", h.EventosAlerta)
+	g.GET("/reposicao", h.Reposicao)
 }
 
-//Entrada esperam JSON: {"product_id": 1, "quantity": 10, "description": "Compra fornecedor"}
+// RegisterAdminRoutes registra as rotas administrativas de estoque (ex.: grupo /api/admin)
+func (h *Handler) RegisterAdminRoutes(g *echo.Group) {
+	// GET só relata as divergências; POST posta os ajustes corretivos
+	g.GET("/stock/reconcile", h.Reconciliacao)
+	g.POST("/stock/reconcile", h.Reconciliar)
+	// política de estoque negativo (global, por categoria ou por produto)
+	g.GET("/stock/negative-policy", h.PoliticasNegativo)
+	g.PUT("/stock/negative-policy", h.DefinirPoliticaNegativo)
+	g.DELETE("/stock/negative-policy", h.RemoverPoliticaNegativo)
+}
+
+// Entrada esperam JSON: {"product_id": 1, "quantity": 10, "reason": "compra", "document": "nf:12345", "notes": "fornecedor X"}
+// warehouse_id é opcional (sem ele, o movimento vai para o depósito padrão)
+// lot e expires_at (AAAA-MM-DD) são opcionais; na saída sem lote a baixa segue FEFO
+// unit_cost é o custo unitário da entrada (opcional; sem ele vale o custo médio atual)
+// reason (compra, venda, devolução, quebra, perda, inventário), document e notes são opcionais
 type movimentRequest struct {
-	ProductID int `json:"product_id"`
-	Quantity float64 `json:"quantity"`
+	ProductID   int      `json:"product_id"`
+	WarehouseID int      `json:"warehouse_id"`
+	Quantity    float64  `json:"quantity"`
+	Lot         string   `json:"lot"`
+	ExpiresAt   string   `json:"expires_at"`
+	UnitCost    *float64 `json:"unit_cost"`
+	Reason      string   `json:"reason"`
+	Document    string   `json:"document"`
+	Notes       string   `json:"notes"`
+}
+
+// batchRequest é um lote de movimentos aplicados juntos (tudo ou nada). Ex.:
+// {"document": "nf:12345", "reason": "compra", "movements": [{"type": "entrada", "product_id": 1, "quantity": 40, "unit_cost": 28.5}, ...]}
+// reason, document e notes do lote valem para as linhas que não informam os seus
+type batchRequest struct {
+	Reason    string      `json:"reason"`
+	Document  string      `json:"document"`
+	Notes     string      `json:"notes"`
+	Movements []batchItem `json:"movements"`
+}
+
+// batchItem é uma linha do lote: tipo (entrada, saida, ajuste) + os campos de um movimento
+type batchItem struct {
+	Type string `json:"type"`
+	movimentRequest
+}
+
+// transferRequest espera JSON: {"product_id": 1, "from_warehouse_id": 1, "to_warehouse_id": 2, "quantity": 10}
+type transferRequest struct {
+	ProductID       int     `json:"product_id"`
+	FromWarehouseID int     `json:"from_warehouse_id"`
+	ToWarehouseID   int     `json:"to_warehouse_id"`
+	Quantity        float64 `json:"quantity"`
 }
 
 // Entrada cria um movimento de tipo ENTRADA
@@ -40,74 +103,540 @@ func (h *Handler) Entrada(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "requisição inválida"})
 	}
-	m := &Movement {
-		ProductID: req.ProductID,
-		Type: "Entrada",
-		Quantity: req.Quantity,
+	m := &Movement{
+		ProductID:   req.ProductID,
+		WarehouseID: req.WarehouseID,
+		Type:        "Entrada",
+		Quantity:    req.Quantity,
+		Lot:         req.Lot,
+		ExpiresAt:   req.ExpiresAt,
+		UnitCost:    req.UnitCost,
+		Reason:      req.Reason,
+		Document:    req.Document,
+		Notes:       req.Notes,
 	}
-	
-	id, err:= h.svc.CreateMovement(c.Request().Context(), m)
+
+	id, err := h.svc.CreateMovement(c.Request().Context(), m)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return movementError(c, http.StatusBadRequest, err)
 	}
+	return c.JSON(http.StatusCreated, movementResponse(id, m))
+}
 
-	return c.JSON(http.StatusOK, map[string]int64{"movement_id": id})
-	}
-	
-	// saida cria um movimento de tipo SAIDA
-	func (h *Handler) Saida(c echo.Context) error {
+// saida cria um movimento de tipo SAIDA
+func (h *Handler) Saida(c echo.Context) error {
 	var req movimentRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "requisição inválida"})
 	}
-	
+
 	m := &Movement{
-		ProductID: req.ProductID,
-		Type: "Saida",
-		Quantity: req.Quantity,
+		ProductID:   req.ProductID,
+		WarehouseID: req.WarehouseID,
+		Type:        "Saida",
+		Quantity:    req.Quantity,
+		Lot:         req.Lot,
+		ExpiresAt:   req.ExpiresAt,
+		UnitCost:    req.UnitCost,
+		Reason:      req.Reason,
+		Document:    req.Document,
+		Notes:       req.Notes,
 	}
 
 	id, err := h.svc.CreateMovement(c.Request().Context(), m)
 	if err != nil {
+		return movementError(c, http.StatusBadRequest, err)
+	}
+	return c.JSON(http.StatusCreated, movementResponse(id, m))
+}
+
+// Ajuste define o estoque diretamente (tipo AJUSTE) - quantity é o novo saldo do depósito
+func (h *Handler) Ajuste(c echo.Context) error {
+	var req movimentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	m := &Movement{
+		ProductID:   req.ProductID,
+		WarehouseID: req.WarehouseID,
+		Type:        "Ajuste",
+		Quantity:    req.Quantity,
+		Lot:         req.Lot,
+		ExpiresAt:   req.ExpiresAt,
+		UnitCost:    req.UnitCost,
+		Reason:      req.Reason,
+		Document:    req.Document,
+		Notes:       req.Notes,
+	}
+
+	id, err := h.svc.CreateMovement(c.Request().Context(), m)
+	if err != nil {
+		return movementError(c, http.StatusBadRequest, err)
+	}
+	return c.JSON(http.StatusCreated, movementResponse(id, m))
+}
+
+// Transferencia move estoque entre depósitos (Saida na origem + Entrada no destino, atômico)
+func (h *Handler) Transferencia(c echo.Context) error {
+	var req transferRequest
+	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "requisição inválida"})
 	}
-	return c.JSON(http.StatusCreated, map[string]int64{"movement_id": id})
+
+	t, err := h.svc.Transfer(c.Request().Context(), req.ProductID, req.FromWarehouseID, req.ToWarehouseID, req.Quantity)
+	if err != nil {
+		return movementError(c, http.StatusBadRequest, err)
+	}
+	return c.JSON(http.StatusCreated, t)
 }
 
-	//Ajuste define o estoque diretamente (tipo AJUSTE) - quantity é o novo estoque
-	func (h *Handler) Ajuste(c echo.Context) error {
-		var req movimentRequest
-		if err := c.Bind(&req); err != nil {
+// Lote aplica vários movimentos numa transação só e devolve o resultado por linha
+func (h *Handler) Lote(c echo.Context) error {
+	var req batchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "requisição inválida"})
+	}
+
+	moves := make([]*Movement, len(req.Movements))
+	for i, it := range req.Movements {
+		m := &Movement{
+			ProductID:   it.ProductID,
+			WarehouseID: it.WarehouseID,
+			Type:        movementType(it.Type),
+			Quantity:    it.Quantity,
+			Lot:         it.Lot,
+			ExpiresAt:   it.ExpiresAt,
+			UnitCost:    it.UnitCost,
+			Reason:      it.Reason,
+			Document:    it.Document,
+			Notes:       it.Notes,
+		}
+		if m.Reason == "" {
+			m.Reason = req.Reason
+		}
+		if m.Document == "" {
+			m.Document = req.Document
+		}
+		if m.Notes == "" {
+			m.Notes = req.Notes
+		}
+		moves[i] = m
+	}
+
+	result, err := h.svc.CreateBatch(c.Request().Context(), moves)
+	if err != nil {
+		if result == nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		var ise *InsufficientStockError
+		if errors.As(err, &ise) {
+			return c.JSON(http.StatusConflict, result)
+		}
+		return c.JSON(http.StatusBadRequest, result)
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// movementResponse é a resposta de um movimento simples (com o aviso da política AVISAR, se houver)
+func movementResponse(id int64, m *Movement) map[string]any {
+	resp := map[string]any{"movement_id": id}
+	if m.Warning != "" {
+		resp["warning"] = m.Warning
+	}
+	if m.Alert != nil {
+		resp["alert"] = m.Alert
+	}
+	return resp
+}
+
+// movementError responde o erro de um movimento: 409 com a quantidade disponível para
+// estoque insuficiente, `status` para os demais
+func movementError(c echo.Context, status int, err error) error {
+	var ise *InsufficientStockError
+	if errors.As(err, &ise) {
+		return c.JSON(http.StatusConflict, map[string]any{
+			"error":        err.Error(),
+			"product_id":   ise.ProductID,
+			"warehouse_id": ise.WarehouseID,
+			"available":    ise.Available,
+			"requested":    ise.Requested,
+		})
+	}
+	return c.JSON(status, map[string]string{"error": err.Error()})
+}
+
+// movementType aceita o tipo em qualquer caixa e com acento ("entrada", "SAÍDA") e devolve
+// o nome gravado no banco; tipos desconhecidos voltam como vieram (a validação recusa)
+func movementType(v string) string {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "entrada":
+		return "Entrada"
+	case "saida", "saída":
+		return "Saida"
+	case "ajuste":
+		return "Ajuste"
+	}
+	return v
+}
+
+// reverseRequest é o corpo opcional do estorno: {"notes": "entrada digitada em duplicidade"}
+type reverseRequest struct {
+	Notes string `json:"notes"`
+}
+
+// Estornar posta o movimento compensatório de um movimento (ligado ao original)
+func (h *Handler) Estornar(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req reverseRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "requisição inválida"})
+	}
+
+	r, err := h.svc.Reverse(c.Request().Context(), id, req.Notes)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case strings.HasSuffix(err.Error(), "não encontrado"):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrJaEstornado), errors.Is(err, ErrNaoEstornavel):
+			status = http.StatusConflict
+		}
+		return movementError(c, status, err)
+	}
+	return c.JSON(http.StatusCreated, r)
+}
+
+// Historico retorna lista de movimentos de um produto
+// Query opcional: reason (ex.: quebra) e document (ex.: orcamento:7)
+func (h *Handler) Historico(c echo.Context) error {
+	idStr := c.Param("product_id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Product_id inválido"})
+	}
+
+	reason := c.QueryParam("reason")
+	if reason != "" {
+		if _, err := ParseReason(reason); err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 		}
+	}
 
-	m := &Movement{
-		ProductID: req.ProductID,
-		Type: "Ajuste",
-		Quantity: req.Quantity,
+	list, err := h.svc.GetHistory(context.Background(), id, reason, c.QueryParam("document"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "erro ao obter historico do produto"})
 	}
+	return c.JSON(http.StatusOK, list)
+}
 
-	id, err := h.svc.CreateMovement(c.Request().Context(), m)
+// Reservas retorna as reservas ativas (não vencidas) de um produto
+func (h *Handler) Reservas(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("product_id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Product_id inválido"})
+	}
+
+	list, err := h.svc.ListReservations(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "erro ao obter reservas do produto"})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// ExpirarReservas marca como EXPIRADA as reservas vencidas
+func (h *Handler) ExpirarReservas(c echo.Context) error {
+	n, err := h.svc.ExpireReservations(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]int64{"expiradas": n})
+}
+
+// Lotes retorna os lotes com saldo de um produto (ordem FEFO por depósito)
+func (h *Handler) Lotes(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Product_id inválido"})
 	}
-	return c.JSON(http.StatusCreated, map[string]int64{"movement_id": id})
+
+	list, err := h.svc.ListLots(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "erro ao obter lotes do produto"})
 	}
+	return c.JSON(http.StatusOK, list)
+}
 
-	//Historico retorna lista de movimentos de um produto
-	func (h *Handler) Historico(c echo.Context) error {
-		idStr := c.Param("product_id")
-		id, err := strconv.Atoi(idStr)
+// LotesVencendo lista os lotes com saldo que vencem nos próximos ?dias=N (padrão 30) e os já vencidos
+func (h *Handler) LotesVencendo(c echo.Context) error {
+	days := 30
+	if d := c.QueryParam("dias"); d != "" {
+		n, err := strconv.Atoi(d)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Product_id inválido"})
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "dias inválido"})
 		}
+		days = n
+	}
+
+	list, err := h.svc.ExpiringLots(c.Request().Context(), days)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// Reconciliacao compara o estoque gravado com o histórico de movimentos (sem corrigir)
+func (h *Handler) Reconciliacao(c echo.Context) error {
+	r, err := h.svc.Reconcile(c.Request().Context(), false)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+// Reconciliar compara e posta os ajustes corretivos das divergências
+func (h *Handler) Reconciliar(c echo.Context) error {
+	r, err := h.svc.Reconcile(c.Request().Context(), true)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+// PoliticasNegativo lista as regras de estoque negativo
+func (h *Handler) PoliticasNegativo(c echo.Context) error {
+	list, err := h.svc.NegativePolicies(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// negativePolicyRequest define uma regra. Ex.: {"policy": "avisar"} (global),
+// {"category": "Materiais", "policy": "permitir"} ou {"product_id": 1, "policy": "bloquear"}
+type negativePolicyRequest struct {
+	ProductID int    `json:"product_id"`
+	Category  string `json:"category"`
+	Policy    string `json:"policy"`
+}
+
+// DefinirPoliticaNegativo grava a regra de estoque negativo do escopo informado
+func (h *Handler) DefinirPoliticaNegativo(c echo.Context) error {
+	var req negativePolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "requisição inválida"})
+	}
+	p, err := h.svc.SetNegativePolicy(c.Request().Context(), NegativePolicy{
+		ProductID: req.ProductID, Category: req.Category, Policy: req.Policy,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.HasSuffix(err.Error(), "não encontrado") {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// RemoverPoliticaNegativo remove a regra de um produto (?product_id=) ou categoria (?category=)
+func (h *Handler) RemoverPoliticaNegativo(c echo.Context) error {
+	productID, err := queryInt(c, "product_id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "product_id inválido"})
+	}
+	if err := h.svc.DeleteNegativePolicy(c.Request().Context(), productID, c.QueryParam("category")); err != nil {
+		status := http.StatusBadRequest
+		if strings.HasSuffix(err.Error(), "não encontrado") || strings.HasSuffix(err.Error(), "não encontrada") {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Alertas lista os produtos no ponto de pedido ou abaixo, com a quantidade sugerida de compra
+func (h *Handler) Alertas(c echo.Context) error {
+	list, err := h.svc.Alerts(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// EventosAlerta lista os eventos de alerta (Saidas que chegaram ao ponto de pedido).
+// Query: desde (último ID já lido, padrão 0) e limite (padrão 100)
+func (h *Handler) EventosAlerta(c echo.Context) error {
+	after, err1 := queryInt(c, "desde")
+	limit, err2 := queryInt(c, "limite")
+	if err1 != nil || err2 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "desde e limite devem ser números"})
+	}
+	list, err := h.svc.AlertEvents(c.Request().Context(), int64(after), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
 
-		list, err := h.svc.GetHistory(context.Background(), id)
+// Reposicao retorna o relatório de reposição calculado das Saidas.
+// Query: dias (janela, padrão 90), prazo (dias de entrega para produtos sem prazo, padrão 7),
+// nivel_servico (%, padrão 95), cobertura (dias, padrão 30), parado (dias sem venda, padrão 60)
+// e formato=csv
+func (h *Handler) Reposicao(c echo.Context) error {
+	window, err1 := queryInt(c, "dias")
+	lead, err2 := queryInt(c, "prazo")
+	coverage, err3 := queryInt(c, "cobertura")
+	slow, err4 := queryInt(c, "parado")
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dias, prazo, cobertura e parado devem ser números inteiros"})
+	}
+	params := ReplenishmentParams{WindowDays: window, LeadTimeDays: lead, CoverageDays: coverage, SlowDays: slow}
+	if v := c.QueryParam("nivel_servico"); v != "" {
+		level, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "erro ao obter historico do produto"})
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "nivel_servico inválido (ex.: 95)"})
+		}
+		params.ServiceLevel = level / 100
+	}
+
+	report, err := h.svc.Replenishment(c.Request().Context(), params)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if c.QueryParam("formato") != "csv" {
+		return c.JSON(http.StatusOK, report)
+	}
+	var rows [][]string
+	for _, it := range report.Items {
+		daysOfStock, daysWithoutSale := "", ""
+		if it.DaysOfStock != nil {
+			daysOfStock = formatFloat(*it.DaysOfStock)
+		}
+		if it.DaysWithoutSale != nil {
+			daysWithoutSale = strconv.Itoa(*it.DaysWithoutSale)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(it.ProductID), it.Product, it.Category, it.Unit,
+			formatFloat(it.Stock), formatFloat(it.Reserved), formatFloat(it.Available), formatFloat(it.OnOrder),
+			formatFloat(it.AvgDaily), formatFloat(it.StdDaily), strconv.Itoa(it.LeadTimeDays),
+			formatFloat(it.SafetyStock), formatFloat(it.ReorderPoint), daysOfStock,
+			formatFloat(it.Suggested), it.LastSale, daysWithoutSale, strconv.FormatBool(it.SlowMover),
+		})
+	}
+	header := []string{"produto_id", "produto", "categoria", "unidade", "estoque", "reservado", "disponivel", "em_pedido",
+		"consumo_medio_dia", "desvio_dia", "prazo_dias", "estoque_seguranca", "ponto_pedido", "dias_de_estoque",
+		"sugestao_compra", "ultima_venda", "dias_sem_venda", "parado"}
+	return writeCSV(c, "reposicao.csv", header, rows)
+}
+
+// Valorizacao retorna o valor do estoque por categoria no fim do dia ?data=AAAA-MM-DD (padrão: hoje),
+// pelo custo médio e pelo FIFO
+func (h *Handler) Valorizacao(c echo.Context) error {
+	v, err := h.svc.Valuation(c.Request().Context(), c.QueryParam("data"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, v)
+}
+
+// Kardex retorna a ficha de estoque do produto no período.
+// Query: de, ate (AAAA-MM-DD ou AAAA-MM-DDTHH:MM:SS), warehouse_id, pagina, por_pagina e formato=csv
+func (h *Handler) Kardex(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Product_id inválido"})
+	}
+	warehouseID, err1 := queryInt(c, "warehouse_id")
+	page, err2 := queryInt(c, "pagina")
+	perPage, err3 := queryInt(c, "por_pagina")
+	if err1 != nil || err2 != nil || err3 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "warehouse_id, pagina e por_pagina devem ser números"})
+	}
+
+	k, err := h.svc.Kardex(c.Request().Context(), id, warehouseID, c.QueryParam("de"), c.QueryParam("ate"), page, perPage)
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.HasSuffix(err.Error(), "não encontrado") {
+			status = http.StatusNotFound
+		}
+		return c.JSON(status, map[string]string{"error": err.Error()})
+	}
+
+	if c.QueryParam("formato") != "csv" {
+		return c.JSON(http.StatusOK, k)
+	}
+	rows := [][]string{{"", k.From, "SALDO INICIAL", "", "", "", formatFloat(k.Opening), "", "", ""}}
+	for _, e := range k.Entries {
+		cost := ""
+		if e.UnitCost != nil {
+			cost = formatFloat(*e.UnitCost)
 		}
-		return c.JSON(http.StatusOK, list)
+		rows = append(rows, []string{
+			strconv.Itoa(e.MovementID), e.CreatedAt, e.Type, strconv.Itoa(e.WarehouseID),
+			formatFloat(e.Quantity), formatFloat(e.Change), formatFloat(e.Balance), cost,
+			e.Reason, e.Document,
+		})
 	}
+	rows = append(rows, []string{"", k.To, "SALDO FINAL", "", "", "", formatFloat(k.Closing), "", "", ""})
+	header := []string{"movimento", "data", "tipo", "deposito", "quantidade", "variacao", "saldo", "custo_unitario", "motivo", "documento"}
+	return writeCSV(c, fmt.Sprintf("kardex-%d.csv", id), header, rows)
+}
 
+// Posicao retorna o estoque de todos os produtos num instante, reconstruído do histórico.
+// Query: em (AAAA-MM-DD = fim do dia, ou AAAA-MM-DDTHH:MM:SS; padrão agora), warehouse_id e formato=csv
+func (h *Handler) Posicao(c echo.Context) error {
+	warehouseID, err := queryInt(c, "warehouse_id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "warehouse_id inválido"})
+	}
 
+	snap, err := h.svc.Snapshot(c.Request().Context(), warehouseID, c.QueryParam("em"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
+	if c.QueryParam("formato") != "csv" {
+		return c.JSON(http.StatusOK, snap)
+	}
+	var rows [][]string
+	for _, it := range snap.Items {
+		rows = append(rows, []string{
+			strconv.Itoa(it.ProductID), it.Product, it.Category, it.Unit, formatFloat(it.Quantity),
+		})
+	}
+	header := []string{"produto_id", "produto", "categoria", "unidade", "quantidade"}
+	return writeCSV(c, "posicao-estoque.csv", header, rows)
+}
+
+// queryInt lê um parâmetro inteiro opcional da query (0 quando ausente)
+func queryInt(c echo.Context, name string) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// formatFloat formata quantidades sem zeros à direita (ex.: 12.5, 40)
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// writeCSV responde com um arquivo CSV para download
+func writeCSV(c echo.Context, filename string, header []string, rows [][]string) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	return w.WriteAll(rows) // WriteAll já faz o Flush
+}
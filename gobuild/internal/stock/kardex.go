@@ -0,0 +1,128 @@
+package stock
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"errors"  // para erros específicos
+	"fmt"     // para formatação de strings e erros
+	"time"    // período do Kardex e momento do snapshot
+)
+
+// parseMoment converte a data/hora recebida na query ("AAAA-MM-DD", "AAAA-MM-DDTHH:MM:SS" ou
+// RFC3339) para o formato gravado pelo SQLite (UTC). Só com a data, endOfDay escolhe entre
+// o começo (00:00:00) e o fim (23:59:59) do dia.
+func parseMoment(v string, endOfDay bool) (string, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t.Format(time.DateTime), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", time.DateTime} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC().Format(time.DateTime), nil
+		}
+	}
+	return "", fmt.Errorf("data inválida (%s): use AAAA-MM-DD ou AAAA-MM-DDTHH:MM:SS", v)
+}
+
+// Kardex monta a ficha de estoque do produto no período [from, to]: saldo inicial (tudo
+// antes de from), cada movimento com o saldo corrente e saldo final. warehouseID 0 = todos
+// os depósitos. Com perPage > 0 devolve só a página pedida (saldos continuam do período todo).
+func (s *Service) Kardex(ctx context.Context, productID, warehouseID int, from, to string, page, perPage int) (*Kardex, error) {
+	exists, err := s.repo.ProductExists(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("produto com ID %d não encontrado", productID)
+	}
+
+	k := &Kardex{ProductID: productID, WarehouseID: warehouseID, Entries: []KardexEntry{}}
+	if from != "" {
+		if k.From, err = parseMoment(from, false); err != nil {
+			return nil, err
+		}
+	}
+	if to == "" {
+		k.To = time.Now().UTC().Format(time.DateTime)
+	} else if k.To, err = parseMoment(to, true); err != nil {
+		return nil, err
+	}
+	if k.From != "" && k.From > k.To { // mesmo formato, então a comparação de texto vale
+		return nil, errors.New("período inválido: início depois do fim")
+	}
+
+	before, inRange, err := s.repo.ListLedgerByProduct(ctx, productID, warehouseID, k.From, k.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// saldos por depósito: o Ajuste antigo define o saldo de um depósito, não do total
+	balances := map[int]float64{}
+	for _, m := range before {
+		k.Opening += applyLedger(balances, m)
+	}
+	running := k.Opening
+	for _, m := range inRange {
+		change := applyLedger(balances, m)
+		running += change
+		k.Entries = append(k.Entries, KardexEntry{
+			MovementID:  m.ID,
+			CreatedAt:   m.CreatedAt,
+			Type:        m.Type,
+			WarehouseID: m.WarehouseID,
+			Quantity:    m.Quantity,
+			Change:      change,
+			Balance:     running,
+			UnitCost:    m.UnitCost,
+			Reason:      m.Reason,
+			Document:    m.Document,
+		})
+	}
+	k.Closing = running
+	k.Total = len(k.Entries)
+
+	if perPage > 0 {
+		if page < 1 {
+			page = 1
+		}
+		start := min((page-1)*perPage, len(k.Entries))
+		end := min(start+perPage, len(k.Entries))
+		k.Entries = k.Entries[start:end]
+	}
+	return k, nil
+}
+
+// Snapshot reconstrói, a partir de stock_movements, o estoque de todos os produtos no instante
+// `at` (vazio = agora). warehouseID 0 = soma de todos os depósitos.
+func (s *Service) Snapshot(ctx context.Context, warehouseID int, at string) (*Snapshot, error) {
+	snap := &Snapshot{WarehouseID: warehouseID}
+	if at == "" {
+		snap.At = time.Now().UTC().Format(time.DateTime)
+	} else {
+		var err error
+		if snap.At, err = parseMoment(at, true); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := s.repo.ListSnapshotProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	moves, err := s.repo.ListLedgerUntil(ctx, warehouseID, snap.At)
+	if err != nil {
+		return nil, err
+	}
+	ledger := replayLedger(moves)
+	for i := range items {
+		for _, qty := range ledger[items[i].ProductID] {
+			items[i].Quantity += qty
+		}
+	}
+	snap.Items = items
+	if snap.Items == nil {
+		snap.Items = []SnapshotItem{}
+	}
+	return snap, nil
+}
@@ -0,0 +1,106 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// ledgerColumns são as colunas lidas por scanLedger (mesma ordem)
+const ledgerColumns = `id, product_id, warehouse_id, tipo, quantidade, delta, unit_cost,
+	COALESCE(reason, ''), COALESCE(document, ''), created_at`
+
+// scanLedger lê movimentos com o necessário para reproduzir saldos
+func scanLedger(rows *sql.Rows) ([]Movement, error) {
+	var list []Movement
+	for rows.Next() {
+		var m Movement
+		var delta, unitCost sql.NullFloat64 // NULL em movimentos antigos
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.WarehouseID, &m.Type, &m.Quantity, &delta, &unitCost,
+			&m.Reason, &m.Document, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear movimentação de estoque: %v", err)
+		}
+		m.Delta = nullFloat(delta)
+		m.UnitCost = nullFloat(unitCost)
+		list = append(list, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das movimentações: %v", err)
+	}
+	return list, nil
+}
+
+// ListLedgerByProduct retorna os movimentos do produto até `to`, separados entre os anteriores
+// a `from` (formam o saldo inicial) e os do período. warehouseID 0 = todos os depósitos.
+// Datas no formato "YYYY-MM-DD HH:MM:SS" (UTC); from vazio = sem movimentos anteriores.
+func (r *Repository) ListLedgerByProduct(ctx context.Context, productID, warehouseID int, from, to string) ([]Movement, []Movement, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+ledgerColumns+`, (? <> '' AND datetime(created_at) < datetime(?))
+		FROM stock_movements
+		WHERE product_id = ? AND (? = 0 OR warehouse_id = ?) AND datetime(created_at) <= datetime(?)
+		ORDER BY id`,
+		from, from, productID, warehouseID, warehouseID, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao buscar movimentações de estoque: %v", err)
+	}
+	defer rows.Close()
+
+	var before, inRange []Movement
+	for rows.Next() {
+		var m Movement
+		var delta, unitCost sql.NullFloat64
+		var isBefore bool
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.WarehouseID, &m.Type, &m.Quantity, &delta, &unitCost,
+			&m.Reason, &m.Document, &m.CreatedAt, &isBefore); err != nil {
+			return nil, nil, fmt.Errorf("erro ao escanear movimentação de estoque: %v", err)
+		}
+		m.Delta = nullFloat(delta)
+		m.UnitCost = nullFloat(unitCost)
+		if isBefore {
+			before = append(before, m)
+		} else {
+			inRange = append(inRange, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("erro durante iteração das movimentações: %v", err)
+	}
+	return before, inRange, nil
+}
+
+// ListLedgerUntil retorna todos os movimentos até o instante `at` ("YYYY-MM-DD HH:MM:SS", UTC),
+// em ordem. warehouseID 0 = todos os depósitos.
+func (r *Repository) ListLedgerUntil(ctx context.Context, warehouseID int, at string) ([]Movement, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+ledgerColumns+` FROM stock_movements
+		WHERE (? = 0 OR warehouse_id = ?) AND datetime(created_at) <= datetime(?)
+		ORDER BY id`, warehouseID, warehouseID, at)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar movimentações de estoque: %v", err)
+	}
+	defer rows.Close()
+	return scanLedger(rows)
+}
+
+// ListSnapshotProducts retorna todos os produtos (quantidade zerada) para o snapshot
+func (r *Repository) ListSnapshotProducts(ctx context.Context) ([]SnapshotItem, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, name, COALESCE(category, ''), COALESCE(unit, '') FROM products ORDER BY category, name`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar produtos: %v", err)
+	}
+	defer rows.Close()
+
+	var list []SnapshotItem
+	for rows.Next() {
+		var it SnapshotItem
+		if err := rows.Scan(&it.ProductID, &it.Product, &it.Category, &it.Unit); err != nil {
+			return nil, fmt.Errorf("erro ao escanear produto: %v", err)
+		}
+		list = append(list, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos produtos: %v", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,120 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"errors"       // para erros específicos
+	"fmt"          // para formatação de strings e erros
+)
+
+// allocateLots decide quais lotes o movimento mexe, atualiza o saldo deles e devolve a alocação:
+//   - Entrada com lote: soma no lote (cria se não existir);
+//   - Saida com lote: tira daquele lote (precisa ter saldo);
+//   - Saida sem lote: FEFO — tira primeiro dos lotes que vencem antes (lotes vencidos não saem);
+//     o que os lotes não cobrirem sai do estoque sem lote (nunca de lote vencido);
+//   - Ajuste com lote: o lote passa a ter exatamente m.Quantity (alocação = diferença).
+//
+// Movimentos sem lote de produtos sem controle de lote não geram alocação.
+func (s *Service) allocateLots(ctx context.Context, tx *sql.Tx, m *Movement, balance float64) ([]LotAllocation, error) {
+	switch m.Type {
+	case "Entrada", "Ajuste":
+		if m.Lot == "" {
+			return nil, nil
+		}
+		lot, err := s.lotForEntry(ctx, tx, m)
+		if err != nil {
+			return nil, err
+		}
+		delta := m.Quantity
+		if m.Type == "Ajuste" {
+			delta = m.Quantity - lot.Quantity
+		}
+		if err := s.repo.AddLotQuantityTx(ctx, tx, lot.ID, delta); err != nil {
+			return nil, err
+		}
+		return []LotAllocation{{LotID: lot.ID, Lot: lot.Lot, ExpiresAt: lot.ExpiresAt, Quantity: delta}}, nil
+
+	case "Saida":
+		if m.Lot != "" {
+			lot, err := s.repo.GetLotTx(ctx, tx, m.ProductID, m.WarehouseID, m.Lot)
+			if err != nil {
+				return nil, err
+			}
+			if lot == nil {
+				return nil, fmt.Errorf("lote %s não encontrado no depósito %d", m.Lot, m.WarehouseID)
+			}
+			if lot.Quantity < m.Quantity {
+				return nil, fmt.Errorf("lote %s tem apenas %v em estoque", lot.Lot, lot.Quantity)
+			}
+			if err := s.repo.AddLotQuantityTx(ctx, tx, lot.ID, -m.Quantity); err != nil {
+				return nil, err
+			}
+			return []LotAllocation{{LotID: lot.ID, Lot: lot.Lot, ExpiresAt: lot.ExpiresAt, Quantity: m.Quantity}}, nil
+		}
+
+		// FEFO: lotes válidos, do que vence primeiro para o que vence por último
+		lots, err := s.repo.ListLotsFEFOTx(ctx, tx, m.ProductID, m.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+		var allocs []LotAllocation
+		remaining := m.Quantity
+		for _, lot := range lots {
+			if remaining <= 0 {
+				break
+			}
+			take := min(remaining, lot.Quantity)
+			if err := s.repo.AddLotQuantityTx(ctx, tx, lot.ID, -take); err != nil {
+				return nil, err
+			}
+			allocs = append(allocs, LotAllocation{LotID: lot.ID, Lot: lot.Lot, ExpiresAt: lot.ExpiresAt, Quantity: take})
+			remaining -= take
+		}
+		if remaining > 0 {
+			// o restante só pode sair do saldo que não está em lote nenhum
+			inLots, err := s.repo.SumLotsTx(ctx, tx, m.ProductID, m.WarehouseID)
+			if err != nil {
+				return nil, err
+			}
+			if inLots > 0 && remaining > balance-inLots {
+				return nil, fmt.Errorf("saldo em lotes válidos insuficiente: faltam %v (lotes vencidos não saem)", remaining)
+			}
+		}
+		return allocs, nil
+	}
+	return nil, nil
+}
+
+// lotForEntry busca o lote do movimento (criando se ainda não existir) e confere a validade
+func (s *Service) lotForEntry(ctx context.Context, tx *sql.Tx, m *Movement) (*Lot, error) {
+	lot, err := s.repo.GetLotTx(ctx, tx, m.ProductID, m.WarehouseID, m.Lot)
+	if err != nil {
+		return nil, err
+	}
+	if lot != nil {
+		if m.ExpiresAt != "" && lot.ExpiresAt != "" && m.ExpiresAt != lot.ExpiresAt {
+			return nil, fmt.Errorf("lote %s já cadastrado com validade %s", lot.Lot, lot.ExpiresAt)
+		}
+		return lot, nil
+	}
+
+	lot = &Lot{ProductID: m.ProductID, WarehouseID: m.WarehouseID, Lot: m.Lot, ExpiresAt: m.ExpiresAt}
+	lot.ID, err = s.repo.InsertLotTx(ctx, tx, lot)
+	if err != nil {
+		return nil, err
+	}
+	return lot, nil
+}
+
+// ListLots retorna os lotes com saldo de um produto, em ordem FEFO por depósito
+func (s *Service) ListLots(ctx context.Context, productID int) ([]Lot, error) {
+	return s.repo.ListLotsByProduct(ctx, productID)
+}
+
+// ExpiringLots retorna os lotes com saldo que vencem nos próximos `days` dias (e os já vencidos)
+func (s *Service) ExpiringLots(ctx context.Context, days int) ([]Lot, error) {
+	if days < 0 {
+		return nil, errors.New("número de dias não pode ser negativo")
+	}
+	return s.repo.ListExpiringLots(ctx, days)
+}
@@ -0,0 +1,147 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// lotColumns são as colunas lidas por scanLots (mesma ordem)
+const lotColumns = `l.id, l.product_id, COALESCE(p.name, ''), l.warehouse_id, l.lot, COALESCE(l.expires_at, ''), l.quantity,
+	CASE WHEN l.expires_at IS NULL THEN NULL
+	     ELSE CAST(julianday(l.expires_at) - julianday(date('now')) AS INTEGER) END,
+	l.created_at`
+
+// GetLotTx busca um lote pelo código (nil, nil se não existir)
+func (r *Repository) GetLotTx(ctx context.Context, tx *sql.Tx, productID, warehouseID int, lot string) (*Lot, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+lotColumns+`
+		FROM stock_lots l LEFT JOIN products p ON p.id = l.product_id
+		WHERE l.product_id = ? AND l.warehouse_id = ? AND l.lot = ?`,
+		productID, warehouseID, lot)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lote: %v", err)
+	}
+	defer rows.Close()
+	list, err := scanLots(rows)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// InsertLotTx cria um lote (quantidade inicial zero; o movimento ajusta depois)
+func (r *Repository) InsertLotTx(ctx context.Context, tx *sql.Tx, l *Lot) (int64, error) {
+	var expiresAt any // NULL quando o lote não tem validade
+	if l.ExpiresAt != "" {
+		expiresAt = l.ExpiresAt
+	}
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_lots (product_id, warehouse_id, lot, expires_at, quantity) VALUES (?, ?, ?, ?, 0)`,
+		l.ProductID, l.WarehouseID, l.Lot, expiresAt)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir lote: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// AddLotQuantityTx soma `delta` (pode ser negativo) ao saldo do lote
+func (r *Repository) AddLotQuantityTx(ctx context.Context, tx *sql.Tx, lotID int64, delta float64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE stock_lots SET quantity = quantity + ? WHERE id = ?`, delta, lotID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar saldo do lote: %v", err)
+	}
+	return nil
+}
+
+// ListLotsFEFOTx retorna os lotes com saldo e dentro da validade, na ordem FEFO
+// (vence primeiro, sai primeiro; lotes sem validade por último)
+func (r *Repository) ListLotsFEFOTx(ctx context.Context, tx *sql.Tx, productID, warehouseID int) ([]Lot, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+lotColumns+`
+		FROM stock_lots l LEFT JOIN products p ON p.id = l.product_id
+		WHERE l.product_id = ? AND l.warehouse_id = ? AND l.quantity > 0
+		  AND (l.expires_at IS NULL OR l.expires_at >= date('now'))
+		ORDER BY l.expires_at IS NULL, l.expires_at, l.id`,
+		productID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lotes (FEFO): %v", err)
+	}
+	defer rows.Close()
+	return scanLots(rows)
+}
+
+// SumLotsTx soma o saldo de todos os lotes do produto no depósito (inclusive vencidos)
+func (r *Repository) SumLotsTx(ctx context.Context, tx *sql.Tx, productID, warehouseID int) (float64, error) {
+	var total float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(quantity), 0) FROM stock_lots WHERE product_id = ? AND warehouse_id = ?`,
+		productID, warehouseID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao somar lotes: %v", err)
+	}
+	return total, nil
+}
+
+// InsertMovementLotsTx grava quanto de cada lote o movimento movimentou
+func (r *Repository) InsertMovementLotsTx(ctx context.Context, tx *sql.Tx, movementID int64, allocs []LotAllocation) error {
+	for _, a := range allocs {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO stock_movement_lots (movement_id, lot_id, quantity) VALUES (?, ?, ?)`,
+			movementID, a.LotID, a.Quantity)
+		if err != nil {
+			return fmt.Errorf("erro ao gravar lote da movimentação: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListLotsByProduct retorna os lotes com saldo de um produto (todos os depósitos), em ordem FEFO
+func (r *Repository) ListLotsByProduct(ctx context.Context, productID int) ([]Lot, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+lotColumns+`
+		FROM stock_lots l LEFT JOIN products p ON p.id = l.product_id
+		WHERE l.product_id = ? AND l.quantity <> 0
+		ORDER BY l.warehouse_id, l.expires_at IS NULL, l.expires_at, l.id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lotes do produto: %v", err)
+	}
+	defer rows.Close()
+	return scanLots(rows)
+}
+
+// ListExpiringLots retorna os lotes com saldo que vencem em até `days` dias (inclui os já vencidos)
+func (r *Repository) ListExpiringLots(ctx context.Context, days int) ([]Lot, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+lotColumns+`
+		FROM stock_lots l LEFT JOIN products p ON p.id = l.product_id
+		WHERE l.quantity > 0 AND l.expires_at IS NOT NULL
+		  AND l.expires_at <= date('now', ?)
+		ORDER BY l.expires_at, l.product_id, l.warehouse_id`, fmt.Sprintf("+%d days", days))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lotes a vencer: %v", err)
+	}
+	defer rows.Close()
+	return scanLots(rows)
+}
+
+// scanLots lê as linhas selecionadas com lotColumns
+func scanLots(rows *sql.Rows) ([]Lot, error) {
+	var list []Lot
+	for rows.Next() {
+		var l Lot
+		var days sql.NullInt64
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.Product, &l.WarehouseID, &l.Lot, &l.ExpiresAt, &l.Quantity, &days, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear lote: %v", err)
+		}
+		if days.Valid {
+			d := int(days.Int64)
+			l.DaysToExpire = &d
+		}
+		list = append(list, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos lotes: %v", err)
+	}
+	return list, nil
+}
@@ -0,0 +1,40 @@
+package stock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// TestSaidaFEFO: a Saida sem lote tira primeiro do lote que vence antes, mesmo que tenha
+// entrado depois, e só passa ao lote seguinte quando ele acaba
+func TestSaidaFEFO(t *testing.T) {
+	svc, _, productID := reservaSetup(t, 0)
+	hoje := time.Now().UTC()
+	tarde := hoje.AddDate(0, 6, 0).Format(time.DateOnly)
+	cedo := hoje.AddDate(0, 2, 0).Format(time.DateOnly)
+
+	movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Entrada", Quantity: 5, Lot: "L-TARDE", ExpiresAt: tarde})
+	movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Entrada", Quantity: 5, Lot: "L-CEDO", ExpiresAt: cedo})
+
+	s1, _ := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Saida", Quantity: 3})
+	if len(s1.Lots) != 1 || s1.Lots[0].Lot != "L-CEDO" || s1.Lots[0].Quantity != 3 {
+		t.Errorf("lotes da 1ª saída = %+v, esperado 3 de L-CEDO", s1.Lots)
+	}
+
+	s2, _ := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Saida", Quantity: 4})
+	if len(s2.Lots) != 2 || s2.Lots[0].Lot != "L-CEDO" || s2.Lots[0].Quantity != 2 ||
+		s2.Lots[1].Lot != "L-TARDE" || s2.Lots[1].Quantity != 2 {
+		t.Errorf("lotes da 2ª saída = %+v, esperado 2 de L-CEDO e 2 de L-TARDE", s2.Lots)
+	}
+
+	lots, err := svc.ListLots(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("erro ao listar lotes: %v", err)
+	}
+	if len(lots) != 1 || lots[0].Lot != "L-TARDE" || lots[0].Quantity != 3 {
+		t.Errorf("lotes com saldo = %+v, esperado só L-TARDE com 3", lots)
+	}
+}
@@ -2,9 +2,373 @@ package stock
 
 // Model representa uma movimentação de estoque
 type Movement struct {
-	ID int `json:"id"` // ID da movimentação
-	ProductID int `json:"product_id"` // ID do produto relacionado
-	Type string `json:"type"` // Tipo de movimentação: "Entrada", "Saida", "Ajuste"
-	Quantity float64 `json:"quantity"` // Quantidade movimentada
-	CreatedAt string `json:"created_at"` // Timestamp da movimentação pelo SQLite
-}
\ No newline at end of file
+	ID          int     `json:"id"`           // ID da movimentação
+	ProductID   int     `json:"product_id"`   // ID do produto relacionado
+	WarehouseID int     `json:"warehouse_id"` // depósito movimentado (0 = depósito padrão)
+	Type        string  `json:"type"`         // Tipo de movimentação: "Entrada", "Saida", "Ajuste"
+	Quantity    float64 `json:"quantity"`     // Quantidade movimentada
+	CreatedAt   string  `json:"created_at"`   // Timestamp da movimentação pelo SQLite
+
+	// Saldo do depósito antes do movimento e diferença aplicada (no Ajuste mostram o que
+	// havia e quanto foi corrigido). Nulos em movimentos anteriores à migração 0007.
+	PreviousQuantity *float64 `json:"previous_quantity,omitempty"`
+	Delta            *float64 `json:"delta,omitempty"`
+
+	// Custo: na Entrada (e no Ajuste que aumenta o saldo) UnitCost é o custo unitário informado;
+	// nos demais é o custo médio aplicado. CostAverage/CostFIFO são o custo total pelos dois
+	// métodos (na Saida, o CMV) e AverageCost o custo médio do produto depois do movimento.
+	UnitCost    *float64 `json:"unit_cost,omitempty"`
+	CostAverage *float64 `json:"cost_average,omitempty"`
+	CostFIFO    *float64 `json:"cost_fifo,omitempty"`
+	AverageCost *float64 `json:"average_cost,omitempty"`
+
+	// transfer marca as pernas de uma transferência: o estoque só muda de lugar, então
+	// não há custo, camada FIFO nem CMV
+	transfer bool
+
+	// reverseCost é o custo unitário da entrada sendo estornada: a saída compensatória tira
+	// esse custo da média e consome primeiro a camada FIFO aberta pela entrada
+	reverseCost *float64
+
+	// Lote (opcional): na Entrada identifica o lote recebido; na Saida força um lote
+	// específico (sem ele, a saída segue FEFO); no Ajuste ajusta só aquele lote.
+	Lot       string          `json:"lot,omitempty"`
+	ExpiresAt string          `json:"expires_at,omitempty"` // validade do lote na Entrada (YYYY-MM-DD)
+	Lots      []LotAllocation `json:"lots,omitempty"`       // lotes efetivamente movimentados
+
+	// Rastreabilidade: motivo (lista controlada, ver Motivo*), documento de origem
+	// (ex.: "nf:12345", "orcamento:7") e observação livre
+	Reason   string `json:"reason,omitempty"`
+	Document string `json:"document,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+
+	// Estorno: ReversalOf é o movimento que este compensa; ReversedBy (só leitura) é o
+	// estorno deste movimento, se houver
+	ReversalOf *int64 `json:"reversal_of,omitempty"`
+	ReversedBy *int64 `json:"reversed_by,omitempty"`
+
+	// Warning é o aviso da política AVISAR quando o movimento deixa o saldo negativo
+	// (só na resposta; não é gravado)
+	Warning string `json:"warning,omitempty"`
+
+	// Alert é o evento gravado quando esta Saida levou o produto ao ponto de pedido
+	Alert *AlertEvent `json:"alert,omitempty"`
+}
+
+// Motivos de movimentação (lista controlada; o motivo é opcional)
+const (
+	MotivoCompra        = "COMPRA"        // entrada de fornecedor
+	MotivoVenda         = "VENDA"         // saída para cliente
+	MotivoDevolucao     = "DEVOLUCAO"     // devolução de cliente (entrada) ou ao fornecedor (saída)
+	MotivoQuebra        = "QUEBRA"        // avaria
+	MotivoPerda         = "PERDA"         // extravio, furto, vencimento
+	MotivoTransferencia = "TRANSFERENCIA" // pernas de uma transferência entre depósitos
+	MotivoInventario    = "INVENTARIO"    // ajuste de contagem física
+)
+
+// reasonTypes diz em que tipos de movimento cada motivo faz sentido
+var reasonTypes = map[string][]string{
+	MotivoCompra:        {"Entrada"},
+	MotivoVenda:         {"Saida"},
+	MotivoDevolucao:     {"Entrada", "Saida"},
+	MotivoQuebra:        {"Saida", "Ajuste"},
+	MotivoPerda:         {"Saida", "Ajuste"},
+	MotivoTransferencia: {"Entrada", "Saida"},
+	MotivoInventario:    {"Ajuste"},
+}
+
+// DefaultWarehouseID é o depósito usado quando o movimento não informa um
+// (mesmo id do depósito padrão criado pela migração 0005)
+const DefaultWarehouseID = 1
+
+// Transfer é o resultado de uma transferência entre depósitos (par Saida/Entrada)
+type Transfer struct {
+	ProductID       int     `json:"product_id"`
+	FromWarehouseID int     `json:"from_warehouse_id"`
+	ToWarehouseID   int     `json:"to_warehouse_id"`
+	Quantity        float64 `json:"quantity"`
+	SaidaID         int64   `json:"saida_movement_id"`
+	EntradaIDs      []int64 `json:"entrada_movement_ids"` // uma Entrada por lote transferido
+	Warning         string  `json:"warning,omitempty"`    // política AVISAR: origem ficou negativa
+}
+
+// Reversal é o resultado de um estorno: o movimento original e os compensatórios
+// (uma saída com vários lotes volta como uma entrada por lote)
+type Reversal struct {
+	MovementID  int64   `json:"movement_id"`
+	ReversalIDs []int64 `json:"reversal_movement_ids"`
+	Warning     string  `json:"warning,omitempty"` // política AVISAR: o estorno deixou o saldo negativo
+}
+
+// Políticas de estoque negativo (o que fazer quando um movimento deixaria o saldo do depósito abaixo de zero)
+const (
+	PoliticaBloquear = "BLOQUEAR" // recusa o movimento (erro de estoque insuficiente)
+	PoliticaPermitir = "PERMITIR" // deixa ficar negativo
+	PoliticaAvisar   = "AVISAR"   // deixa ficar negativo e avisa na resposta
+)
+
+// DefaultNegativePolicy vale quando não há regra nenhuma (nem a global)
+const DefaultNegativePolicy = PoliticaBloquear
+
+// Escopos de uma regra de estoque negativo (vale a mais específica)
+const (
+	EscopoGlobal    = "GLOBAL"
+	EscopoCategoria = "CATEGORIA"
+	EscopoProduto   = "PRODUTO"
+)
+
+// NegativePolicy é uma regra de estoque negativo: global, de uma categoria ou de um produto
+type NegativePolicy struct {
+	Scope     string `json:"scope"`
+	ProductID int    `json:"product_id,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Policy    string `json:"policy"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// StockAlert é um produto no ponto de pedido (ou abaixo) com a sugestão de compra
+type StockAlert struct {
+	ProductID    int     `json:"product_id"`
+	Product      string  `json:"product"`
+	Category     string  `json:"category"`
+	Unit         string  `json:"unit"`
+	Stock        float64 `json:"stock"`
+	MinStock     float64 `json:"min_stock"`
+	MaxStock     float64 `json:"max_stock"`
+	ReorderPoint float64 `json:"reorder_point"`
+	BelowMin     bool    `json:"below_min"`          // abaixo do estoque de segurança
+	Suggested    float64 `json:"suggested_quantity"` // ver suggestOrder
+}
+
+// AlertEvent é o registro de uma Saida que levou o estoque do produto ao ponto de pedido
+type AlertEvent struct {
+	ID            int64   `json:"id"`
+	ProductID     int     `json:"product_id"`
+	Product       string  `json:"product,omitempty"`
+	MovementID    int64   `json:"movement_id"`
+	PreviousStock float64 `json:"previous_stock"`
+	Stock         float64 `json:"stock"`
+	ReorderPoint  float64 `json:"reorder_point"`
+	Suggested     float64 `json:"suggested_quantity"`
+	CreatedAt     string  `json:"created_at,omitempty"`
+}
+
+// ReplenishmentParams são os parâmetros do relatório de reposição (zero = padrão)
+type ReplenishmentParams struct {
+	WindowDays   int     // janela de Saidas analisada, em dias (padrão 90)
+	LeadTimeDays int     // prazo de entrega para produtos sem prazo cadastrado (padrão 7)
+	ServiceLevel float64 // nível de serviço do estoque de segurança, entre 0.5 e 0.999 (padrão 0.95)
+	CoverageDays int     // quantos dias de consumo a compra deve cobrir além do prazo (padrão 30)
+	SlowDays     int     // sem venda há N dias (com estoque) = produto parado (padrão 60)
+}
+
+// Replenishment é o relatório de reposição: consumo das Saidas na janela e sugestão de compra
+type Replenishment struct {
+	From         string              `json:"from"` // início da janela (UTC)
+	WindowDays   int                 `json:"window_days"`
+	LeadTimeDays int                 `json:"lead_time_days"`
+	ServiceLevel float64             `json:"service_level"`
+	CoverageDays int                 `json:"coverage_days"`
+	SlowDays     int                 `json:"slow_days"`
+	Items        []ReplenishmentItem `json:"items"`
+}
+
+// ReplenishmentItem é a linha de um produto no relatório de reposição.
+// Consumo diário = Saidas da janela (sem transferências e estornos) / dias da janela;
+// segurança = z(nível de serviço) × desvio diário × √prazo; ponto de pedido = consumo no
+// prazo + segurança; com a posição (disponível + em pedido) no ponto ou abaixo, sugere
+// completar até o ponto de pedido + consumo da cobertura.
+type ReplenishmentItem struct {
+	ProductID       int      `json:"product_id"`
+	Product         string   `json:"product"`
+	Category        string   `json:"category"`
+	Unit            string   `json:"unit"`
+	Stock           float64  `json:"stock"`
+	Reserved        float64  `json:"reserved"`
+	Available       float64  `json:"available"`
+	OnOrder         float64  `json:"on_order"`  // pendente em pedidos de compra abertos
+	TotalOut        float64  `json:"total_out"` // saída na janela
+	SaleDays        int      `json:"sale_days"` // dias da janela com saída
+	AvgDaily        float64  `json:"avg_daily"`
+	StdDaily        float64  `json:"std_daily"`
+	LeadTimeDays    int      `json:"lead_time_days"`
+	SafetyStock     float64  `json:"safety_stock"`
+	ReorderPoint    float64  `json:"reorder_point"`
+	DaysOfStock     *float64 `json:"days_of_stock"` // quanto o disponível dura no consumo médio (nulo sem consumo)
+	Suggested       float64  `json:"suggested_quantity"`
+	LastSale        string   `json:"last_sale,omitempty"`
+	DaysWithoutSale *int     `json:"days_without_sale"` // nulo = nunca vendeu
+	SlowMover       bool     `json:"slow_mover"`
+}
+
+// MaxBatchSize é o máximo de movimentos aceitos num lote (uma transação só)
+const MaxBatchSize = 500
+
+// BatchResult é o resultado de um lote de movimentos: ou todos foram aplicados, ou nenhum
+type BatchResult struct {
+	Applied bool        `json:"applied"`
+	Error   string      `json:"error,omitempty"` // primeiro erro que impediu o lote
+	Lines   []BatchLine `json:"lines"`
+}
+
+// BatchLine é o resultado de uma linha do lote (Line começa em 1)
+type BatchLine struct {
+	Line        int             `json:"line"`
+	Type        string          `json:"type"`
+	ProductID   int             `json:"product_id"`
+	WarehouseID int             `json:"warehouse_id"`
+	Quantity    float64         `json:"quantity"`
+	MovementID  *int64          `json:"movement_id,omitempty"` // só quando o lote foi aplicado
+	Lots        []LotAllocation `json:"lots,omitempty"`
+	Warning     string          `json:"warning,omitempty"`
+	Alert       *AlertEvent     `json:"alert,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// Lot é o saldo de um lote de um produto em um depósito
+type Lot struct {
+	ID           int64   `json:"id"`
+	ProductID    int     `json:"product_id"`
+	Product      string  `json:"product,omitempty"` // nome do produto (relatórios)
+	WarehouseID  int     `json:"warehouse_id"`
+	Lot          string  `json:"lot"`
+	ExpiresAt    string  `json:"expires_at"` // YYYY-MM-DD ("" = sem validade)
+	Quantity     float64 `json:"quantity"`
+	DaysToExpire *int    `json:"days_to_expire,omitempty"` // negativo = já vencido
+	CreatedAt    string  `json:"created_at"`
+}
+
+// LotAllocation é a parte de um movimento atribuída a um lote
+// (no Ajuste, Quantity é a diferença aplicada ao lote e pode ser negativa)
+type LotAllocation struct {
+	LotID     int64   `json:"lot_id"`
+	Lot       string  `json:"lot"`
+	ExpiresAt string  `json:"expires_at,omitempty"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// Status de uma reserva de estoque
+const (
+	ReservaAtiva      = "ATIVA"      // segura o estoque (conta no "reservado")
+	ReservaLiberada   = "LIBERADA"   // orçamento cancelado/expirado/alterado
+	ReservaConvertida = "CONVERTIDA" // virou Saida (orçamento convertido em venda)
+	ReservaExpirada   = "EXPIRADA"   // passou de expires_at sem conversão
+)
+
+// Reservation é uma quantidade de um produto separada para um orçamento aprovado.
+// Não mexe no estoque físico (products.stock), só reduz o disponível.
+type Reservation struct {
+	ID          int64   `json:"id"`
+	BudgetID    int64   `json:"budget_id"`
+	ProductID   int     `json:"product_id"`
+	WarehouseID int     `json:"warehouse_id"`
+	Quantity    float64 `json:"quantity"`
+	Status      string  `json:"status"`
+	ExpiresAt   string  `json:"expires_at"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// Reconciliation é o resultado da conferência do estoque gravado (products.stock e
+// stock_balances) contra o histórico de movimentos (ledger) reproduzido do zero
+type Reconciliation struct {
+	Products   int     `json:"products"`   // produtos conferidos
+	Mismatches int     `json:"mismatches"` // produtos com divergência
+	Fixed      bool    `json:"fixed"`      // se os ajustes corretivos foram postados
+	Drifts     []Drift `json:"drifts"`     // só os produtos divergentes
+}
+
+// Drift é a divergência de um produto
+type Drift struct {
+	ProductID  int              `json:"product_id"`
+	Product    string           `json:"product"`
+	Stock      float64          `json:"stock"`    // products.stock
+	Balances   float64          `json:"balances"` // soma dos saldos por depósito
+	Ledger     float64          `json:"ledger"`   // soma reproduzida dos movimentos
+	Warehouses []WarehouseDrift `json:"warehouses,omitempty"`
+}
+
+// WarehouseDrift é a divergência entre o saldo gravado de um depósito e o ledger
+type WarehouseDrift struct {
+	WarehouseID int     `json:"warehouse_id"`
+	Balance     float64 `json:"balance"`
+	Ledger      float64 `json:"ledger"`
+	Difference  float64 `json:"difference"`            // saldo - ledger
+	MovementID  *int64  `json:"movement_id,omitempty"` // ajuste corretivo postado
+}
+
+// costConsumption é quanto um movimento consumiu de uma camada FIFO
+type costConsumption struct {
+	LayerID  int64
+	Quantity float64
+}
+
+// Valuation é o valor do estoque numa data, por categoria, pelos dois métodos de custo
+type Valuation struct {
+	Date         string              `json:"date"`          // YYYY-MM-DD (fim do dia)
+	TotalAverage float64             `json:"total_average"` // valor pelo custo médio
+	TotalFIFO    float64             `json:"total_fifo"`    // valor pelas camadas FIFO
+	Categories   []CategoryValuation `json:"categories"`
+}
+
+// CategoryValuation é o valor do estoque de uma categoria
+type CategoryValuation struct {
+	Category     string             `json:"category"`
+	Quantity     float64            `json:"quantity"`
+	ValueAverage float64            `json:"value_average"`
+	ValueFIFO    float64            `json:"value_fifo"`
+	Products     []ProductValuation `json:"products"`
+}
+
+// ProductValuation é o valor do estoque de um produto
+type ProductValuation struct {
+	ProductID    int     `json:"product_id"`
+	Product      string  `json:"product"`
+	Category     string  `json:"-"`
+	Quantity     float64 `json:"quantity"`
+	AverageCost  float64 `json:"average_cost"`
+	ValueAverage float64 `json:"value_average"`
+	ValueFIFO    float64 `json:"value_fifo"`
+}
+
+// Kardex é a ficha de estoque de um produto num período: saldo inicial, cada movimento com
+// o saldo corrente e saldo final
+type Kardex struct {
+	ProductID   int           `json:"product_id"`
+	WarehouseID int           `json:"warehouse_id,omitempty"` // 0 = todos os depósitos
+	From        string        `json:"from,omitempty"`         // início do período (vazio = desde o primeiro movimento)
+	To          string        `json:"to"`                     // fim do período
+	Opening     float64       `json:"opening"`                // saldo antes do período
+	Closing     float64       `json:"closing"`                // saldo no fim do período
+	Total       int           `json:"total"`                  // movimentos no período (antes da paginação)
+	Entries     []KardexEntry `json:"entries"`
+}
+
+// KardexEntry é uma linha do Kardex
+type KardexEntry struct {
+	MovementID  int      `json:"movement_id"`
+	CreatedAt   string   `json:"created_at"`
+	Type        string   `json:"type"`
+	WarehouseID int      `json:"warehouse_id"`
+	Quantity    float64  `json:"quantity"` // quantidade informada no movimento
+	Change      float64  `json:"change"`   // variação efetiva do saldo (+ entrada / - saída)
+	Balance     float64  `json:"balance"`  // saldo depois do movimento
+	UnitCost    *float64 `json:"unit_cost,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+	Document    string   `json:"document,omitempty"`
+}
+
+// Snapshot é a posição do estoque de todos os produtos num instante, reconstruída do histórico
+type Snapshot struct {
+	At          string         `json:"at"`
+	WarehouseID int            `json:"warehouse_id,omitempty"` // 0 = todos os depósitos
+	Items       []SnapshotItem `json:"items"`
+}
+
+// SnapshotItem é a quantidade de um produto no instante do snapshot
+type SnapshotItem struct {
+	ProductID int     `json:"product_id"`
+	Product   string  `json:"product"`
+	Category  string  `json:"category"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+}
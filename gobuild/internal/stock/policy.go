@@ -0,0 +1,106 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"errors"       // para erros específicos
+	"fmt"          // para formatação de strings e erros
+	"strconv"      // id do produto na chave da política de estoque negativo
+	"strings"      // normaliza a política e a categoria
+)
+
+// checkNegative aplica a política de estoque negativo a um movimento que deixaria o saldo do
+// depósito abaixo de zero: BLOQUEAR devolve *InsufficientStockError, AVISAR preenche m.Warning
+func (s *Service) checkNegative(ctx context.Context, tx *sql.Tx, m *Movement, balance, newBalance float64) error {
+	policy, err := s.repo.NegativePolicyTx(ctx, tx, m.ProductID)
+	if err != nil {
+		return err
+	}
+	if policy == "" {
+		policy = DefaultNegativePolicy
+	}
+	switch policy {
+	case PoliticaPermitir:
+		return nil
+	case PoliticaAvisar:
+		m.Warning = fmt.Sprintf("saldo do produto %d no depósito %d ficou negativo (%v)", m.ProductID, m.WarehouseID, newBalance)
+		return nil
+	}
+	return &InsufficientStockError{
+		ProductID:   m.ProductID,
+		WarehouseID: m.WarehouseID,
+		Requested:   balance - newBalance,
+		Available:   max(balance, 0),
+	}
+}
+
+// NegativePolicies retorna as regras de estoque negativo (global, categorias e produtos)
+func (s *Service) NegativePolicies(ctx context.Context) ([]NegativePolicy, error) {
+	list, err := s.repo.ListNegativePolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []NegativePolicy{}
+	}
+	return list, nil
+}
+
+// SetNegativePolicy grava uma regra. O escopo vem do que foi informado: product_id = regra do
+// produto, category = regra da categoria, nenhum dos dois = regra global.
+func (s *Service) SetNegativePolicy(ctx context.Context, p NegativePolicy) (*NegativePolicy, error) {
+	p.Policy = strings.ToUpper(strings.TrimSpace(p.Policy))
+	if p.Policy != PoliticaBloquear && p.Policy != PoliticaPermitir && p.Policy != PoliticaAvisar {
+		return nil, errors.New("política inválida: use bloquear, permitir ou avisar")
+	}
+	scope, ref, err := s.policyScope(ctx, p.ProductID, p.Category)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetNegativePolicy(ctx, scope, ref, p.Policy); err != nil {
+		return nil, err
+	}
+	p.Scope, p.Category = scope, strings.TrimSpace(p.Category)
+	return &p, nil
+}
+
+// DeleteNegativePolicy remove a regra de um produto ou categoria (passa a valer a do nível acima).
+// A regra global não é removida, só alterada.
+func (s *Service) DeleteNegativePolicy(ctx context.Context, productID int, category string) error {
+	scope, ref, err := s.policyScope(ctx, productID, category)
+	if err != nil {
+		return err
+	}
+	if scope == EscopoGlobal {
+		return errors.New("a política global não pode ser removida; altere-a")
+	}
+	ok, err := s.repo.DeleteNegativePolicy(ctx, scope, ref)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("política não encontrada")
+	}
+	return nil
+}
+
+// policyScope descobre o escopo (e a chave gravada) de uma regra de estoque negativo
+func (s *Service) policyScope(ctx context.Context, productID int, category string) (string, string, error) {
+	category = strings.TrimSpace(category)
+	switch {
+	case productID != 0 && category != "":
+		return "", "", errors.New("informe product_id ou category, não os dois")
+	case productID != 0:
+		exists, err := s.repo.ProductExists(ctx, productID)
+		if err != nil {
+			return "", "", err
+		}
+		if !exists {
+			return "", "", fmt.Errorf("produto com ID %d não encontrado", productID)
+		}
+		return EscopoProduto, strconv.Itoa(productID), nil
+	case category != "":
+		return EscopoCategoria, category, nil
+	}
+	return EscopoGlobal, "", nil
+}
@@ -0,0 +1,85 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+	"strconv"      // id do produto guardado como texto nas políticas
+)
+
+// NegativePolicyTx retorna a política que vale para o produto: a regra do produto, senão a da
+// categoria dele, senão a global ("" se não houver regra nenhuma)
+func (r *Repository) NegativePolicyTx(ctx context.Context, tx *sql.Tx, productID int) (string, error) {
+	var policy string
+	err := tx.QueryRowContext(ctx,
+		`SELECT policy FROM stock_negative_policies
+		WHERE (scope = 'PRODUTO' AND ref = CAST(? AS TEXT))
+		   OR (scope = 'CATEGORIA' AND ref = (SELECT COALESCE(category, '') FROM products WHERE id = ?))
+		   OR scope = 'GLOBAL'
+		ORDER BY CASE scope WHEN 'PRODUTO' THEN 0 WHEN 'CATEGORIA' THEN 1 ELSE 2 END
+		LIMIT 1`, productID, productID).Scan(&policy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("erro ao buscar política de estoque negativo: %v", err)
+	}
+	return policy, nil
+}
+
+// ListNegativePolicies retorna todas as regras (global, categorias e produtos)
+func (r *Repository) ListNegativePolicies(ctx context.Context) ([]NegativePolicy, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT scope, ref, policy, updated_at FROM stock_negative_policies
+		ORDER BY CASE scope WHEN 'GLOBAL' THEN 0 WHEN 'CATEGORIA' THEN 1 ELSE 2 END, ref`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar políticas de estoque negativo: %v", err)
+	}
+	defer rows.Close()
+
+	var list []NegativePolicy
+	for rows.Next() {
+		var p NegativePolicy
+		var ref string
+		if err := rows.Scan(&p.Scope, &ref, &p.Policy, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear política de estoque negativo: %v", err)
+		}
+		switch p.Scope {
+		case EscopoProduto:
+			p.ProductID, _ = strconv.Atoi(ref)
+		case EscopoCategoria:
+			p.Category = ref
+		}
+		list = append(list, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das políticas: %v", err)
+	}
+	return list, nil
+}
+
+// SetNegativePolicy grava (ou substitui) a regra do escopo
+func (r *Repository) SetNegativePolicy(ctx context.Context, scope, ref, policy string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`INSERT INTO stock_negative_policies (scope, ref, policy) VALUES (?, ?, ?)
+		ON CONFLICT (scope, ref) DO UPDATE SET policy = excluded.policy, updated_at = CURRENT_TIMESTAMP`,
+		scope, ref, policy)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar política de estoque negativo: %v", err)
+	}
+	return nil
+}
+
+// DeleteNegativePolicy remove a regra do escopo; retorna false se ela não existia
+func (r *Repository) DeleteNegativePolicy(ctx context.Context, scope, ref string) (bool, error) {
+	result, err := r.DB.ExecContext(ctx,
+		`DELETE FROM stock_negative_policies WHERE scope = ? AND ref = ?`, scope, ref)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover política de estoque negativo: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover política de estoque negativo: %v", err)
+	}
+	return n > 0, nil
+}
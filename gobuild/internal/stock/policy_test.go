@@ -0,0 +1,64 @@
+package stock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+)
+
+// TestPoliticaNegativoPrecedencia: a regra do produto vale sobre a da categoria, que vale
+// sobre a global; removida a regra mais específica, passa a valer a do nível acima
+func TestPoliticaNegativoPrecedencia(t *testing.T) {
+	ctx := context.Background()
+	svc, _, productID := reservaSetup(t, 0) // categoria "Materiais", saldo zero
+
+	definir := func(p stock.NegativePolicy) {
+		t.Helper()
+		if _, err := svc.SetNegativePolicy(ctx, p); err != nil {
+			t.Fatalf("erro ao gravar política %+v: %v", p, err)
+		}
+	}
+	remover := func(productID int, category string) {
+		t.Helper()
+		if err := svc.DeleteNegativePolicy(ctx, productID, category); err != nil {
+			t.Fatalf("erro ao remover política: %v", err)
+		}
+	}
+	tests := []struct {
+		name  string
+		setup func()
+		want  string // política que deve valer na saída sem saldo
+	}{
+		{"só global bloquear", func() { definir(stock.NegativePolicy{Policy: stock.PoliticaBloquear}) }, stock.PoliticaBloquear},
+		{"categoria permitir sobre global", func() { definir(stock.NegativePolicy{Category: "Materiais", Policy: stock.PoliticaPermitir}) }, stock.PoliticaPermitir},
+		{"produto avisar sobre categoria", func() { definir(stock.NegativePolicy{ProductID: productID, Policy: stock.PoliticaAvisar}) }, stock.PoliticaAvisar},
+		{"produto bloquear sobre categoria", func() { definir(stock.NegativePolicy{ProductID: productID, Policy: stock.PoliticaBloquear}) }, stock.PoliticaBloquear},
+		{"sem regra do produto vale a categoria", func() { remover(productID, "") }, stock.PoliticaPermitir},
+		{"sem regra da categoria vale a global", func() { remover(0, "Materiais") }, stock.PoliticaBloquear},
+		{"regra de outra categoria não vale", func() { definir(stock.NegativePolicy{Category: "Ferragens", Policy: stock.PoliticaPermitir}) }, stock.PoliticaBloquear},
+		{"global alterada para avisar", func() { definir(stock.NegativePolicy{Policy: stock.PoliticaAvisar}) }, stock.PoliticaAvisar},
+	}
+	for _, tt := range tests {
+		tt.setup()
+		m := &stock.Movement{ProductID: productID, Type: "Saida", Quantity: 1}
+		_, err := svc.CreateMovement(ctx, m)
+		var got string
+		switch {
+		case err == nil && m.Warning != "":
+			got = stock.PoliticaAvisar
+		case err == nil:
+			got = stock.PoliticaPermitir
+		default:
+			var ise *stock.InsufficientStockError
+			if !errors.As(err, &ise) {
+				t.Fatalf("%s: erro inesperado: %v", tt.name, err)
+			}
+			got = stock.PoliticaBloquear
+		}
+		if got != tt.want {
+			t.Errorf("%s: vale %s, esperado %s", tt.name, got, tt.want)
+		}
+	}
+}
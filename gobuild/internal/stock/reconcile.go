@@ -0,0 +1,137 @@
+package stock
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"fmt"     // para formatação de strings e erros
+	"math"    // tolerância ao comparar ledger e saldo
+	"sort"    // ordena as divergências por depósito
+)
+
+// reconcileEpsilon é a tolerância para comparar quantidades (somas de float)
+const reconcileEpsilon = 1e-6
+
+// Reconcile reproduz o histórico de movimentos de cada produto e compara com o que está
+// gravado: o saldo de cada depósito (stock_balances) e o total (products.stock).
+//
+// Com fix = true, o saldo gravado é tomado como verdade (é o que o usuário vê e já
+// corrigiu à mão): para cada depósito divergente é postado um Ajuste corretivo que leva
+// o ledger até o saldo (previous_quantity = ledger, delta = diferença), sem mexer no saldo;
+// e o total do produto volta a ser a soma dos depósitos. Tudo numa transação.
+func (s *Service) Reconcile(ctx context.Context, fix bool) (*Reconciliation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // só leitura quando fix = false
+
+	products, err := s.repo.ListProductStockTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.repo.ListBalancesTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	moves, err := s.repo.ListLedgerTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	ledger := replayLedger(moves)
+
+	result := &Reconciliation{Products: len(products), Fixed: fix, Drifts: []Drift{}}
+	for _, d := range products {
+		// depósitos que aparecem no saldo gravado ou no ledger
+		warehouses := map[int]bool{}
+		for w, qty := range balances[d.ProductID] {
+			d.Balances += qty
+			warehouses[w] = true
+		}
+		for w, qty := range ledger[d.ProductID] {
+			d.Ledger += qty
+			warehouses[w] = true
+		}
+		for w := range warehouses {
+			balance, expected := balances[d.ProductID][w], ledger[d.ProductID][w]
+			if diff := balance - expected; math.Abs(diff) > reconcileEpsilon {
+				d.Warehouses = append(d.Warehouses, WarehouseDrift{
+					WarehouseID: w, Balance: balance, Ledger: expected, Difference: diff,
+				})
+			}
+		}
+		totalDrift := math.Abs(d.Stock-d.Balances) > reconcileEpsilon
+		if len(d.Warehouses) == 0 && !totalDrift {
+			continue
+		}
+		sortWarehouseDrifts(d.Warehouses)
+
+		if fix {
+			for i := range d.Warehouses {
+				wd := &d.Warehouses[i]
+				previous, delta := wd.Ledger, wd.Difference
+				id, err := s.repo.Insert(ctx, tx, &Movement{
+					ProductID:        d.ProductID,
+					WarehouseID:      wd.WarehouseID,
+					Type:             "Ajuste",
+					Quantity:         wd.Balance,
+					PreviousQuantity: &previous,
+					Delta:            &delta,
+					Notes:            "ajuste corretivo da reconciliação",
+				})
+				if err != nil {
+					return nil, err
+				}
+				wd.MovementID = &id
+			}
+			if totalDrift {
+				if err := s.updateStock(ctx, tx, d.ProductID, d.Balances); err != nil {
+					return nil, fmt.Errorf("erro ao corrigir estoque do produto %d: %v", d.ProductID, err)
+				}
+			}
+		}
+		result.Drifts = append(result.Drifts, d)
+	}
+	result.Mismatches = len(result.Drifts)
+
+	if fix {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// replayLedger reproduz os saldos (produto -> depósito -> quantidade) a partir dos movimentos,
+// em ordem (regra em applyLedger)
+func replayLedger(moves []Movement) map[int]map[int]float64 {
+	ledger := map[int]map[int]float64{}
+	for _, m := range moves {
+		if ledger[m.ProductID] == nil {
+			ledger[m.ProductID] = map[int]float64{}
+		}
+		applyLedger(ledger[m.ProductID], m)
+	}
+	return ledger
+}
+
+// applyLedger aplica um movimento aos saldos por depósito de um produto e retorna a variação.
+// Movimentos com delta gravado somam o delta; os antigos (sem delta) seguem o tipo:
+// Entrada soma, Saida subtrai e Ajuste define o saldo do depósito.
+func applyLedger(balances map[int]float64, m Movement) float64 {
+	before := balances[m.WarehouseID]
+	switch {
+	case m.Delta != nil:
+		balances[m.WarehouseID] += *m.Delta
+	case m.Type == "Entrada":
+		balances[m.WarehouseID] += m.Quantity
+	case m.Type == "Saida":
+		balances[m.WarehouseID] -= m.Quantity
+	case m.Type == "Ajuste":
+		balances[m.WarehouseID] = m.Quantity
+	}
+	return balances[m.WarehouseID] - before
+}
+
+// sortWarehouseDrifts ordena as divergências por depósito (o map não garante ordem)
+func sortWarehouseDrifts(list []WarehouseDrift) {
+	sort.Slice(list, func(i, j int) bool { return list[i].WarehouseID < list[j].WarehouseID })
+}
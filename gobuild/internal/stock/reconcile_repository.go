@@ -0,0 +1,83 @@
+package stock
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// ListProductStockTx retorna id, nome e estoque total (products.stock) de todos os produtos
+func (r *Repository) ListProductStockTx(ctx context.Context, tx *sql.Tx) ([]Drift, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, stock FROM products ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar produtos: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Drift
+	for rows.Next() {
+		var d Drift
+		if err := rows.Scan(&d.ProductID, &d.Product, &d.Stock); err != nil {
+			return nil, fmt.Errorf("erro ao escanear produto: %v", err)
+		}
+		list = append(list, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos produtos: %v", err)
+	}
+	return list, nil
+}
+
+// ListBalancesTx retorna todos os saldos gravados: produto -> depósito -> quantidade
+func (r *Repository) ListBalancesTx(ctx context.Context, tx *sql.Tx) (map[int]map[int]float64, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT product_id, warehouse_id, quantity FROM stock_balances`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar saldos: %v", err)
+	}
+	defer rows.Close()
+
+	balances := map[int]map[int]float64{}
+	for rows.Next() {
+		var productID, warehouseID int
+		var qty float64
+		if err := rows.Scan(&productID, &warehouseID, &qty); err != nil {
+			return nil, fmt.Errorf("erro ao escanear saldo: %v", err)
+		}
+		if balances[productID] == nil {
+			balances[productID] = map[int]float64{}
+		}
+		balances[productID][warehouseID] = qty
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos saldos: %v", err)
+	}
+	return balances, nil
+}
+
+// ListLedgerTx retorna todos os movimentos na ordem em que aconteceram (só o necessário
+// para reproduzir os saldos)
+func (r *Repository) ListLedgerTx(ctx context.Context, tx *sql.Tx) ([]Movement, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, product_id, warehouse_id, tipo, quantidade, delta FROM stock_movements ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar movimentações de estoque: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Movement
+	for rows.Next() {
+		var m Movement
+		var delta sql.NullFloat64 // NULL em movimentos anteriores à migração 0007
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.WarehouseID, &m.Type, &m.Quantity, &delta); err != nil {
+			return nil, fmt.Errorf("erro ao escanear movimentação de estoque: %v", err)
+		}
+		if delta.Valid {
+			m.Delta = &delta.Float64
+		}
+		list = append(list, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das movimentações: %v", err)
+	}
+	return list, nil
+}
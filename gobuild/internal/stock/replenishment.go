@@ -0,0 +1,148 @@
+package stock
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"errors"  // para erros específicos
+	"fmt"     // para formatação de strings e erros
+	"math"    // estatísticas do consumo e arredondamento
+	"sort"    // ordena os itens do relatório
+	"time"    // janela de consumo e dias sem venda
+)
+
+// padrões do relatório de reposição
+const (
+	defaultReplenishmentWindow   = 90
+	maxReplenishmentWindow       = 365
+	defaultReplenishmentLeadTime = 7
+	defaultServiceLevel          = 0.95
+	defaultCoverageDays          = 30
+	defaultSlowDays              = 60
+)
+
+// Replenishment monta o relatório de reposição a partir das Saidas dos últimos WindowDays dias
+// (o dia de hoje conta como o último). Ver ReplenishmentItem para as fórmulas.
+func (s *Service) Replenishment(ctx context.Context, p ReplenishmentParams) (*Replenishment, error) {
+	if p.WindowDays == 0 {
+		p.WindowDays = defaultReplenishmentWindow
+	}
+	if p.LeadTimeDays == 0 {
+		p.LeadTimeDays = defaultReplenishmentLeadTime
+	}
+	if p.ServiceLevel == 0 {
+		p.ServiceLevel = defaultServiceLevel
+	}
+	if p.CoverageDays == 0 {
+		p.CoverageDays = defaultCoverageDays
+	}
+	if p.SlowDays == 0 {
+		p.SlowDays = defaultSlowDays
+	}
+	if p.WindowDays < 1 || p.WindowDays > maxReplenishmentWindow {
+		return nil, fmt.Errorf("a janela deve ter entre 1 e %d dias", maxReplenishmentWindow)
+	}
+	if p.LeadTimeDays < 0 || p.CoverageDays < 0 || p.SlowDays < 0 {
+		return nil, errors.New("prazo, cobertura e dias sem venda não podem ser negativos")
+	}
+	if p.ServiceLevel < 0.5 || p.ServiceLevel > 0.999 {
+		return nil, errors.New("o nível de serviço deve estar entre 50% e 99,9%")
+	}
+	// fator z da normal para o nível de serviço (95% -> 1,645)
+	z := math.Sqrt2 * math.Erfinv(2*p.ServiceLevel-1)
+
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(p.WindowDays - 1))
+	report := &Replenishment{
+		From:         start.Format(time.DateTime),
+		WindowDays:   p.WindowDays,
+		LeadTimeDays: p.LeadTimeDays,
+		ServiceLevel: p.ServiceLevel,
+		CoverageDays: p.CoverageDays,
+		SlowDays:     p.SlowDays,
+		Items:        []ReplenishmentItem{},
+	}
+
+	daily, err := s.repo.ListDailyConsumption(ctx, report.From)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := map[int]map[string]float64{}
+	for _, d := range daily {
+		if byProduct[d.ProductID] == nil {
+			byProduct[d.ProductID] = map[string]float64{}
+		}
+		byProduct[d.ProductID][d.Day] += d.Quantity
+	}
+
+	items, err := s.repo.ListReplenishmentProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range items {
+		// a série tem um valor por dia da janela, inclusive os dias sem saída
+		days := byProduct[it.ProductID]
+		var sum, sumSq float64
+		for _, q := range days {
+			sum += q
+			sumSq += q * q
+		}
+		n := float64(p.WindowDays)
+		mean := sum / n
+		variance := max(sumSq/n-mean*mean, 0)
+
+		if it.LeadTimeDays <= 0 {
+			it.LeadTimeDays = p.LeadTimeDays
+		}
+		lead := float64(it.LeadTimeDays)
+		it.Available = it.Stock - it.Reserved
+		it.TotalOut = round2(sum)
+		it.SaleDays = len(days)
+		it.AvgDaily = round2(mean)
+		it.StdDaily = round2(math.Sqrt(variance))
+		it.SafetyStock = round2(z * math.Sqrt(variance) * math.Sqrt(lead))
+		it.ReorderPoint = round2(mean*lead + z*math.Sqrt(variance)*math.Sqrt(lead))
+		if mean > 0 {
+			d := round2(max(it.Available, 0) / mean)
+			it.DaysOfStock = &d
+			// o que já está pedido ao fornecedor conta como estoque a caminho
+			if position := it.Available + it.OnOrder; position <= it.ReorderPoint {
+				it.Suggested = round2(it.ReorderPoint + mean*float64(p.CoverageDays) - position)
+			}
+		}
+
+		if it.LastSale != "" {
+			if t, ok := parseStoredTime(it.LastSale); ok {
+				it.LastSale = t.Format(time.DateTime)
+				d := int(today.Sub(t.Truncate(24*time.Hour)).Hours() / 24)
+				it.DaysWithoutSale = &d
+			}
+		}
+		it.SlowMover = it.Stock > 0 && (it.DaysWithoutSale == nil || *it.DaysWithoutSale >= p.SlowDays)
+		report.Items = append(report.Items, it)
+	}
+
+	// primeiro o que precisa comprar (maior sugestão), depois os parados
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		a, b := report.Items[i], report.Items[j]
+		if a.Suggested != b.Suggested {
+			return a.Suggested > b.Suggested
+		}
+		return a.SlowMover && !b.SlowMover
+	})
+	return report, nil
+}
+
+// parseStoredTime lê um created_at devolvido pelo SQLite (texto "AAAA-MM-DD HH:MM:SS" ou RFC3339)
+func parseStoredTime(v string) (time.Time, bool) {
+	for _, layout := range []string{time.DateTime, time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// round2 arredonda para duas casas (quantidades e médias do relatório)
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
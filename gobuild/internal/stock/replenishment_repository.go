@@ -0,0 +1,86 @@
+package stock
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"fmt"     // para formatação de strings e erros
+)
+
+// consumptionFilter seleciona as Saidas que contam como consumo (alias m): fora transferências
+// entre depósitos, devoluções ao fornecedor, estornos e Saidas já estornadas
+const consumptionFilter = `m.tipo = 'Saida' AND m.reversal_of IS NULL
+	AND COALESCE(m.reason, '') NOT IN ('` + MotivoTransferencia + `', '` + MotivoDevolucao + `')
+	AND NOT EXISTS (SELECT 1 FROM stock_movements r WHERE r.reversal_of = m.id)`
+
+// dailyConsumption é o consumo de um produto em um dia (AAAA-MM-DD, UTC)
+type dailyConsumption struct {
+	ProductID int
+	Day       string
+	Quantity  float64
+}
+
+// ListDailyConsumption soma o consumo por produto e dia a partir de `from` (formato do SQLite)
+func (r *Repository) ListDailyConsumption(ctx context.Context, from string) ([]dailyConsumption, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT m.product_id, date(m.created_at), SUM(m.quantidade)
+		FROM stock_movements m
+		WHERE `+consumptionFilter+` AND m.created_at >= ?
+		GROUP BY m.product_id, date(m.created_at)`, from)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao somar consumo diário: %v", err)
+	}
+	defer rows.Close()
+
+	var list []dailyConsumption
+	for rows.Next() {
+		var d dailyConsumption
+		if err := rows.Scan(&d.ProductID, &d.Day, &d.Quantity); err != nil {
+			return nil, fmt.Errorf("erro ao escanear consumo diário: %v", err)
+		}
+		list = append(list, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração do consumo diário: %v", err)
+	}
+	return list, nil
+}
+
+// ListReplenishmentProducts retorna todos os produtos com estoque, reservado (reservas ativas),
+// em pedido (pedidos de compra abertos), prazo de entrega (do produto; sem ele, o menor prazo
+// dos fornecedores ativos do produto) e data do último consumo (vazia se nunca houve)
+func (r *Repository) ListReplenishmentProducts(ctx context.Context) ([]ReplenishmentItem, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT p.id, p.name, COALESCE(p.category, ''), COALESCE(p.unit, ''), p.stock,
+			CASE WHEN p.lead_time_days > 0 THEN p.lead_time_days ELSE
+				COALESCE((SELECT MIN(f.lead_time_days) FROM product_suppliers ps
+					JOIN suppliers f ON f.id = ps.supplier_id
+					WHERE ps.product_id = p.id AND f.active = 1 AND f.lead_time_days > 0), 0) END,
+			(SELECT COALESCE(SUM(s.quantity), 0) FROM stock_reservations s
+				WHERE s.product_id = p.id AND s.status = 'ATIVA'
+				  AND (s.expires_at IS NULL OR s.expires_at > CURRENT_TIMESTAMP)),
+			(SELECT COALESCE(SUM(i.quantity - i.received), 0) FROM purchase_order_items i
+				JOIN purchase_orders o ON o.id = i.order_id
+				WHERE i.product_id = p.id AND i.quantity > i.received
+				  AND o.status IN ('ABERTO', 'PARCIALMENTE_RECEBIDO')),
+			COALESCE((SELECT MAX(m.created_at) FROM stock_movements m
+				WHERE m.product_id = p.id AND `+consumptionFilter+`), '')
+		FROM products p
+		ORDER BY p.name, p.id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar produtos para reposição: %v", err)
+	}
+	defer rows.Close()
+
+	var list []ReplenishmentItem
+	for rows.Next() {
+		var it ReplenishmentItem
+		if err := rows.Scan(&it.ProductID, &it.Product, &it.Category, &it.Unit, &it.Stock, &it.LeadTimeDays,
+			&it.Reserved, &it.OnOrder, &it.LastSale); err != nil {
+			return nil, fmt.Errorf("erro ao escanear produto para reposição: %v", err)
+		}
+		list = append(list, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos produtos para reposição: %v", err)
+	}
+	return list, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"      // Para passar contexto em operações de banco de dados
 	"database/sql" // pacote sql para manipulação de rows/ results
 	"fmt"          // para formatação de strings e erros
+	"strings"      // monta a lista de placeholders do IN (...)
 )
 
 // Repository gerencia operações de banco de dados para movimentações de estoque (stock_movements)
@@ -17,12 +18,18 @@ func NewRepository(db *sql.DB) *Repository {
 		DB: db,
 	}
 }
+
 // Insert registra um movimento de estoque e retorna o ID inserido.
-// Usamos ExecContext para passar contexto e facilitar cancelmento/timeouts.
-func (r *Repository) Insert(ctx context.Context, m *Movement) (int64, error) {
-	result, err := r.DB.ExecContext(ctx,
-		`INSERT INTO stock_movements (product_id, tipo, quantidade) VALUES (?, ?, ?)`,	
-		m.ProductID, m.Type, m.Quantity,
+// Recebe a transação aberta pelo Service: o movimento só existe se o estoque
+// do produto também foi atualizado (mesmo commit).
+func (r *Repository) Insert(ctx context.Context, tx *sql.Tx, m *Movement) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_movements (product_id, warehouse_id, tipo, quantidade, previous_quantity, delta,
+			unit_cost, cost_average, cost_fifo, average_cost, reason, document, notes, reversal_of)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.ProductID, m.WarehouseID, m.Type, m.Quantity, m.PreviousQuantity, m.Delta,
+		m.UnitCost, m.CostAverage, m.CostFIFO, m.AverageCost,
+		nullString(m.Reason), nullString(m.Document), nullString(m.Notes), m.ReversalOf,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("erro ao inserir movimentação de estoque: %v", err)
@@ -35,13 +42,18 @@ func (r *Repository) Insert(ctx context.Context, m *Movement) (int64, error) {
 
 	return id, nil
 }
-// GetByProduct retorna historico de movimentos de um produto (ordenado desc por data)
-func (r *Repository) GetByProduct(ctx context.Context, productID int) ([]Movement, error) {
+
+// GetByProduct retorna historico de movimentos de um produto (ordenado desc por data),
+// filtrando por motivo e documento quando informados
+func (r *Repository) GetByProduct(ctx context.Context, productID int, reason, document string) ([]Movement, error) {
 	rows, err := r.DB.QueryContext(ctx,
-		`SELECT id, product_id, tipo, quantidade, created_at 
+		`SELECT id, product_id, warehouse_id, tipo, quantidade, previous_quantity, delta,
+			unit_cost, cost_average, cost_fifo, average_cost,
+			COALESCE(reason, ''), COALESCE(document, ''), COALESCE(notes, ''), reversal_of,
+			(SELECT MIN(r.id) FROM stock_movements r WHERE r.reversal_of = stock_movements.id), created_at
 		FROM stock_movements
-		WHERE product_id = ?
-		ORDER BY created_at DESC`, productID,
+		WHERE product_id = ? AND (? = '' OR reason = ?) AND (? = '' OR document = ?)
+		ORDER BY created_at DESC, id DESC`, productID, reason, reason, document, document,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar movimentações de estoque: %v", err)
@@ -51,14 +63,314 @@ func (r *Repository) GetByProduct(ctx context.Context, productID int) ([]Movemen
 	var list []Movement
 	for rows.Next() {
 		var m Movement
-		if err := rows.Scan(&m.ID, &m.ProductID, &m.Type, &m.Quantity, &m.CreatedAt); err != nil {
+		var previous, delta sql.NullFloat64 // NULL em movimentos antigos
+		var unitCost, costAverage, costFIFO, averageCost sql.NullFloat64
+		var reversalOf, reversedBy sql.NullInt64
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.WarehouseID, &m.Type, &m.Quantity, &previous, &delta,
+			&unitCost, &costAverage, &costFIFO, &averageCost, &m.Reason, &m.Document, &m.Notes,
+			&reversalOf, &reversedBy, &m.CreatedAt); err != nil {
 			return nil, fmt.Errorf("erro ao escanear movimentação de estoque: %v", err)
 		}
+		m.ReversalOf = nullInt(reversalOf)
+		m.ReversedBy = nullInt(reversedBy)
+		m.PreviousQuantity = nullFloat(previous)
+		m.Delta = nullFloat(delta)
+		m.UnitCost = nullFloat(unitCost)
+		m.CostAverage = nullFloat(costAverage)
+		m.CostFIFO = nullFloat(costFIFO)
+		m.AverageCost = nullFloat(averageCost)
 		list = append(list, m)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("erro durante iteração das movimentações: %v", err)
 	}
 
+	// lotes movimentados por cada movimento do produto
+	allocs, err := r.allocationsByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Lots = allocs[list[i].ID]
+	}
+
 	return list, nil
-}
\ No newline at end of file
+}
+
+// allocationsByProduct retorna, por ID de movimento, os lotes movimentados de um produto
+func (r *Repository) allocationsByProduct(ctx context.Context, productID int) (map[int][]LotAllocation, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT ml.movement_id, l.id, l.lot, COALESCE(l.expires_at, ''), ml.quantity
+		FROM stock_movement_lots ml
+		JOIN stock_lots l ON l.id = ml.lot_id
+		WHERE l.product_id = ?
+		ORDER BY ml.movement_id, l.expires_at IS NULL, l.expires_at, l.id`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lotes das movimentações: %v", err)
+	}
+	defer rows.Close()
+
+	byMovement := map[int][]LotAllocation{}
+	for rows.Next() {
+		var movementID int
+		var a LotAllocation
+		if err := rows.Scan(&movementID, &a.LotID, &a.Lot, &a.ExpiresAt, &a.Quantity); err != nil {
+			return nil, fmt.Errorf("erro ao escanear lote da movimentação: %v", err)
+		}
+		byMovement[movementID] = append(byMovement[movementID], a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos lotes: %v", err)
+	}
+	return byMovement, nil
+}
+
+// GetMovementTx busca um movimento pelo ID com os lotes movimentados (nil, nil se não existir)
+func (r *Repository) GetMovementTx(ctx context.Context, tx *sql.Tx, id int64) (*Movement, error) {
+	var m Movement
+	var previous, delta, unitCost sql.NullFloat64
+	var reversalOf sql.NullInt64
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, product_id, warehouse_id, tipo, quantidade, previous_quantity, delta, unit_cost,
+			COALESCE(reason, ''), COALESCE(document, ''), reversal_of, created_at
+		FROM stock_movements WHERE id = ?`, id,
+	).Scan(&m.ID, &m.ProductID, &m.WarehouseID, &m.Type, &m.Quantity, &previous, &delta, &unitCost,
+		&m.Reason, &m.Document, &reversalOf, &m.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // movimento não encontrado
+		}
+		return nil, fmt.Errorf("erro ao buscar movimentação de estoque: %v", err)
+	}
+	m.PreviousQuantity = nullFloat(previous)
+	m.Delta = nullFloat(delta)
+	m.UnitCost = nullFloat(unitCost)
+	m.ReversalOf = nullInt(reversalOf)
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT l.id, l.lot, COALESCE(l.expires_at, ''), ml.quantity
+		FROM stock_movement_lots ml
+		JOIN stock_lots l ON l.id = ml.lot_id
+		WHERE ml.movement_id = ?
+		ORDER BY l.expires_at IS NULL, l.expires_at, l.id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar lotes da movimentação: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a LotAllocation
+		if err := rows.Scan(&a.LotID, &a.Lot, &a.ExpiresAt, &a.Quantity); err != nil {
+			return nil, fmt.Errorf("erro ao escanear lote da movimentação: %v", err)
+		}
+		m.Lots = append(m.Lots, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos lotes: %v", err)
+	}
+	return &m, nil
+}
+
+// ReversedByTx retorna o ID do (primeiro) estorno do movimento (0 se não foi estornado)
+func (r *Repository) ReversedByTx(ctx context.Context, tx *sql.Tx, id int64) (int64, error) {
+	var reversedBy int64
+	err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MIN(id), 0) FROM stock_movements WHERE reversal_of = ?`, id).Scan(&reversedBy)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar estorno da movimentação: %v", err)
+	}
+	return reversedBy, nil
+}
+
+// GetBalanceTx lê o saldo de um produto em um depósito (0 se ainda não houver linha)
+func (r *Repository) GetBalanceTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int) (float64, error) {
+	var qty float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT quantity FROM stock_balances WHERE warehouse_id = ? AND product_id = ?`,
+		warehouseID, productID,
+	).Scan(&qty)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("erro ao ler saldo do depósito: %v", err)
+	}
+	return qty, nil
+}
+
+// SetBalanceTx grava o saldo de um produto em um depósito (insere ou atualiza)
+func (r *Repository) SetBalanceTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, qty float64) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_balances (warehouse_id, product_id, quantity) VALUES (?, ?, ?)
+		ON CONFLICT (warehouse_id, product_id) DO UPDATE SET quantity = excluded.quantity`,
+		warehouseID, productID, qty,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar saldo do depósito: %v", err)
+	}
+	return nil
+}
+
+// WarehouseActiveTx informa se o depósito existe e está ativo
+func (r *Repository) WarehouseActiveTx(ctx context.Context, tx *sql.Tx, warehouseID int) (bool, error) {
+	var active bool
+	err := tx.QueryRowContext(ctx, `SELECT active FROM warehouses WHERE id = ?`, warehouseID).Scan(&active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao ler depósito: %v", err)
+	}
+	return active, nil
+}
+
+// InsertReservation grava uma reserva ATIVA que expira em `ttlSeconds` segundos
+// (a data é calculada pelo SQLite, no mesmo formato de CURRENT_TIMESTAMP)
+func (r *Repository) InsertReservation(ctx context.Context, tx *sql.Tx, res *Reservation, ttlSeconds int64) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO stock_reservations (budget_id, product_id, warehouse_id, quantity, status, expires_at)
+		VALUES (?, ?, ?, ?, ?, datetime('now', ?))`,
+		res.BudgetID, res.ProductID, res.WarehouseID, res.Quantity, ReservaAtiva, fmt.Sprintf("+%d seconds", ttlSeconds),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir reserva de estoque: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// ReservedTx soma as reservas ATIVAS e não vencidas de um produto no depósito (mesma regra do
+// reservado de GET /api/products/:id/stock)
+func (r *Repository) ReservedTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int) (float64, error) {
+	var reserved float64
+	err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations
+		WHERE warehouse_id = ? AND product_id = ? AND status = ?
+		  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`,
+		warehouseID, productID, ReservaAtiva,
+	).Scan(&reserved)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao somar reservas do depósito: %v", err)
+	}
+	return reserved, nil
+}
+
+// ListReservationsByBudgetTx retorna as reservas de um orçamento nos status informados
+func (r *Repository) ListReservationsByBudgetTx(ctx context.Context, tx *sql.Tx, budgetID int64, status ...string) ([]Reservation, error) {
+	query := `SELECT id, budget_id, product_id, warehouse_id, quantity, status, expires_at, created_at
+		FROM stock_reservations
+		WHERE budget_id = ?`
+	args := []any{budgetID}
+	if len(status) > 0 {
+		query += ` AND status IN (?` + strings.Repeat(", ?", len(status)-1) + `)`
+		for _, st := range status {
+			args = append(args, st)
+		}
+	}
+	query += ` ORDER BY id`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar reservas do orçamento: %v", err)
+	}
+	defer rows.Close()
+	return scanReservations(rows)
+}
+
+// ListActiveReservationsByProduct retorna as reservas ATIVAS e não vencidas de um produto
+func (r *Repository) ListActiveReservationsByProduct(ctx context.Context, productID int) ([]Reservation, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, budget_id, product_id, warehouse_id, quantity, status, expires_at, created_at
+		FROM stock_reservations
+		WHERE product_id = ? AND status = ?
+		  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		ORDER BY id`,
+		productID, ReservaAtiva,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar reservas do produto: %v", err)
+	}
+	defer rows.Close()
+	return scanReservations(rows)
+}
+
+// UpdateReservationsStatusTx muda o status das reservas de um orçamento (de `from` para `to`)
+func (r *Repository) UpdateReservationsStatusTx(ctx context.Context, tx *sql.Tx, budgetID int64, from []string, to string) error {
+	if len(from) == 0 {
+		return nil
+	}
+	args := []any{to, budgetID}
+	for _, st := range from {
+		args = append(args, st)
+	}
+	_, err := tx.ExecContext(ctx,
+		`UPDATE stock_reservations SET status = ?
+		WHERE budget_id = ? AND status IN (?`+strings.Repeat(", ?", len(from)-1)+`)`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar reservas do orçamento: %v", err)
+	}
+	return nil
+}
+
+// ExpireReservations marca como EXPIRADA as reservas ATIVAS com expires_at no passado.
+// Retorna quantas reservas expiraram.
+func (r *Repository) ExpireReservations(ctx context.Context) (int64, error) {
+	result, err := r.DB.ExecContext(ctx,
+		`UPDATE stock_reservations SET status = ?
+		WHERE status = ? AND expires_at IS NOT NULL AND expires_at <= CURRENT_TIMESTAMP`,
+		ReservaExpirada, ReservaAtiva,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao expirar reservas: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanReservations lê as linhas de stock_reservations
+func scanReservations(rows *sql.Rows) ([]Reservation, error) {
+	var list []Reservation
+	for rows.Next() {
+		var res Reservation
+		var expiresAt sql.NullString // reservas sem validade ficam com NULL
+		if err := rows.Scan(&res.ID, &res.BudgetID, &res.ProductID, &res.WarehouseID, &res.Quantity, &res.Status, &expiresAt, &res.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear reserva: %v", err)
+		}
+		res.ExpiresAt = expiresAt.String
+		list = append(list, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração das reservas: %v", err)
+	}
+	return list, nil
+}
+
+// nullString grava texto vazio como NULL (campos opcionais do movimento)
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+// nullInt converte uma coluna INTEGER anulável em ponteiro (nil quando NULL)
+func nullInt(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+// nullFloat converte uma coluna REAL anulável em ponteiro (nil quando NULL)
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+// ProductExists informa se o produto existe
+func (r *Repository) ProductExists(ctx context.Context, productID int) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE id = ?)`, productID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar produto: %v", err)
+	}
+	return exists, nil
+}
@@ -5,36 +5,72 @@ import (
 	"database/sql" // pacote sql para manipulação de rows/ results
 	"errors"       // para erros específicos
 	"fmt"          // para formatação de strings e erros
+	"slices"       // confere o tipo de movimento aceito por cada motivo
+	"strings"      // normalização do motivo
+	"time"         // validade das reservas
 )
 
+// DefaultReservationTTL é a validade padrão de uma reserva de estoque
+const DefaultReservationTTL = 7 * 24 * time.Hour
+
+// Erros do estorno (o handler mapeia para 409 Conflict)
+var (
+	// ErrJaEstornado indica que o movimento já tem um estorno
+	ErrJaEstornado = errors.New("movimento já foi estornado")
+	// ErrNaoEstornavel indica movimento que não pode ser estornado (o motivo vai junto na mensagem)
+	ErrNaoEstornavel = errors.New("movimento não pode ser estornado")
+)
+
+// InsufficientStockError é o erro de saldo insuficiente quando a política é BLOQUEAR
+// (os handlers de estoque e de orçamento respondem 409 com a quantidade disponível)
+type InsufficientStockError struct {
+	ProductID   int
+	WarehouseID int
+	Requested   float64 // quanto o movimento tiraria do depósito
+	Available   float64 // saldo do depósito antes do movimento (0 se já estava negativo)
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("estoque insuficiente do produto %d no depósito %d: disponível %v, solicitado %v",
+		e.ProductID, e.WarehouseID, e.Available, e.Requested)
+}
+
+// AvailableQuantity devolve o disponível (permite que outros módulos leiam o erro sem importar o estoque)
+func (e *InsufficientStockError) AvailableQuantity() float64 {
+	return e.Available
+}
+
 // Service coordena regras de negócio para movimentações de estoque
 // - verifica se o produto existe (pode usar repositório de produtos)
 // - realiza a operação em transação (atualiza product.stock e insere movement)
-// - previne estoque negativo (configuravel)
+// - previne estoque negativo (política por produto, categoria ou global)
 type Service struct {
-	db *sql.DB			   // Conexão com o banco (injetada na criação do serviço)
-	repo *Repository	   // Repositório de movimentações de estoque
-	getProduct func(ctx context.Context, id int) (float64, error) // função para obter estoque do produto
-	updateStock func(ctx context.Context, id int, newStock float64) error // função para atualizar estoque do produto
-	//	allowNegative bool	   // se falso, previne estoque negativo (configurável é só deixar true por enquanto)
+	db            *sql.DB                                                               // Conexão com o banco (injetada na criação do serviço)
+	repo          *Repository                                                           // Repositório de movimentações de estoque
+	getProduct    func(ctx context.Context, tx *sql.Tx, id int) (float64, error)        // função para obter estoque do produto (dentro da transação)
+	updateStock   func(ctx context.Context, tx *sql.Tx, id int, newStock float64) error // função para atualizar estoque do produto (dentro da transação)
+	alertNotifier func(AlertEvent)                                                      // avisado depois do commit quando uma Saida chega ao ponto de pedido
 }
+
 // ProductLite é uma visão reduzida do produto usada pelo serviço de estoque
 // não precisamos de todos os campos, só do estoque atual
 type ProductLite struct {
-	ID int
+	ID    int
 	Stock float64
 }
 
 // NewService cria uma o serviço de estoque
-// passamos também funções ulitárias para ler/atualizar o estoque do produto (injeção para simplicidade)
+// passamos também funções ulitárias para ler/atualizar o estoque do produto (injeção para simplicidade).
+// As duas funções recebem a transação aberta pelo serviço, assim leitura, cálculo e gravação
+// acontecem no mesmo commit.
 func NewService(db *sql.DB, repo *Repository,
-	getProduct func(ctx context.Context, id int) (float64, error),
-	updateStock func(ctx context.Context, id int, newStock float64) error,
-	) *Service {
+	getProduct func(ctx context.Context, tx *sql.Tx, id int) (float64, error),
+	updateStock func(ctx context.Context, tx *sql.Tx, id int, newStock float64) error,
+) *Service {
 	return &Service{
-		db: db,
-		repo: repo,
-		getProduct: getProduct,
+		db:          db,
+		repo:        repo,
+		getProduct:  getProduct,
 		updateStock: updateStock,
 	}
 }
@@ -44,98 +80,673 @@ func validType(t string) bool {
 	return t == "Entrada" || t == "Saida" || t == "Ajuste"
 }
 
-//CreateMovement executa o fluxo completo de um movimento:
+// CreateMovement executa o fluxo completo de um movimento:
 // 1. valida o tipo e quantidade
 // 2. inicia transação
-// 3. obtém estoque atual do produto
-// 4. insere registro em stock_movements
+// 3. obtém estoque atual do produto (dentro da transação)
+// 4. atualiza products.stock e insere registro em stock_movements
 // 5. commita a transação (ou rollback em caso de erro)
+//
+// A conexão é aberta com _txlock=immediate (ver database.Connect), então o BEGIN já
+// reserva a escrita no SQLite: duas saídas simultâneas nunca leem o mesmo estoque.
 func (s *Service) CreateMovement(ctx context.Context, m *Movement) (int64, error) {
 	//validações básicas
+	if err := validateMovement(m); err != nil {
+		return 0, err
+	}
+
+	// agora fazemos a operação dentro de uma transação para garantir atomicidade
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+
+	id, err := s.apply(ctx, tx, m)
+	if err != nil {
+		_ = tx.Rollback() // tenta rollback em caso de erro
+		return 0, err
+	}
+
+	// commit da transação
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	s.notifyAlerts(m)
+
+	return id, nil
+}
+
+// CreateMovementTx faz o mesmo que CreateMovement, mas dentro de uma transação aberta por outro
+// módulo (ex.: budget muda status e estoque no mesmo commit). Commit/rollback ficam com quem chamou.
+func (s *Service) CreateMovementTx(ctx context.Context, tx *sql.Tx, m *Movement) (int64, error) {
+	if err := validateMovement(m); err != nil {
+		return 0, err
+	}
+	return s.apply(ctx, tx, m)
+}
+
+// validateMovement faz as validações básicas de tipo e quantidade
+func validateMovement(m *Movement) error {
 	if !validType(m.Type) {
-		return 0, errors.New("tipo de movimentação inválido")
+		return errors.New("tipo de movimentação inválido")
+	}
+	// ajuste pode zerar o saldo; entrada e saída precisam de quantidade positiva
+	if m.Type == "Ajuste" && m.Quantity < 0 {
+		return errors.New("quantidade do ajuste não pode ser negativa")
+	}
+	if m.Type != "Ajuste" && m.Quantity <= 0 {
+		return errors.New("quantidade deve ser maior que zero")
+	}
+	if m.UnitCost != nil {
+		if m.Type == "Saida" {
+			return errors.New("custo unitário só pode ser informado na entrada ou ajuste")
+		}
+		if *m.UnitCost < 0 {
+			return errors.New("custo unitário não pode ser negativo")
+		}
+	}
+	if m.ExpiresAt != "" {
+		if m.Lot == "" || m.Type == "Saida" {
+			return errors.New("validade só pode ser informada junto com o lote, na entrada ou ajuste")
+		}
+		if _, err := time.Parse(time.DateOnly, m.ExpiresAt); err != nil {
+			return errors.New("validade inválida: use o formato AAAA-MM-DD")
+		}
+	}
+	return validateReason(m)
+}
+
+// validateReason normaliza o motivo (ex.: "devolução" -> DEVOLUCAO), o documento e a observação,
+// e confere se o motivo existe e combina com o tipo do movimento
+func validateReason(m *Movement) error {
+	m.Document = strings.TrimSpace(m.Document)
+	m.Notes = strings.TrimSpace(m.Notes)
+	if m.Reason == "" {
+		return nil
+	}
+	reason, err := ParseReason(m.Reason)
+	if err != nil {
+		return err
+	}
+	if reason == MotivoTransferencia && !m.transfer {
+		return errors.New("motivo TRANSFERENCIA é exclusivo das transferências entre depósitos (use /stock/transferencia)")
+	}
+	if !slices.Contains(reasonTypes[reason], m.Type) {
+		return fmt.Errorf("motivo %s não se aplica a movimento do tipo %s", reason, m.Type)
+	}
+	m.Reason = reason
+	return nil
+}
+
+// reasonReplacer tira os acentos aceitos na digitação do motivo
+var reasonReplacer = strings.NewReplacer("Ç", "C", "Ã", "A", "Á", "A", "Â", "A", "Ê", "E", "É", "E", "Í", "I", "Ó", "O", "Õ", "O", "Ú", "U")
+
+// ParseReason converte o motivo digitado ("devolução", "Quebra"...) para o código da lista controlada
+func ParseReason(v string) (string, error) {
+	reason := reasonReplacer.Replace(strings.ToUpper(strings.TrimSpace(v)))
+	if _, ok := reasonTypes[reason]; !ok {
+		return "", fmt.Errorf("motivo inválido (%s): use compra, venda, devolução, quebra, perda, transferência ou inventário", v)
+	}
+	return reason, nil
+}
+
+// apply faz o read-modify-write do estoque e grava o movimento usando a transação recebida.
+// Atualiza o saldo do depósito (stock_balances) e o total do produto (products.stock) juntos.
+// Não faz commit nem rollback: quem abriu a transação decide.
+func (s *Service) apply(ctx context.Context, tx *sql.Tx, m *Movement) (int64, error) {
+	// 0) depósito: sem depósito informado usa o padrão; precisa existir e estar ativo
+	if m.WarehouseID == 0 {
+		m.WarehouseID = DefaultWarehouseID
+	}
+	active, err := s.repo.WarehouseActiveTx(ctx, tx, m.WarehouseID)
+	if err != nil {
+		return 0, err
 	}
-	if m.Quantity <= 0 {
-		return 0, errors.New("quantidade deve ser maior que zero")
+	if !active {
+		return 0, fmt.Errorf("depósito %d não encontrado ou inativo", m.WarehouseID)
 	}
 
-	// lê produto atual (via função injetada)
-	currentStock, err := s.getProduct(ctx, m.ProductID)	
+	// 1) lê produto atual (via função injetada, dentro da transação) e o saldo no depósito
+	currentStock, err := s.getProduct(ctx, tx, m.ProductID)
 	if err != nil {
 		return 0, fmt.Errorf("erro ao obter produto: %v", err)
 	}
+	balance, err := s.repo.GetBalanceTx(ctx, tx, m.WarehouseID, m.ProductID)
+	if err != nil {
+		return 0, err
+	}
+
+	// lotes: define (e atualiza) os lotes que o movimento mexe — FEFO na saída sem lote
+	allocs, err := s.allocateLots(ctx, tx, m, balance)
+	if err != nil {
+		return 0, err
+	}
 
-	// dependendo do tipo, calcula novo estoque
-	newStock := currentStock // começa com estoque atual
+	// dependendo do tipo, calcula novo saldo do depósito
+	newBalance := balance // começa com saldo atual
 	switch m.Type {
 	case "Entrada":
-		newStock += m.Quantity 
+		newBalance += m.Quantity
 	case "Saida":
-		newStock -= m.Quantity
+		newBalance -= m.Quantity
 	case "Ajuste":
-		// ajuste significa que o estoque passa a ser exatamente m.Quantity
-		newStock = m.Quantity	
-}
+		if m.Lot != "" {
+			// ajuste de um lote: o depósito muda só a diferença daquele lote
+			newBalance += allocs[0].Quantity
+		} else {
+			// ajuste significa que o saldo do depósito passa a ser exatamente m.Quantity
+			newBalance = m.Quantity
+		}
+	}
+	// o total do produto muda na mesma proporção do depósito
+	newStock := currentStock + (newBalance - balance)
+
+	// custo: custo médio e camadas FIFO acompanham o total do produto
+	// (transferência só muda o estoque de lugar, então não tem custo)
+	var consumptions []costConsumption
+	if !m.transfer {
+		consumptions, err = s.applyCost(ctx, tx, m, currentStock, newStock-currentStock)
+		if err != nil {
+			return 0, err
+		}
+	}
 
-// evita estoque negativo em saídas (pode ser configurável futuramente)
-//if !s.allowNegative && newStock < 0 {
-//	return 0, errors.New("estoque insuficiente para saída")
-//}
+	// estoque negativo: a política do produto (ou da categoria, ou a global) decide
+	if newBalance < 0 && newBalance < balance {
+		if err := s.checkNegative(ctx, tx, m, balance, newBalance); err != nil {
+			return 0, err
+		}
+	}
 
-// agora fazemos a operação dentro de uma transação para garantir atomicidade
-tx, err := s.db.BeginTx(ctx, nil)
-if err != nil {
-	return 0, fmt.Errorf("erro ao iniciar transação: %v", err)
+	// 2) atualizar o saldo do depósito e o estoque total na tabela products
+	if err := s.repo.SetBalanceTx(ctx, tx, m.WarehouseID, m.ProductID, newBalance); err != nil {
+		return 0, err
+	}
+	if err := s.updateStock(ctx, tx, m.ProductID, newStock); err != nil {
+		return 0, fmt.Errorf("erro ao atualizar estoque do produto: %v", err)
+	}
+
+	// 3) inserir o registro de movimento (e os lotes movimentados) na mesma transação,
+	// guardando o saldo anterior do depósito e a diferença aplicada
+	delta := newBalance - balance
+	m.PreviousQuantity, m.Delta = &balance, &delta
+	id, err := s.repo.Insert(ctx, tx, m)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.InsertMovementLotsTx(ctx, tx, id, allocs); err != nil {
+		return 0, err
+	}
+	m.Lots = allocs
+
+	// o que entrou abre uma camada FIFO com o custo do movimento; o que saiu registra as camadas consumidas
+	if !m.transfer && newStock > currentStock {
+		if err := s.repo.InsertCostLayerTx(ctx, tx, m.ProductID, id, *m.UnitCost, newStock-currentStock); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.repo.InsertCostConsumptionsTx(ctx, tx, id, consumptions); err != nil {
+		return 0, err
+	}
+
+	// alerta de reposição: a Saida que leva o produto ao ponto de pedido grava um evento
+	if m.Type == "Saida" && !m.transfer {
+		if err := s.checkReorder(ctx, tx, m, id, currentStock, newStock); err != nil {
+			return 0, err
+		}
+	}
+
+	return id, nil
 }
 
-// 1) atualizar o estoque na tabela products usando a função injetada (que pode usar tx)
-if err := s.updateStock(ctx, m.ProductID, newStock); err != nil {
-	_ = tx.Rollback() // tenta rollback em caso de erro
-	return 0, fmt.Errorf("erro ao atualizar estoque do produto: %v", err)
+// Transfer move estoque de um depósito para outro: posta uma Saida na origem (FEFO) e as
+// Entradas correspondentes no destino na mesma transação (o total do produto não muda).
+func (s *Service) Transfer(ctx context.Context, productID, fromWarehouseID, toWarehouseID int, quantity float64) (*Transfer, error) {
+	if fromWarehouseID == 0 || toWarehouseID == 0 {
+		return nil, errors.New("informe os depósitos de origem e destino")
+	}
+	if fromWarehouseID == toWarehouseID {
+		return nil, errors.New("depósitos de origem e destino devem ser diferentes")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	saida := &Movement{
+		ProductID:   productID,
+		WarehouseID: fromWarehouseID,
+		Type:        "Saida",
+		Quantity:    quantity,
+		Reason:      MotivoTransferencia,
+		transfer:    true,
+	}
+	saidaID, err := s.CreateMovementTx(ctx, tx, saida)
+	if err != nil {
+		return nil, err
+	}
+	// as Entradas apontam para a Saida que as originou
+	document := fmt.Sprintf("transferencia:%d", saidaID)
+
+	// no destino, cada lote que saiu da origem entra com o mesmo código e validade;
+	// o que saiu sem lote entra sem lote
+	var entradas []*Movement
+	untracked := quantity
+	for _, a := range saida.Lots {
+		entradas = append(entradas, &Movement{
+			ProductID:   productID,
+			WarehouseID: toWarehouseID,
+			Type:        "Entrada",
+			Quantity:    a.Quantity,
+			Lot:         a.Lot,
+			ExpiresAt:   a.ExpiresAt,
+			Reason:      MotivoTransferencia,
+			Document:    document,
+			transfer:    true,
+		})
+		untracked -= a.Quantity
+	}
+	if untracked > 0 {
+		entradas = append(entradas, &Movement{
+			ProductID:   productID,
+			WarehouseID: toWarehouseID,
+			Type:        "Entrada",
+			Quantity:    untracked,
+			Reason:      MotivoTransferencia,
+			Document:    document,
+			transfer:    true,
+		})
+	}
+
+	t := &Transfer{
+		ProductID:       productID,
+		FromWarehouseID: fromWarehouseID,
+		ToWarehouseID:   toWarehouseID,
+		Quantity:        quantity,
+		SaidaID:         saidaID,
+		Warning:         saida.Warning,
+	}
+	for _, e := range entradas {
+		id, err := s.CreateMovementTx(ctx, tx, e)
+		if err != nil {
+			return nil, err
+		}
+		t.EntradaIDs = append(t.EntradaIDs, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+
+	return t, nil
 }
 
-// 2) inserir o registro de movimento (usando repo, que usa a conexao principal - OK)
-id, err := s.repo.Insert(ctx, m)
-if err != nil {
-	_ = tx.Rollback() // tenta rollback em caso de erro
-	return 0, fmt.Errorf("erro ao inserir movimentação de estoque: %v", err)
+// CreateBatch aplica um lote de movimentos (tipos misturados) numa transação só: todos são
+// validados antes, e se qualquer um falhar nada é gravado. O resultado traz uma linha por
+// movimento (com o erro da linha que falhou); err != nil quando o lote não foi aplicado.
+func (s *Service) CreateBatch(ctx context.Context, moves []*Movement) (*BatchResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // desfaz tudo se alguma linha falhar
+
+	result, err := s.CreateBatchTx(ctx, tx, moves)
+	if err != nil {
+		return result, err
+	}
+	if err := tx.Commit(); err != nil {
+		result.Applied, result.Error = false, err.Error()
+		for i := range result.Lines {
+			result.Lines[i].MovementID = nil
+		}
+		return result, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	s.notifyAlerts(moves...)
+	return result, nil
 }
 
-// 3) commit da transação
-if err := tx.Commit(); err != nil {
-	return 0, fmt.Errorf("erro ao commitar transação: %v", err)
+// CreateBatchTx faz o mesmo que CreateBatch dentro de uma transação aberta por outro módulo
+// (ex.: recebimento de um pedido de compra). Se der erro, quem chamou deve fazer o rollback.
+func (s *Service) CreateBatchTx(ctx context.Context, tx *sql.Tx, moves []*Movement) (*BatchResult, error) {
+	result := &BatchResult{Lines: make([]BatchLine, len(moves))}
+	fail := func(err error) (*BatchResult, error) {
+		result.Error = err.Error()
+		for i := range result.Lines {
+			line := &result.Lines[i]
+			line.MovementID, line.Lots, line.Warning, line.Alert = nil, nil, "", nil
+		}
+		return result, err
+	}
+	if len(moves) == 0 {
+		return fail(errors.New("informe ao menos um movimento"))
+	}
+	if len(moves) > MaxBatchSize {
+		return fail(fmt.Errorf("lote com %d movimentos: o máximo é %d", len(moves), MaxBatchSize))
+	}
+
+	// 1) valida todas as linhas antes de mexer no estoque (reporta todas as inválidas)
+	var first error
+	for i, m := range moves {
+		if m.WarehouseID == 0 {
+			m.WarehouseID = DefaultWarehouseID
+		}
+		result.Lines[i] = BatchLine{
+			Line: i + 1, Type: m.Type, ProductID: m.ProductID, WarehouseID: m.WarehouseID, Quantity: m.Quantity,
+		}
+		if err := validateMovement(m); err != nil {
+			result.Lines[i].Error = err.Error()
+			if first == nil {
+				first = fmt.Errorf("linha %d: %w", i+1, err)
+			}
+		}
+	}
+	if first != nil {
+		return fail(first)
+	}
+
+	// 2) aplica em ordem; uma linha pode depender das anteriores (ex.: entrada e depois saída)
+	for i, m := range moves {
+		id, err := s.apply(ctx, tx, m)
+		if err != nil {
+			result.Lines[i].Error = err.Error()
+			return fail(fmt.Errorf("linha %d: %w", i+1, err))
+		}
+		result.Lines[i].MovementID = &id
+		result.Lines[i].Lots = m.Lots
+		result.Lines[i].Warning = m.Warning
+		result.Lines[i].Alert = m.Alert
+	}
+	result.Applied = true
+	return result, nil
 }
 
-return id, nil
+// Reverse estorna um movimento postando o(s) movimento(s) compensatório(s), ligados ao original
+// por reversal_of e com document "estorno:<id>", na mesma transação:
+//   - Entrada: Saida da mesma quantidade, do mesmo lote (se tinha lote);
+//   - Saida: Entrada da mesma quantidade pelo custo unitário aplicado na saída, devolvendo
+//     a cada lote o que saiu dele (uma Entrada por lote, mais uma para o que saiu sem lote);
+//   - Ajuste: aplica a diferença inversa sobre o saldo atual (do depósito ou do lote), então
+//     movimentos posteriores ao ajuste são preservados. Exige o saldo anterior registrado
+//     (ajustes anteriores à migração 0007 não têm).
+//
+// Não estorna estornos, pernas de transferência (faça a transferência de volta) nem
+// movimentos de orçamento (altere ou cancele o orçamento).
+func (s *Service) Reverse(ctx context.Context, id int64, notes string) (*Reversal, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback() // sem efeito depois do commit
+
+	orig, err := s.repo.GetMovementTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if orig == nil {
+		return nil, fmt.Errorf("movimento com ID %d não encontrado", id)
+	}
+	reversedBy, err := s.repo.ReversedByTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reversedBy != 0 {
+		return nil, fmt.Errorf("%w (estorno %d)", ErrJaEstornado, reversedBy)
+	}
+	switch {
+	case orig.ReversalOf != nil:
+		return nil, fmt.Errorf("%w: é o estorno do movimento %d", ErrNaoEstornavel, *orig.ReversalOf)
+	case orig.Reason == MotivoTransferencia:
+		return nil, fmt.Errorf("%w: faz parte de uma transferência; transfira de volta", ErrNaoEstornavel)
+	case strings.HasPrefix(orig.Document, "orcamento:"):
+		return nil, fmt.Errorf("%w: gerado pelo %s; altere ou cancele o orçamento", ErrNaoEstornavel, orig.Document)
+	case orig.Type == "Ajuste" && (orig.PreviousQuantity == nil || orig.Delta == nil):
+		return nil, fmt.Errorf("%w: ajuste sem saldo anterior registrado", ErrNaoEstornavel)
+	case orig.Type == "Ajuste" && *orig.Delta == 0:
+		return nil, fmt.Errorf("%w: ajuste não alterou o saldo", ErrNaoEstornavel)
+	}
+
+	base := Movement{
+		ProductID:   orig.ProductID,
+		WarehouseID: orig.WarehouseID,
+		Document:    fmt.Sprintf("estorno:%d", id),
+		Notes:       notes,
+		ReversalOf:  &id,
+	}
+	var reversals []*Movement
+	switch orig.Type {
+	case "Entrada":
+		m := base
+		m.Type, m.Quantity, m.reverseCost = "Saida", orig.Quantity, orig.UnitCost
+		if len(orig.Lots) > 0 {
+			m.Lot = orig.Lots[0].Lot
+		}
+		reversals = append(reversals, &m)
+
+	case "Saida":
+		untracked := orig.Quantity
+		for _, a := range orig.Lots {
+			m := base
+			m.Type, m.Quantity, m.Lot, m.UnitCost = "Entrada", a.Quantity, a.Lot, orig.UnitCost
+			reversals = append(reversals, &m)
+			untracked -= a.Quantity
+		}
+		if untracked > 0 {
+			m := base
+			m.Type, m.Quantity, m.UnitCost = "Entrada", untracked, orig.UnitCost
+			reversals = append(reversals, &m)
+		}
+
+	case "Ajuste":
+		m := base
+		m.Type = "Ajuste"
+		current, err := s.repo.GetBalanceTx(ctx, tx, orig.WarehouseID, orig.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if len(orig.Lots) > 0 {
+			// ajuste de lote: o estorno ajusta o mesmo lote
+			lot, err := s.repo.GetLotTx(ctx, tx, orig.ProductID, orig.WarehouseID, orig.Lots[0].Lot)
+			if err != nil {
+				return nil, err
+			}
+			if lot == nil {
+				return nil, fmt.Errorf("lote %s não encontrado no depósito %d", orig.Lots[0].Lot, orig.WarehouseID)
+			}
+			m.Lot, current = lot.Lot, lot.Quantity
+		}
+		m.Quantity = current - *orig.Delta
+		if m.Quantity < 0 {
+			return nil, fmt.Errorf("estorno deixaria saldo negativo (%v)", m.Quantity)
+		}
+		if *orig.Delta < 0 {
+			m.UnitCost = orig.UnitCost // o que o ajuste tirou volta pelo custo aplicado nele
+		} else {
+			m.reverseCost = orig.UnitCost // o que o ajuste pôs sai pelo custo dele
+		}
+		reversals = append(reversals, &m)
+	}
+
+	result := &Reversal{MovementID: id}
+	for _, m := range reversals {
+		rid, err := s.CreateMovementTx(ctx, tx, m)
+		if err != nil {
+			return nil, err
+		}
+		result.ReversalIDs = append(result.ReversalIDs, rid)
+		if m.Warning != "" {
+			result.Warning = m.Warning
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	s.notifyAlerts(reversals...)
+	return result, nil
 }
 
-// GetHistory retorna o historico de movimentações para um produto
-func (s *Service) GetHistory(ctx context.Context, productID int) ([]Movement, error) {
-	return s.repo.GetByProduct(ctx, productID)
+// GetHistory retorna o historico de movimentações para um produto,
+// opcionalmente filtrado por motivo e/ou documento (vazio = sem filtro)
+func (s *Service) GetHistory(ctx context.Context, productID int, reason, document string) ([]Movement, error) {
+	if reason != "" {
+		var err error
+		if reason, err = ParseReason(reason); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetByProduct(ctx, productID, reason, strings.TrimSpace(document))
 }
 
-// Saida reduz o estoque de um produto
+// Saida reduz o estoque de um produto (depósito padrão)
 func (s *Service) Saida(ctx context.Context, productID int, quantity float64) error {
 	// cria movimento de saída
 	m := &Movement{
 		ProductID: productID,
-		Type: "Saida",
-		Quantity: quantity,
+		Type:      "Saida",
+		Quantity:  quantity,
 	}
 	_, err := s.CreateMovement(ctx, m)
 	return err
 }
 
+// Entrada aumenta o estoque de um produto (depósito padrão)
 func (s *Service) Entrada(ctx context.Context, productID int, quantity float64) error {
 	// cria movimento de entrada
 	m := &Movement{
 		ProductID: productID,
-		Type: "Entrada",
-		Quantity: quantity,
+		Type:      "Entrada",
+		Quantity:  quantity,
 	}
 	_, err := s.CreateMovement(ctx, m)
 	return err
+}
+
+// SaidaTx reduz o estoque de um produto no depósito, dentro de uma transação já aberta.
+// reason e document identificam a origem (ex.: VENDA, "orcamento:7"); podem ser vazios.
+func (s *Service) SaidaTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, quantity float64, reason, document string) error {
+	_, err := s.CreateMovementTx(ctx, tx, &Movement{
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Type:        "Saida",
+		Quantity:    quantity,
+		Reason:      reason,
+		Document:    document,
+	})
+	return err
+}
+
+// EntradaTx aumenta o estoque de um produto no depósito, dentro de uma transação já aberta
+// (reason e document como em SaidaTx)
+func (s *Service) EntradaTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, quantity float64, reason, document string) error {
+	_, err := s.CreateMovementTx(ctx, tx, &Movement{
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Type:        "Entrada",
+		Quantity:    quantity,
+		Reason:      reason,
+		Document:    document,
+	})
+	return err
+}
+
+// AjusteTx define o saldo do produto no depósito usando a transação do chamador
+// (usado pelo inventário ao fechar uma contagem); retorna o ID do movimento
+func (s *Service) AjusteTx(ctx context.Context, tx *sql.Tx, warehouseID, productID int, quantity float64, reason, document string) (int64, error) {
+	return s.CreateMovementTx(ctx, tx, &Movement{
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Type:        "Ajuste",
+		Quantity:    quantity,
+		Reason:      reason,
+		Document:    document,
+	})
+}
+
+// --- Reservas ---
+// Uma reserva separa estoque para um orçamento aprovado sem mexer no estoque físico:
+// disponível = products.stock - reservas ATIVAS não vencidas.
+
+// ReserveTx cria uma reserva ATIVA de um produto no depósito para o orçamento, dentro da transação recebida.
+// A quantidade é comparada com o disponível do depósito (saldo - reservas ATIVAS não vencidas, lidos
+// na mesma transação): passando dele, a política de estoque negativo decide (BLOQUEAR devolve
+// *InsufficientStockError), como numa Saida.
+func (s *Service) ReserveTx(ctx context.Context, tx *sql.Tx, budgetID int64, warehouseID, productID int, quantity float64) error {
+	if quantity <= 0 {
+		return errors.New("quantidade deve ser maior que zero")
+	}
+	if warehouseID == 0 {
+		warehouseID = DefaultWarehouseID
+	}
+	active, err := s.repo.WarehouseActiveTx(ctx, tx, warehouseID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return fmt.Errorf("depósito %d não encontrado ou inativo", warehouseID)
+	}
+	// garante que o produto existe (a função injetada retorna erro se não existir)
+	if _, err := s.getProduct(ctx, tx, productID); err != nil {
+		return fmt.Errorf("erro ao obter produto: %v", err)
 	}
 
+	balance, err := s.repo.GetBalanceTx(ctx, tx, warehouseID, productID)
+	if err != nil {
+		return err
+	}
+	reserved, err := s.repo.ReservedTx(ctx, tx, warehouseID, productID)
+	if err != nil {
+		return err
+	}
+	available := balance - reserved
+	if available-quantity < 0 {
+		m := &Movement{ProductID: productID, WarehouseID: warehouseID}
+		if err := s.checkNegative(ctx, tx, m, available, available-quantity); err != nil {
+			return err
+		}
+	}
 
+	_, err = s.repo.InsertReservation(ctx, tx, &Reservation{
+		BudgetID:    budgetID,
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Quantity:    quantity,
+	}, int64(DefaultReservationTTL/time.Second))
+	return err
+}
 
+// ReleaseTx libera as reservas pendentes do orçamento (cancelamento, expiração ou troca de itens).
+// As EXPIRADAS também são liberadas: CommitTx baixa as duas, e uma reserva vencida que
+// sobrasse ao lado das novas seria baixada em dobro na conversão.
+func (s *Service) ReleaseTx(ctx context.Context, tx *sql.Tx, budgetID int64) error {
+	return s.repo.UpdateReservationsStatusTx(ctx, tx, budgetID, []string{ReservaAtiva, ReservaExpirada}, ReservaLiberada)
+}
+
+// CommitTx transforma as reservas do orçamento em Saida definitiva (conversão em venda).
+// Reservas que já venceram também são baixadas: a venda aconteceu e a mercadoria sai do mesmo jeito.
+func (s *Service) CommitTx(ctx context.Context, tx *sql.Tx, budgetID int64) error {
+	pending := []string{ReservaAtiva, ReservaExpirada}
+	list, err := s.repo.ListReservationsByBudgetTx(ctx, tx, budgetID, pending...)
+	if err != nil {
+		return err
+	}
+	for _, res := range list {
+		if err := s.SaidaTx(ctx, tx, res.WarehouseID, res.ProductID, res.Quantity, MotivoVenda, fmt.Sprintf("orcamento:%d", budgetID)); err != nil {
+			return err
+		}
+	}
+	return s.repo.UpdateReservationsStatusTx(ctx, tx, budgetID, pending, ReservaConvertida)
+}
+
+// ListReservations retorna as reservas ATIVAS (não vencidas) de um produto
+func (s *Service) ListReservations(ctx context.Context, productID int) ([]Reservation, error) {
+	return s.repo.ListActiveReservationsByProduct(ctx, productID)
+}
+
+// ExpireReservations marca como EXPIRADA as reservas vencidas e retorna quantas foram afetadas.
+// Reservas vencidas já não contam no reservado; isto só deixa o status explícito.
+func (s *Service) ExpireReservations(ctx context.Context) (int64, error) {
+	return s.repo.ExpireReservations(ctx)
+}
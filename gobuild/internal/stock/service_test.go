@@ -0,0 +1,388 @@
+package stock_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/database"
+	"github.com/EtraudBits/golangProject/gobuild/internal/product"
+	"github.com/EtraudBits/golangProject/gobuild/internal/stock"
+	"github.com/labstack/echo/v4"
+)
+
+// TestSaidaConcorrente dispara várias saídas em paralelo contra /api/stock/saida e
+// confere que nenhuma atualização se perdeu: estoque final = inicial - soma das saídas
+// e existe exatamente um registro em stock_movements por requisição.
+func TestSaidaConcorrente(t *testing.T) {
+	if err := database.ConnectPath(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("erro ao conectar: %v", err)
+	}
+	t.Cleanup(func() { _ = database.DB.Close() })
+
+	const (
+		estoqueInicial = 100.0
+		requisicoes    = 40
+		quantidade     = 2.0
+	)
+
+	productRepo := product.NewRepository(database.DB)
+	svc := stock.NewService(database.DB, stock.NewRepository(database.DB), productRepo.GetStockTx, productRepo.UpdateStockTx)
+
+	// o estoque inicial entra como uma Entrada (fora da contagem das saídas abaixo)
+	productID, err := product.NewService(productRepo, svc).Create(context.Background(), &product.Produto{
+		Name: "Cimento CP-II 50kg", Preco: 25.5, Estoque: estoqueInicial, Unidade: "saco", Categoria: "Materiais",
+	})
+	if err != nil {
+		t.Fatalf("erro ao inserir produto: %v", err)
+	}
+
+	e := echo.New()
+	stock.NewHandler(svc).RegisterRoutes(e.Group("/api/stock"))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, requisicoes)
+	body := fmt.Sprintf(`{"product_id":%d,"quantity":%v}`, productID, quantidade)
+
+	for i := 0; i < requisicoes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(srv.URL+"/api/stock/saida", echo.MIMEApplicationJSON, strings.NewReader(body))
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusCreated {
+				errs <- fmt.Errorf("status inesperado: %d", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("saída falhou: %v", err)
+	}
+
+	var estoque float64
+	if err := database.DB.QueryRow(`SELECT stock FROM products WHERE id = ?`, productID).Scan(&estoque); err != nil {
+		t.Fatalf("erro ao ler estoque: %v", err)
+	}
+	if want := estoqueInicial - requisicoes*quantidade; estoque != want {
+		t.Errorf("estoque final = %v, esperado %v", estoque, want)
+	}
+
+	var saldo float64
+	if err := database.DB.QueryRow(`SELECT quantity FROM stock_balances WHERE warehouse_id = ? AND product_id = ?`,
+		stock.DefaultWarehouseID, productID).Scan(&saldo); err != nil {
+		t.Fatalf("erro ao ler saldo do depósito: %v", err)
+	}
+	if saldo != estoque {
+		t.Errorf("saldo do depósito = %v, esperado %v (igual ao total)", saldo, estoque)
+	}
+
+	var movimentos int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM stock_movements WHERE product_id = ? AND tipo = 'Saida'`, productID).Scan(&movimentos); err != nil {
+		t.Fatalf("erro ao contar movimentos: %v", err)
+	}
+	if movimentos != requisicoes {
+		t.Errorf("saídas gravadas = %d, esperado %d", movimentos, requisicoes)
+	}
+}
+
+// reservaSetup cria um banco novo com um produto de estoque inicial e retorna o serviço de
+// estoque, o serviço de produto (para ler reservado/disponível) e o ID do produto
+func reservaSetup(t *testing.T, estoqueInicial float64) (*stock.Service, *product.Service, int) {
+	t.Helper()
+	if err := database.ConnectPath(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("erro ao conectar: %v", err)
+	}
+	t.Cleanup(func() { _ = database.DB.Close() })
+
+	productRepo := product.NewRepository(database.DB)
+	svc := stock.NewService(database.DB, stock.NewRepository(database.DB), productRepo.GetStockTx, productRepo.UpdateStockTx)
+	productSvc := product.NewService(productRepo, svc)
+	productID, err := productSvc.Create(context.Background(), &product.Produto{
+		Name: "Areia média m³", Preco: 120, Estoque: estoqueInicial, Unidade: "m3", Categoria: "Materiais",
+	})
+	if err != nil {
+		t.Fatalf("erro ao inserir produto: %v", err)
+	}
+	return svc, productSvc, int(productID)
+}
+
+// inTx roda fn numa transação e faz o commit (como o budget faz com ReserveTx/ReleaseTx/CommitTx)
+func inTx(t *testing.T, fn func(tx *sql.Tx) error) {
+	t.Helper()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		t.Fatalf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		t.Fatalf("erro na transação: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("erro ao commitar: %v", err)
+	}
+}
+
+// conferirEstoque compara estoque, reservado e disponível do produto
+func conferirEstoque(t *testing.T, productSvc *product.Service, productID int, estoque, reservado float64) {
+	t.Helper()
+	info, err := productSvc.GetStock(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("erro ao ler estoque: %v", err)
+	}
+	if info.Estoque != estoque || info.Reservado != reservado || info.Disponivel != estoque-reservado {
+		t.Errorf("estoque/reservado/disponível = %v/%v/%v, esperado %v/%v/%v",
+			info.Estoque, info.Reservado, info.Disponivel, estoque, reservado, estoque-reservado)
+	}
+}
+
+// contarSaidas conta as Saidas de venda gravadas para o orçamento
+func contarSaidas(t *testing.T, productID int, budgetID int64) (int, float64) {
+	t.Helper()
+	var n int
+	var total float64
+	err := database.DB.QueryRow(`SELECT COUNT(*), COALESCE(SUM(quantidade), 0) FROM stock_movements
+		WHERE product_id = ? AND tipo = 'Saida' AND document = ?`, productID, fmt.Sprintf("orcamento:%d", budgetID)).Scan(&n, &total)
+	if err != nil {
+		t.Fatalf("erro ao contar saídas: %v", err)
+	}
+	return n, total
+}
+
+// TestReservaConvertida: a reserva segura o disponível sem mexer no físico; na conversão
+// vira uma Saida e deixa de contar no reservado
+func TestReservaConvertida(t *testing.T) {
+	ctx := context.Background()
+	svc, productSvc, productID := reservaSetup(t, 100)
+	const budgetID = 1
+
+	inTx(t, func(tx *sql.Tx) error {
+		return svc.ReserveTx(ctx, tx, budgetID, stock.DefaultWarehouseID, productID, 10)
+	})
+	conferirEstoque(t, productSvc, productID, 100, 10)
+
+	inTx(t, func(tx *sql.Tx) error { return svc.CommitTx(ctx, tx, budgetID) })
+	conferirEstoque(t, productSvc, productID, 90, 0)
+	if n, q := contarSaidas(t, productID, budgetID); n != 1 || q != 10 {
+		t.Errorf("saídas = %d (%v), esperado 1 (10)", n, q)
+	}
+}
+
+// TestReservaLiberada: liberar a reserva devolve o disponível e não gera movimento
+func TestReservaLiberada(t *testing.T) {
+	ctx := context.Background()
+	svc, productSvc, productID := reservaSetup(t, 100)
+	const budgetID = 1
+
+	inTx(t, func(tx *sql.Tx) error {
+		return svc.ReserveTx(ctx, tx, budgetID, stock.DefaultWarehouseID, productID, 10)
+	})
+	inTx(t, func(tx *sql.Tx) error { return svc.ReleaseTx(ctx, tx, budgetID) })
+	conferirEstoque(t, productSvc, productID, 100, 0)
+
+	// depois de liberada, a conversão não tem o que baixar
+	inTx(t, func(tx *sql.Tx) error { return svc.CommitTx(ctx, tx, budgetID) })
+	if n, _ := contarSaidas(t, productID, budgetID); n != 0 {
+		t.Errorf("saídas = %d, esperado 0", n)
+	}
+}
+
+// TestReservaExpirada: reserva vencida deixa de contar no reservado; se o orçamento é
+// alterado (libera e reserva de novo) e depois convertido, a mercadoria sai uma vez só
+func TestReservaExpirada(t *testing.T) {
+	ctx := context.Background()
+	svc, productSvc, productID := reservaSetup(t, 100)
+	const budgetID = 1
+
+	inTx(t, func(tx *sql.Tx) error {
+		return svc.ReserveTx(ctx, tx, budgetID, stock.DefaultWarehouseID, productID, 10)
+	})
+	if _, err := database.DB.Exec(`UPDATE stock_reservations SET expires_at = datetime('now', '-1 hour')`); err != nil {
+		t.Fatalf("erro ao vencer a reserva: %v", err)
+	}
+	conferirEstoque(t, productSvc, productID, 100, 0)
+
+	expired, err := svc.ExpireReservations(ctx)
+	if err != nil {
+		t.Fatalf("erro ao expirar reservas: %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("reservas expiradas = %d, esperado 1", expired)
+	}
+
+	// alteração do orçamento aprovado: libera as reservas antigas e reserva os itens de novo
+	inTx(t, func(tx *sql.Tx) error {
+		if err := svc.ReleaseTx(ctx, tx, budgetID); err != nil {
+			return err
+		}
+		return svc.ReserveTx(ctx, tx, budgetID, stock.DefaultWarehouseID, productID, 10)
+	})
+	conferirEstoque(t, productSvc, productID, 100, 10)
+
+	inTx(t, func(tx *sql.Tx) error { return svc.CommitTx(ctx, tx, budgetID) })
+	conferirEstoque(t, productSvc, productID, 90, 0)
+	if n, q := contarSaidas(t, productID, budgetID); n != 1 || q != 10 {
+		t.Errorf("saídas = %d (%v), esperado 1 (10)", n, q)
+	}
+}
+
+// TestReservaAcimaDoDisponivel: dois orçamentos disputando o mesmo produto. O segundo só reserva o
+// que sobrou do primeiro; com BLOQUEAR o excesso é recusado com o disponível, com PERMITIR passa
+func TestReservaAcimaDoDisponivel(t *testing.T) {
+	ctx := context.Background()
+	svc, productSvc, productID := reservaSetup(t, 10)
+
+	inTx(t, func(tx *sql.Tx) error {
+		return svc.ReserveTx(ctx, tx, 1, stock.DefaultWarehouseID, productID, 7)
+	})
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		t.Fatalf("erro ao iniciar transação: %v", err)
+	}
+	err = svc.ReserveTx(ctx, tx, 2, stock.DefaultWarehouseID, productID, 7)
+	_ = tx.Rollback()
+	ise, ok := err.(*stock.InsufficientStockError)
+	if !ok {
+		t.Fatalf("erro = %v, esperado *InsufficientStockError", err)
+	}
+	if ise.Available != 3 || ise.Requested != 7 {
+		t.Errorf("disponível/solicitado = %v/%v, esperado 3/7", ise.Available, ise.Requested)
+	}
+	conferirEstoque(t, productSvc, productID, 10, 7)
+
+	// o que sobrou ainda pode ser reservado
+	inTx(t, func(tx *sql.Tx) error {
+		return svc.ReserveTx(ctx, tx, 2, stock.DefaultWarehouseID, productID, 3)
+	})
+	conferirEstoque(t, productSvc, productID, 10, 10)
+
+	// com PERMITIR o produto aceita reservar além do saldo
+	if _, err := svc.SetNegativePolicy(ctx, stock.NegativePolicy{ProductID: productID, Policy: stock.PoliticaPermitir}); err != nil {
+		t.Fatalf("erro ao gravar política: %v", err)
+	}
+	inTx(t, func(tx *sql.Tx) error {
+		return svc.ReserveTx(ctx, tx, 3, stock.DefaultWarehouseID, productID, 5)
+	})
+	conferirEstoque(t, productSvc, productID, 10, 15)
+}
+
+// movimentar posta um movimento fora de transação e devolve o movimento preenchido (custos,
+// lotes, aviso) e o ID
+func movimentar(t *testing.T, svc *stock.Service, m *stock.Movement) (*stock.Movement, int64) {
+	t.Helper()
+	id, err := svc.CreateMovement(context.Background(), m)
+	if err != nil {
+		t.Fatalf("erro no movimento %s de %v: %v", m.Type, m.Quantity, err)
+	}
+	return m, id
+}
+
+// custo devolve um ponteiro para o custo unitário da Entrada
+func custo(v float64) *float64 { return &v }
+
+// custoMedio lê o custo médio gravado no produto
+func custoMedio(t *testing.T, productID int) float64 {
+	t.Helper()
+	var avg float64
+	if err := database.DB.QueryRow(`SELECT average_cost FROM products WHERE id = ?`, productID).Scan(&avg); err != nil {
+		t.Fatalf("erro ao ler custo médio: %v", err)
+	}
+	return avg
+}
+
+// TestEstorno: estornar uma Entrada tira a quantidade dela e devolve o custo médio ao que era
+// antes; estornar uma Saida devolve a quantidade pelo custo aplicado, sem mexer na média
+func TestEstorno(t *testing.T) {
+	ctx := context.Background()
+	svc, productSvc, productID := reservaSetup(t, 0)
+
+	movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Entrada", Quantity: 10, UnitCost: custo(20)})
+	_, entradaID := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Entrada", Quantity: 10, UnitCost: custo(30)})
+	if avg := custoMedio(t, productID); avg != 25 {
+		t.Fatalf("custo médio = %v, esperado 25", avg)
+	}
+
+	r, err := svc.Reverse(ctx, entradaID, "nota lançada em dobro")
+	if err != nil {
+		t.Fatalf("erro ao estornar entrada: %v", err)
+	}
+	if len(r.ReversalIDs) != 1 {
+		t.Errorf("movimentos de estorno = %d, esperado 1", len(r.ReversalIDs))
+	}
+	conferirEstoque(t, productSvc, productID, 10, 0)
+	if avg := custoMedio(t, productID); avg != 20 {
+		t.Errorf("custo médio após o estorno da entrada = %v, esperado 20", avg)
+	}
+	if _, err := svc.Reverse(ctx, entradaID, ""); !errors.Is(err, stock.ErrJaEstornado) {
+		t.Errorf("segundo estorno: erro = %v, esperado ErrJaEstornado", err)
+	}
+
+	_, saidaID := movimentar(t, svc, &stock.Movement{ProductID: productID, Type: "Saida", Quantity: 4})
+	conferirEstoque(t, productSvc, productID, 6, 0)
+	if _, err := svc.Reverse(ctx, saidaID, ""); err != nil {
+		t.Fatalf("erro ao estornar saída: %v", err)
+	}
+	conferirEstoque(t, productSvc, productID, 10, 0)
+	if avg := custoMedio(t, productID); avg != 20 {
+		t.Errorf("custo médio após o estorno da saída = %v, esperado 20", avg)
+	}
+}
+
+// TestLoteDesfeitoPorInteiro: quando uma linha do lote falha, as anteriores (já aplicadas na
+// transação) são desfeitas — estoque, movimentos e custo ficam como antes
+func TestLoteDesfeitoPorInteiro(t *testing.T) {
+	svc, productSvc, productID := reservaSetup(t, 10)
+	contarMovimentos := func() int {
+		t.Helper()
+		var n int
+		if err := database.DB.QueryRow(`SELECT COUNT(*) FROM stock_movements WHERE product_id = ?`, productID).Scan(&n); err != nil {
+			t.Fatalf("erro ao contar movimentos: %v", err)
+		}
+		return n
+	}
+	antes, avgAntes := contarMovimentos(), custoMedio(t, productID)
+
+	result, err := svc.CreateBatch(context.Background(), []*stock.Movement{
+		{ProductID: productID, Type: "Entrada", Quantity: 5, UnitCost: custo(40)},
+		{ProductID: productID, Type: "Saida", Quantity: 3},
+		{ProductID: productID, Type: "Saida", Quantity: 100}, // mais do que o saldo (BLOQUEAR)
+	})
+	if err == nil {
+		t.Fatal("esperado erro na 3ª linha")
+	}
+	var ise *stock.InsufficientStockError
+	if !errors.As(err, &ise) {
+		t.Errorf("erro = %v, esperado *InsufficientStockError", err)
+	}
+	if result.Applied || result.Lines[2].Error == "" {
+		t.Errorf("resultado = %+v, esperado lote não aplicado com erro na linha 3", result)
+	}
+	for _, line := range result.Lines {
+		if line.MovementID != nil {
+			t.Errorf("linha %d ficou com movimento %d", line.Line, *line.MovementID)
+		}
+	}
+
+	conferirEstoque(t, productSvc, productID, 10, 0)
+	if n := contarMovimentos(); n != antes {
+		t.Errorf("movimentos = %d, esperado %d", n, antes)
+	}
+	if avg := custoMedio(t, productID); avg != avgAntes {
+		t.Errorf("custo médio = %v, esperado %v", avg, avgAntes)
+	}
+}
@@ -0,0 +1,129 @@
+package supplier
+
+import (
+	"net/http" // para constantes de status HTTP
+	"strconv"  // para conversão de string para int
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // status HTTP do erro do serviço
+	"github.com/labstack/echo/v4"                                 // framework web Echo
+)
+
+// Handler expõe os endpoints HTTP de fornecedores
+type Handler struct {
+	svc *Service
+}
+
+// NewHandler cria um novo handler com o serviço injetado
+func NewHandler(svc *Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// RegisterRoutes registra as rotas de fornecedor no grupo Echo (ex.: /api/suppliers)
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("", h.Create)
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.PUT("/:id", h.Update)
+	// produtos do fornecedor (código no fornecedor e último custo)
+	g.GET("/:id/products", h.Products)
+	g.PUT("/:id/products/:product_id", h.SetProduct)
+	g.DELETE("/:id/products/:product_id", h.DeleteProduct)
+}
+
+// Create cria um fornecedor.
+// Ex.: {"name": "Cimenteira Exemplo Ltda", "cnpj": "11.222.333/0001-81", "lead_time_days": 5,
+// "contacts": [{"name": "Carla", "role": "vendedora", "phone": "11 99999-0000"}]}
+func (h *Handler) Create(c echo.Context) error {
+	var req Supplier
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	id, err := h.svc.Create(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]int64{"id": id})
+}
+
+// List retorna os fornecedores (?ativos=true para só os ativos)
+func (h *Handler) List(c echo.Context) error {
+	list, err := h.svc.List(c.Request().Context(), c.QueryParam("ativos") == "true")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// Get retorna um fornecedor por id, com os contatos
+func (h *Handler) Get(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	s, err := h.svc.Get(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, s)
+}
+
+// Update atualiza um fornecedor (cadastro completo, incluindo "active" e a lista de contatos)
+func (h *Handler) Update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req Supplier
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	req.ID = id
+	if err := h.svc.Update(c.Request().Context(), &req); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "fornecedor atualizado com sucesso"})
+}
+
+// Products retorna os produtos vinculados ao fornecedor
+func (h *Handler) Products(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	list, err := h.svc.Products(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// SetProduct vincula um produto ao fornecedor. Ex.: {"supplier_code": "CP2-50", "last_cost": 28.9}
+func (h *Handler) SetProduct(c echo.Context) error {
+	id, err1 := strconv.Atoi(c.Param("id"))
+	productID, err2 := strconv.Atoi(c.Param("product_id"))
+	if err1 != nil || err2 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	var req ProductLink
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "dados inválidos: " + err.Error()})
+	}
+	req.SupplierID, req.ProductID = id, productID
+	if err := h.svc.SetProduct(c.Request().Context(), &req); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "produto vinculado ao fornecedor"})
+}
+
+// DeleteProduct remove o vínculo do produto com o fornecedor
+func (h *Handler) DeleteProduct(c echo.Context) error {
+	id, err1 := strconv.Atoi(c.Param("id"))
+	productID, err2 := strconv.Atoi(c.Param("product_id"))
+	if err1 != nil || err2 != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "ID inválido"})
+	}
+	if err := h.svc.DeleteProduct(c.Request().Context(), id, productID); err != nil {
+		return c.JSON(apperr.Status(err), map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "vínculo removido"})
+}
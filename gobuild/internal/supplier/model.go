@@ -0,0 +1,35 @@
+package supplier
+
+// Supplier representa um fornecedor
+type Supplier struct {
+	ID           int       `json:"id"`             // id auto-incremental (PK)
+	Name         string    `json:"name"`           // razão social
+	TradeName    string    `json:"trade_name"`     // nome fantasia (opcional)
+	CNPJ         string    `json:"cnpj"`           // gravado só com os dígitos
+	Email        string    `json:"email"`          // e-mail principal (opcional)
+	Phone        string    `json:"phone"`          // telefone principal (opcional)
+	LeadTimeDays int       `json:"lead_time_days"` // prazo de entrega em dias (previsão dos pedidos e reposição)
+	Notes        string    `json:"notes"`
+	Active       bool      `json:"active"`             // inativos não recebem pedidos de compra
+	CreatedAt    string    `json:"created_at"`         // timestamp de criação
+	Contacts     []Contact `json:"contacts,omitempty"` // pessoas de contato (vendedor, financeiro...)
+}
+
+// Contact é uma pessoa de contato do fornecedor
+type Contact struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"` // ex.: "vendedor", "financeiro"
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// ProductLink liga um produto a um fornecedor: código do produto no fornecedor e último
+// custo pago (atualizado pelo recebimento dos pedidos de compra)
+type ProductLink struct {
+	SupplierID     int      `json:"supplier_id"`
+	ProductID      int      `json:"product_id"`
+	Product        string   `json:"product"`
+	SupplierCode   string   `json:"supplier_code"`
+	LastCost       *float64 `json:"last_cost"`
+	LastPurchaseAt string   `json:"last_purchase_at,omitempty"`
+}
@@ -0,0 +1,235 @@
+package supplier
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL de fornecedores, contatos e produtos do fornecedor
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de fornecedores
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// supplierColumns são as colunas lidas por scanSupplier (mesma ordem)
+const supplierColumns = `id, name, COALESCE(trade_name, ''), cnpj, COALESCE(email, ''), COALESCE(phone, ''),
+	lead_time_days, COALESCE(notes, ''), active, created_at`
+
+// scanSupplier lê um fornecedor de uma linha (Row ou Rows), sem os contatos
+func scanSupplier(scan func(dest ...any) error) (Supplier, error) {
+	var s Supplier
+	err := scan(&s.ID, &s.Name, &s.TradeName, &s.CNPJ, &s.Email, &s.Phone,
+		&s.LeadTimeDays, &s.Notes, &s.Active, &s.CreatedAt)
+	return s, err
+}
+
+// CreateTx insere o fornecedor e os contatos e retorna o ID gerado
+func (r *Repository) CreateTx(ctx context.Context, tx *sql.Tx, s *Supplier) (int64, error) {
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO suppliers (name, trade_name, cnpj, email, phone, lead_time_days, notes, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.Name, s.TradeName, s.CNPJ, s.Email, s.Phone, s.LeadTimeDays, s.Notes, s.Active)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir fornecedor: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao obter ID do fornecedor: %v", err)
+	}
+	if err := r.replaceContactsTx(ctx, tx, int(id), s.Contacts); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateTx atualiza o cadastro e substitui os contatos do fornecedor
+func (r *Repository) UpdateTx(ctx context.Context, tx *sql.Tx, s *Supplier) error {
+	_, err := tx.ExecContext(ctx,
+		`UPDATE suppliers SET name = ?, trade_name = ?, cnpj = ?, email = ?, phone = ?,
+			lead_time_days = ?, notes = ?, active = ? WHERE id = ?`,
+		s.Name, s.TradeName, s.CNPJ, s.Email, s.Phone, s.LeadTimeDays, s.Notes, s.Active, s.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar fornecedor: %v", err)
+	}
+	return r.replaceContactsTx(ctx, tx, s.ID, s.Contacts)
+}
+
+// replaceContactsTx apaga os contatos do fornecedor e grava a lista informada
+func (r *Repository) replaceContactsTx(ctx context.Context, tx *sql.Tx, supplierID int, contacts []Contact) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM supplier_contacts WHERE supplier_id = ?`, supplierID); err != nil {
+		return fmt.Errorf("erro ao remover contatos do fornecedor: %v", err)
+	}
+	for _, c := range contacts {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO supplier_contacts (supplier_id, name, role, email, phone) VALUES (?, ?, ?, ?, ?)`,
+			supplierID, c.Name, c.Role, c.Email, c.Phone)
+		if err != nil {
+			return fmt.Errorf("erro ao inserir contato do fornecedor: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetAll retorna os fornecedores (sem os contatos), só os ativos se activeOnly
+func (r *Repository) GetAll(ctx context.Context, activeOnly bool) ([]Supplier, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT `+supplierColumns+` FROM suppliers WHERE ? = 0 OR active = 1 ORDER BY name, id`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar fornecedores: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Supplier
+	for rows.Next() {
+		s, err := scanSupplier(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear fornecedor: %v", err)
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos fornecedores: %v", err)
+	}
+	return list, nil
+}
+
+// GetByID busca um fornecedor pelo ID com os contatos (nil, nil se não existir)
+func (r *Repository) GetByID(ctx context.Context, id int) (*Supplier, error) {
+	s, err := scanSupplier(r.DB.QueryRowContext(ctx,
+		`SELECT `+supplierColumns+` FROM suppliers WHERE id = ?`, id).Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // fornecedor não encontrado
+		}
+		return nil, fmt.Errorf("erro ao escanear fornecedor: %v", err)
+	}
+	s.Contacts, err = r.listContacts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// IDByCNPJ retorna o ID do fornecedor com o CNPJ (0 se não houver)
+func (r *Repository) IDByCNPJ(ctx context.Context, cnpj string) (int, error) {
+	var id int
+	err := r.DB.QueryRowContext(ctx, `SELECT id FROM suppliers WHERE cnpj = ?`, cnpj).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("erro ao buscar fornecedor pelo CNPJ: %v", err)
+	}
+	return id, nil
+}
+
+// listContacts retorna os contatos do fornecedor na ordem de cadastro
+func (r *Repository) listContacts(ctx context.Context, supplierID int) ([]Contact, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT name, COALESCE(role, ''), COALESCE(email, ''), COALESCE(phone, '')
+		FROM supplier_contacts WHERE supplier_id = ? ORDER BY id`, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contatos do fornecedor: %v", err)
+	}
+	defer rows.Close()
+
+	list := []Contact{}
+	for rows.Next() {
+		var c Contact
+		if err := rows.Scan(&c.Name, &c.Role, &c.Email, &c.Phone); err != nil {
+			return nil, fmt.Errorf("erro ao escanear contato: %v", err)
+		}
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos contatos: %v", err)
+	}
+	return list, nil
+}
+
+// ProductExists informa se o produto existe
+func (r *Repository) ProductExists(ctx context.Context, productID int) (bool, error) {
+	var n int
+	err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM products WHERE id = ?`, productID).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar produto: %v", err)
+	}
+	return n > 0, nil
+}
+
+// ListProducts retorna os produtos vinculados ao fornecedor
+func (r *Repository) ListProducts(ctx context.Context, supplierID int) ([]ProductLink, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT ps.supplier_id, ps.product_id, COALESCE(p.name, ''), COALESCE(ps.supplier_code, ''),
+			ps.last_cost, COALESCE(ps.last_purchase_at, '')
+		FROM product_suppliers ps
+		LEFT JOIN products p ON p.id = ps.product_id
+		WHERE ps.supplier_id = ?
+		ORDER BY p.name, ps.product_id`, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar produtos do fornecedor: %v", err)
+	}
+	defer rows.Close()
+
+	list := []ProductLink{}
+	for rows.Next() {
+		var l ProductLink
+		var lastCost sql.NullFloat64 // NULL até o primeiro recebimento
+		if err := rows.Scan(&l.SupplierID, &l.ProductID, &l.Product, &l.SupplierCode, &lastCost, &l.LastPurchaseAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear produto do fornecedor: %v", err)
+		}
+		if lastCost.Valid {
+			l.LastCost = &lastCost.Float64
+		}
+		list = append(list, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos produtos do fornecedor: %v", err)
+	}
+	return list, nil
+}
+
+// UpsertProduct cria ou atualiza o vínculo produto-fornecedor. Sem last_cost informado,
+// o último custo gravado (dos recebimentos) é mantido.
+func (r *Repository) UpsertProduct(ctx context.Context, l *ProductLink) error {
+	_, err := r.DB.ExecContext(ctx,
+		`INSERT INTO product_suppliers (supplier_id, product_id, supplier_code, last_cost)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (supplier_id, product_id) DO UPDATE SET
+			supplier_code = excluded.supplier_code,
+			last_cost = COALESCE(excluded.last_cost, product_suppliers.last_cost)`,
+		l.SupplierID, l.ProductID, nullString(l.SupplierCode), l.LastCost)
+	if err != nil {
+		return fmt.Errorf("erro ao vincular produto ao fornecedor: %v", err)
+	}
+	return nil
+}
+
+// DeleteProduct remove o vínculo; retorna false se ele não existia
+func (r *Repository) DeleteProduct(ctx context.Context, supplierID, productID int) (bool, error) {
+	result, err := r.DB.ExecContext(ctx,
+		`DELETE FROM product_suppliers WHERE supplier_id = ? AND product_id = ?`, supplierID, productID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover vínculo do produto: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar vínculo removido: %v", err)
+	}
+	return n > 0, nil
+}
+
+// nullString grava texto vazio como NULL
+func nullString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,177 @@
+package supplier
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"fmt"     // para formatação de strings e erros
+	"strings" // para limpar os campos de texto
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+	"github.com/EtraudBits/golangProject/gobuild/internal/taxid"  // validação do CNPJ
+)
+
+// ErrCNPJDuplicado indica CNPJ já cadastrado em outro fornecedor (o handler responde 409)
+var ErrCNPJDuplicado = apperr.Conflict("já existe fornecedor com este CNPJ")
+
+// Service contém as regras de negócio de fornecedores
+type Service struct {
+	repo *Repository // dependencia do repositorio para persistencia
+}
+
+// NewService cria uma nova instância do serviço de fornecedores
+func NewService(r *Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// validate limpa e valida o cadastro antes de salvar (o CNPJ fica só com os dígitos)
+func validate(s *Supplier) error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.TradeName = strings.TrimSpace(s.TradeName)
+	if s.Name == "" {
+		return apperr.Invalid("a razão social do fornecedor não pode ser vazia")
+	}
+	cnpj, ok := taxid.CNPJ(s.CNPJ)
+	if !ok {
+		return apperr.Invalid("CNPJ inválido: %s", s.CNPJ)
+	}
+	s.CNPJ = cnpj
+	if s.LeadTimeDays < 0 {
+		return apperr.Invalid("o prazo de entrega não pode ser negativo")
+	}
+	for i := range s.Contacts {
+		s.Contacts[i].Name = strings.TrimSpace(s.Contacts[i].Name)
+		if s.Contacts[i].Name == "" {
+			return apperr.Invalid("contato %d: informe o nome", i+1)
+		}
+	}
+	return nil
+}
+
+// Create cadastra um fornecedor (ativo) com os contatos
+func (s *Service) Create(ctx context.Context, sup *Supplier) (int64, error) {
+	if err := validate(sup); err != nil {
+		return 0, fmt.Errorf("validação do fornecedor falhou: %w", err)
+	}
+	if err := s.checkCNPJ(ctx, sup.CNPJ, 0); err != nil {
+		return 0, err
+	}
+	sup.Active = true
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	id, err := s.repo.CreateTx(ctx, tx, sup)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return id, nil
+}
+
+// checkCNPJ recusa CNPJ já usado por outro fornecedor (exceptID é o próprio, na atualização)
+func (s *Service) checkCNPJ(ctx context.Context, cnpj string, exceptID int) error {
+	id, err := s.repo.IDByCNPJ(ctx, cnpj)
+	if err != nil {
+		return err
+	}
+	if id != 0 && id != exceptID {
+		return fmt.Errorf("%w (fornecedor %d)", ErrCNPJDuplicado, id)
+	}
+	return nil
+}
+
+// List retorna os fornecedores (só os ativos se activeOnly)
+func (s *Service) List(ctx context.Context, activeOnly bool) ([]Supplier, error) {
+	list, err := s.repo.GetAll(ctx, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []Supplier{}
+	}
+	return list, nil
+}
+
+// Get retorna um fornecedor por ID com os contatos, ou erro se não encontrado
+func (s *Service) Get(ctx context.Context, id int) (*Supplier, error) {
+	sup, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sup == nil {
+		return nil, apperr.NotFound("fornecedor com ID %d não encontrado", id)
+	}
+	return sup, nil
+}
+
+// Update atualiza o cadastro do fornecedor; os contatos informados substituem os atuais
+func (s *Service) Update(ctx context.Context, sup *Supplier) error {
+	if err := validate(sup); err != nil {
+		return fmt.Errorf("validação do fornecedor falhou: %w", err)
+	}
+	if _, err := s.Get(ctx, sup.ID); err != nil {
+		return err
+	}
+	if err := s.checkCNPJ(ctx, sup.CNPJ, sup.ID); err != nil {
+		return err
+	}
+
+	tx, err := s.repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.UpdateTx(ctx, tx, sup); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao commitar transação: %v", err)
+	}
+	return nil
+}
+
+// Products retorna os produtos vinculados ao fornecedor
+func (s *Service) Products(ctx context.Context, supplierID int) ([]ProductLink, error) {
+	if _, err := s.Get(ctx, supplierID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListProducts(ctx, supplierID)
+}
+
+// SetProduct vincula (ou atualiza o vínculo de) um produto ao fornecedor
+func (s *Service) SetProduct(ctx context.Context, l *ProductLink) error {
+	if _, err := s.Get(ctx, l.SupplierID); err != nil {
+		return err
+	}
+	exists, err := s.repo.ProductExists(ctx, l.ProductID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apperr.NotFound("produto com ID %d não encontrado", l.ProductID)
+	}
+	l.SupplierCode = strings.TrimSpace(l.SupplierCode)
+	if l.LastCost != nil && *l.LastCost < 0 {
+		return apperr.Invalid("o custo não pode ser negativo")
+	}
+	return s.repo.UpsertProduct(ctx, l)
+}
+
+// DeleteProduct remove o vínculo do produto com o fornecedor
+func (s *Service) DeleteProduct(ctx context.Context, supplierID, productID int) error {
+	ok, err := s.repo.DeleteProduct(ctx, supplierID, productID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperr.NotFound("produto %d não vinculado ao fornecedor %d: vínculo não encontrado", productID, supplierID)
+	}
+	return nil
+}
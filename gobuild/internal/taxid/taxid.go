@@ -0,0 +1,104 @@
+// Package taxid valida os documentos fiscais brasileiros (CPF e CNPJ) usados no cadastro de
+// clientes e fornecedores. Os documentos são gravados só com os dígitos.
+package taxid
+
+import "strings" // para montar o documento só com os dígitos
+
+// Tipos de pessoa do cadastro
+const (
+	PessoaFisica   = "PF" // CPF
+	PessoaJuridica = "PJ" // CNPJ
+)
+
+// digits tira a pontuação aceita (. / - e espaço) e devolve os dígitos; ok é false se o
+// texto tiver outro caractere
+func digits(v string) (ds []int, s string, ok bool) {
+	var b strings.Builder
+	for _, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+			ds = append(ds, int(r-'0'))
+			b.WriteRune(r)
+		case r == '.' || r == '/' || r == '-' || r == ' ':
+			// pontuação aceita: 123.456.789-09, 12.345.678/0001-95
+		default:
+			return nil, "", false
+		}
+	}
+	return ds, b.String(), true
+}
+
+// allSame informa se todos os dígitos são iguais (passam na conta mas não são documento)
+func allSame(ds []int) bool {
+	for _, d := range ds[1:] {
+		if d != ds[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// mod11 é o dígito verificador módulo 11 (resto < 2 vira 0)
+func mod11(sum int) int {
+	if rest := sum % 11; rest >= 2 {
+		return 11 - rest
+	}
+	return 0
+}
+
+// CPF tira a pontuação do CPF e confere os dígitos verificadores.
+// Retorna os 11 dígitos e se o CPF é válido.
+func CPF(v string) (string, bool) {
+	ds, s, ok := digits(v)
+	if !ok || len(ds) != 11 || allSame(ds) {
+		return "", false
+	}
+	// dígito verificador: pesos 10..2 para o primeiro e 11..2 para o segundo
+	check := func(n int) int {
+		sum := 0
+		for i, d := range ds[:n] {
+			sum += d * (n + 1 - i)
+		}
+		return mod11(sum)
+	}
+	if check(9) != ds[9] || check(10) != ds[10] {
+		return "", false
+	}
+	return s, true
+}
+
+// CNPJ tira a pontuação do CNPJ e confere os dígitos verificadores.
+// Retorna os 14 dígitos e se o CNPJ é válido.
+func CNPJ(v string) (string, bool) {
+	ds, s, ok := digits(v)
+	if !ok || len(ds) != 14 || allSame(ds) {
+		return "", false
+	}
+	// dígito verificador: pesos 5..2,9..2 para o primeiro e 6..2,9..2 para o segundo
+	check := func(n int) int {
+		sum, weight := 0, n-7
+		for _, d := range ds[:n] {
+			sum += d * weight
+			weight--
+			if weight < 2 {
+				weight = 9
+			}
+		}
+		return mod11(sum)
+	}
+	if check(12) != ds[12] || check(13) != ds[13] {
+		return "", false
+	}
+	return s, true
+}
+
+// Document confere o documento conforme o tipo de pessoa (PF: CPF, PJ: CNPJ)
+func Document(kind, v string) (string, bool) {
+	switch kind {
+	case PessoaFisica:
+		return CPF(v)
+	case PessoaJuridica:
+		return CNPJ(v)
+	}
+	return "", false
+}
@@ -0,0 +1,82 @@
+package taxid_test
+
+import (
+	"testing"
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/taxid"
+)
+
+// TestCPF confere dígitos verificadores, pontuação aceita e documentos recusados
+func TestCPF(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		valid bool
+	}{
+		{"52998224725", "52998224725", true},
+		{"529.982.247-25", "52998224725", true},
+		{"111.444.777-35", "11144477735", true},
+		{" 111 444 777 35 ", "11144477735", true},
+		{"529.982.247-24", "", false}, // segundo dígito errado
+		{"529.982.247-15", "", false}, // primeiro dígito errado
+		{"111.111.111-11", "", false}, // todos iguais
+		{"000.000.000-00", "", false},
+		{"5299822472", "", false},   // dígitos a menos
+		{"529982247250", "", false}, // dígitos a mais
+		{"529.982.247_25", "", false},
+		{"52998224a25", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := taxid.CPF(tt.in)
+		if ok != tt.valid || got != tt.want {
+			t.Errorf("CPF(%q) = %q, %v; esperado %q, %v", tt.in, got, ok, tt.want, tt.valid)
+		}
+	}
+}
+
+// TestCNPJ confere dígitos verificadores, pontuação aceita e documentos recusados
+func TestCNPJ(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		valid bool
+	}{
+		{"11222333000181", "11222333000181", true},
+		{"11.222.333/0001-81", "11222333000181", true},
+		{"11.444.777/0001-61", "11444777000161", true},
+		{"11.222.333/0001-82", "", false}, // segundo dígito errado
+		{"11.222.333/0001-71", "", false}, // primeiro dígito errado
+		{"11.111.111/1111-11", "", false}, // todos iguais
+		{"00.000.000/0000-00", "", false},
+		{"1122233300018", "", false}, // dígitos a menos
+		{"11.222.333\\0001-81", "", false},
+		{"52998224725", "", false}, // CPF no lugar de CNPJ
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := taxid.CNPJ(tt.in)
+		if ok != tt.valid || got != tt.want {
+			t.Errorf("CNPJ(%q) = %q, %v; esperado %q, %v", tt.in, got, ok, tt.want, tt.valid)
+		}
+	}
+}
+
+// TestDocument confere que o tipo de pessoa escolhe a validação
+func TestDocument(t *testing.T) {
+	tests := []struct {
+		kind, in string
+		valid    bool
+	}{
+		{taxid.PessoaFisica, "529.982.247-25", true},
+		{taxid.PessoaJuridica, "11.222.333/0001-81", true},
+		{taxid.PessoaFisica, "11.222.333/0001-81", false},
+		{taxid.PessoaJuridica, "529.982.247-25", false},
+		{"PX", "529.982.247-25", false},
+	}
+	for _, tt := range tests {
+		if _, ok := taxid.Document(tt.kind, tt.in); ok != tt.valid {
+			t.Errorf("Document(%q, %q) = %v, esperado %v", tt.kind, tt.in, ok, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package warehouse
+
+// DefaultID é o depósito padrão criado pela migração (recebeu o estoque que já existia)
+const DefaultID = 1
+
+// Warehouse representa um depósito/local de estoque (ex.: loja, pátio)
+type Warehouse struct {
+	ID        int    `json:"id"`         // id auto-incremental (PK)
+	Name      string `json:"name"`       // nome do depósito (ex.: "Pátio")
+	Address   string `json:"address"`    // endereço (opcional)
+	Active    bool   `json:"active"`     // depósitos inativos não recebem movimentações
+	CreatedAt string `json:"created_at"` // timestamp de criação
+}
+
+// Balance é o saldo de um produto em um depósito
+type Balance struct {
+	WarehouseID int     `json:"warehouse_id"`
+	ProductID   int     `json:"product_id"`
+	Product     string  `json:"product"` // nome do produto (para exibição)
+	Quantity    float64 `json:"quantity"`
+}
@@ -0,0 +1,108 @@
+package warehouse
+
+import (
+	"context"      // Para passar contexto em operações de banco de dados
+	"database/sql" // pacote sql para manipulação de rows/ results
+	"fmt"          // para formatação de strings e erros
+)
+
+// Repository lida com o SQL de depósitos (warehouses) e leitura de saldos (stock_balances)
+type Repository struct {
+	DB *sql.DB // Conexão com o banco (injetada na criação do repositório)
+}
+
+// NewRepository cria uma nova instância do repositório de depósitos
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{
+		DB: db,
+	}
+}
+
+// Create insere um depósito e retorna o ID gerado
+func (r *Repository) Create(ctx context.Context, w *Warehouse) (int64, error) {
+	result, err := r.DB.ExecContext(ctx,
+		`INSERT INTO warehouses (name, address, active) VALUES (?, ?, ?)`,
+		w.Name, w.Address, w.Active,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir depósito: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetAll retorna todos os depósitos
+func (r *Repository) GetAll(ctx context.Context) ([]Warehouse, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT id, name, COALESCE(address, ''), active, created_at FROM warehouses ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar depósitos: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Warehouse
+	for rows.Next() {
+		var w Warehouse
+		if err := rows.Scan(&w.ID, &w.Name, &w.Address, &w.Active, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear depósito: %v", err)
+		}
+		list = append(list, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos depósitos: %v", err)
+	}
+	return list, nil
+}
+
+// GetByID busca um depósito pelo ID (nil, nil se não existir)
+func (r *Repository) GetByID(ctx context.Context, id int) (*Warehouse, error) {
+	var w Warehouse
+	err := r.DB.QueryRowContext(ctx,
+		`SELECT id, name, COALESCE(address, ''), active, created_at FROM warehouses WHERE id = ?`, id,
+	).Scan(&w.ID, &w.Name, &w.Address, &w.Active, &w.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // depósito não encontrado
+		}
+		return nil, fmt.Errorf("erro ao escanear depósito: %v", err)
+	}
+	return &w, nil
+}
+
+// Update atualiza nome, endereço e situação de um depósito
+func (r *Repository) Update(ctx context.Context, w *Warehouse) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE warehouses SET name = ?, address = ?, active = ? WHERE id = ?`,
+		w.Name, w.Address, w.Active, w.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar depósito: %v", err)
+	}
+	return nil
+}
+
+// GetBalances retorna o saldo de cada produto no depósito
+func (r *Repository) GetBalances(ctx context.Context, warehouseID int) ([]Balance, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT b.warehouse_id, b.product_id, COALESCE(p.name, ''), b.quantity
+		FROM stock_balances b
+		LEFT JOIN products p ON p.id = b.product_id
+		WHERE b.warehouse_id = ?
+		ORDER BY p.name`, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar saldos do depósito: %v", err)
+	}
+	defer rows.Close()
+
+	var list []Balance
+	for rows.Next() {
+		var b Balance
+		if err := rows.Scan(&b.WarehouseID, &b.ProductID, &b.Product, &b.Quantity); err != nil {
+			return nil, fmt.Errorf("erro ao escanear saldo: %v", err)
+		}
+		list = append(list, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro durante iteração dos saldos: %v", err)
+	}
+	return list, nil
+}
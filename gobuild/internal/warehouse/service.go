@@ -0,0 +1,77 @@
+package warehouse
+
+import (
+	"context" // Para passar contexto em operações de banco de dados
+	"fmt"     // para formatação de strings e erros
+
+	"github.com/EtraudBits/golangProject/gobuild/internal/apperr" // categoria do erro (404, 409, 400)
+)
+
+// Service contém as regras de negócio de depósitos
+type Service struct {
+	repo *Repository // dependencia do repositorio para persistencia
+}
+
+// NewService cria uma nova instância do serviço de depósitos
+func NewService(r *Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// validate realiza validações básicas antes de salvar
+func validate(w *Warehouse) error {
+	if w.Name == "" {
+		return apperr.Invalid("o nome do depósito não pode ser vazio")
+	}
+	return nil
+}
+
+// Create cria um depósito (ativo por padrão)
+func (s *Service) Create(ctx context.Context, w *Warehouse) (int64, error) {
+	if err := validate(w); err != nil {
+		return 0, fmt.Errorf("validação do depósito falhou: %w", err)
+	}
+	w.Active = true
+	return s.repo.Create(ctx, w)
+}
+
+// List retorna todos os depósitos
+func (s *Service) List(ctx context.Context) ([]Warehouse, error) {
+	return s.repo.GetAll(ctx)
+}
+
+// Get retorna um depósito por ID, ou erro se não encontrado
+func (s *Service) Get(ctx context.Context, id int) (*Warehouse, error) {
+	w, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, apperr.NotFound("depósito com ID %d não encontrado", id)
+	}
+	return w, nil
+}
+
+// Update atualiza um depósito existente.
+// O depósito padrão não pode ser desativado (recebe movimentações sem depósito informado).
+func (s *Service) Update(ctx context.Context, w *Warehouse) error {
+	if err := validate(w); err != nil {
+		return fmt.Errorf("validação do depósito falhou: %w", err)
+	}
+	if _, err := s.Get(ctx, w.ID); err != nil {
+		return err
+	}
+	if w.ID == DefaultID && !w.Active {
+		return apperr.Invalid("o depósito padrão não pode ser desativado")
+	}
+	return s.repo.Update(ctx, w)
+}
+
+// Balances retorna o saldo de cada produto no depósito
+func (s *Service) Balances(ctx context.Context, id int) ([]Balance, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBalances(ctx, id)
+}